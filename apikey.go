@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/service"
+)
+
+const (
+	apiKeyUsage    = "usage: tasker apikey [-workspace <name>] create <name> <scope,...>|list|revoke <id>"
+	workspaceUsage = "usage: tasker workspace create <name>|list"
+	// cliActor audits the changes made from the command line
+	cliActor = "cli"
+)
+
+// runAPIKey runs the apikey subcommand on the -workspace workspace, the default one if not given. The key created is
+// printed once and can't be retrieved again
+func runAPIKey(srv service.Service, args []string) error {
+	flags := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	workspaceName := flags.String("workspace", "default", "name of the workspace the keys belong to")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf(apiKeyUsage)
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return fmt.Errorf(apiKeyUsage)
+	}
+
+	ctx := service.ContextWithActor(context.Background(), cliActor)
+	workspace, err := srv.GetWorkspaceByName(ctx, *workspaceName)
+	if err != nil {
+		return fmt.Errorf("workspace %s: %w", *workspaceName, err)
+	}
+	ctx = service.ContextWithWorkspace(ctx, workspace.ID)
+	switch args[0] {
+	case "create":
+		if len(args) != 3 {
+			return fmt.Errorf(apiKeyUsage)
+		}
+		key := entities.APIKey{Name: args[1]}
+		for _, scope := range strings.Split(args[2], ",") {
+			key.Scopes = append(key.Scopes, entities.Scope(strings.TrimSpace(scope)))
+		}
+		if err := key.IsValid(); err != nil {
+			return err
+		}
+
+		key, secret, err := srv.CreateAPIKey(ctx, key)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created api key %d %s on workspace %s with scopes %s, store it now, it can't be retrieved again:\n%s\n", key.ID, key.Name, workspace.Name, args[2], secret)
+		return nil
+	case "list":
+		keys, err := srv.GetAPIKeys(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			status := "active"
+			if key.RevokedAt != nil {
+				status = "revoked at " + key.RevokedAt.Format("2006-01-02 15:04:05")
+			}
+			scopes := make([]string, len(key.Scopes))
+			for i, scope := range key.Scopes {
+				scopes[i] = string(scope)
+			}
+			fmt.Printf("%d\t%s\t%s...\t%s\t%s\n", key.ID, key.Name, key.Prefix, strings.Join(scopes, ","), status)
+		}
+		return nil
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf(apiKeyUsage)
+		}
+		keyID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid api key ID %q, %s", args[1], apiKeyUsage)
+		}
+		if err := srv.RevokeAPIKey(ctx, keyID); err != nil {
+			return err
+		}
+		fmt.Printf("revoked api key %d\n", keyID)
+		return nil
+	default:
+		return fmt.Errorf("unknown apikey command %q, %s", args[0], apiKeyUsage)
+	}
+}
+
+// runWorkspace runs the workspace subcommand, the API keys created on a workspace only reach its tasks, schedules,
+// executions and secrets
+func runWorkspace(srv service.Service, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(workspaceUsage)
+	}
+
+	ctx := service.ContextWithActor(context.Background(), cliActor)
+	switch args[0] {
+	case "create":
+		if len(args) != 2 {
+			return fmt.Errorf(workspaceUsage)
+		}
+		workspace := entities.Workspace{Name: args[1]}
+		if err := workspace.IsValid(); err != nil {
+			return err
+		}
+
+		workspace, err := srv.CreateWorkspace(ctx, workspace)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created workspace %d %s\n", workspace.ID, workspace.Name)
+		return nil
+	case "list":
+		workspaces, err := srv.GetWorkspaces(ctx)
+		if err != nil {
+			return err
+		}
+		for _, workspace := range workspaces {
+			fmt.Printf("%d\t%s\t%s\n", workspace.ID, workspace.Name, workspace.CreatedAt.Format("2006-01-02 15:04:05"))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown workspace command %q, %s", args[0], workspaceUsage)
+	}
+}
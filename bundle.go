@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/service"
+)
+
+const (
+	exportUsage = "usage: tasker export [-workspace <name>] [-format yaml|json]"
+	importUsage = "usage: tasker import [-workspace <name>] [-dry-run] <file|->"
+)
+
+// runExport writes the bundle of the tasks and schedules of the -workspace workspace to the standard output
+func runExport(srv service.Service, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	workspaceName := flags.String("workspace", "default", "name of the workspace to export")
+	format := flags.String("format", entities.YAMLBundleFormat, "format of the bundle, yaml or json")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return fmt.Errorf(exportUsage)
+	}
+
+	ctx, err := workspaceContext(srv, *workspaceName)
+	if err != nil {
+		return err
+	}
+	bundle, err := srv.ExportBundle(ctx)
+	if err != nil {
+		return err
+	}
+	bundleData, err := bundle.Marshal(*format)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(bundleData)
+	return err
+}
+
+// runImport applies the bundle of the file, or of the standard input with -, to the -workspace workspace and prints the
+// changes. With -dry-run the changes are only printed
+func runImport(srv service.Service, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	workspaceName := flags.String("workspace", "default", "name of the workspace to import to")
+	dryRun := flags.Bool("dry-run", false, "print the changes without applying them")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return fmt.Errorf(importUsage)
+	}
+
+	var bundleData []byte
+	var err error
+	if path := flags.Arg(0); path == "-" {
+		bundleData, err = io.ReadAll(os.Stdin)
+	} else {
+		bundleData, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("reading bundle: %w", err)
+	}
+	bundle, err := entities.ParseBundle(bundleData)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := workspaceContext(srv, *workspaceName)
+	if err != nil {
+		return err
+	}
+	result, err := srv.ImportBundle(ctx, bundle, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range result.Changes {
+		name := change.Task
+		if change.Schedule != "" {
+			name += "/" + change.Schedule
+		}
+		fmt.Printf("%s\t%s\t%s", change.Action, change.EntityType, name)
+		if change.Action == entities.UpdateImportAction {
+			fmt.Printf("\t%s", change.Diff)
+		}
+		fmt.Println()
+	}
+	if result.DryRun {
+		fmt.Println("dry run, nothing was changed")
+	}
+	return nil
+}
+
+// workspaceContext returns the context of the CLI changes on the workspace with the name
+func workspaceContext(srv service.Service, workspaceName string) (context.Context, error) {
+	ctx := service.ContextWithActor(context.Background(), cliActor)
+	workspace, err := srv.GetWorkspaceByName(ctx, workspaceName)
+	if err != nil {
+		return nil, fmt.Errorf("workspace %s: %w", workspaceName, err)
+	}
+	return service.ContextWithWorkspace(ctx, workspace.ID), nil
+}
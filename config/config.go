@@ -0,0 +1,203 @@
+package config
+
+import (
+	"time"
+)
+
+// Config holds every setting of the server. The values are loaded from the defaults, then the JSON file given with
+// -config or TASKER_CONFIG, then the env variables on the env tags and finally the flags, named after the json keys
+// of each field, e.g. -http.addr=:8080
+type Config struct {
+	HTTP             HTTP                     `json:"http"`
+	MgmtDB           MgmtDB                   `json:"mgmt_db"`
+	ExecutionStorage ExecutionStorage         `json:"execution_storage"`
+	Scheduler        Scheduler                `json:"scheduler"`
+	HTTPClient       HTTPClient               `json:"http_client"`
+	Secrets          Secrets                  `json:"secrets"`
+	SQLDataSources   map[string]SQLDataSource `json:"sql_data_sources" env:"TASKER_SQL_DATA_SOURCES"`
+	Command          Command                  `json:"command"`
+	SMTP             SMTP                     `json:"smtp"`
+	Tracing          Tracing                  `json:"tracing"`
+	Log              Log                      `json:"log"`
+	Auth             Auth                     `json:"auth"`
+	// ShutdownTimeout is how long the shutdown waits for the running executions before saving them as interrupted
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" env:"TASKER_SHUTDOWN_TIMEOUT"`
+	// HealthCheckTimeout limits each dependency ping of /readyz
+	HealthCheckTimeout time.Duration `json:"health_check_timeout" env:"TASKER_HEALTH_CHECK_TIMEOUT"`
+}
+
+// HTTP configures the API server
+type HTTP struct {
+	Addr              string        `json:"addr" env:"TASKER_HTTP_ADDR"`
+	ReadTimeout       time.Duration `json:"read_timeout" env:"TASKER_HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout" env:"TASKER_HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `json:"write_timeout" env:"TASKER_HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `json:"idle_timeout" env:"TASKER_HTTP_IDLE_TIMEOUT"`
+	// Debug adds the full error message to the debug member of the error responses, it may show internal details
+	Debug bool `json:"debug" env:"TASKER_HTTP_DEBUG"`
+}
+
+// MgmtDB configures the management database of tasks, schedules, executions and secrets
+type MgmtDB struct {
+	Dialect         string        `json:"dialect" env:"TASKER_MGMT_DB_DIALECT"`
+	DSN             string        `json:"dsn" env:"TASKER_MGMT_DB_DSN" secret:"dsn"`
+	MaxOpenConns    int           `json:"max_open_conns" env:"TASKER_MGMT_DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `json:"max_idle_conns" env:"TASKER_MGMT_DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime" env:"TASKER_MGMT_DB_CONN_MAX_LIFETIME"`
+}
+
+// ExecutionStorage configures the storage used by the storage steps
+type ExecutionStorage struct {
+	Backend string `json:"backend" env:"TASKER_EXECUTION_STORAGE"`
+	// Path is the JSON file of the file backend
+	Path  string `json:"path" env:"TASKER_EXECUTION_STORAGE_PATH"`
+	Redis Redis  `json:"redis"`
+}
+
+type Redis struct {
+	Addr     string `json:"addr" env:"TASKER_REDIS_ADDR"`
+	Password string `json:"password" env:"TASKER_REDIS_PASSWORD" secret:"true"`
+	DB       int    `json:"db" env:"TASKER_REDIS_DB"`
+}
+
+// Scheduler configures the scheduled task executions
+type Scheduler struct {
+	// TaskTimeout limits each attempt of a scheduled execution
+	TaskTimeout time.Duration `json:"task_timeout" env:"TASKER_SCHEDULER_TASK_TIMEOUT"`
+	// Timezone the crons are evaluated on, as an IANA name
+	Timezone string `json:"timezone" env:"TASKER_SCHEDULER_TIMEZONE"`
+}
+
+// HTTPClient configures the outbound requests of the api call steps
+type HTTPClient struct {
+	Timeout             time.Duration `json:"timeout" env:"TASKER_HTTP_CLIENT_TIMEOUT"`
+	MaxIdleConns        int           `json:"max_idle_conns" env:"TASKER_HTTP_CLIENT_MAX_IDLE_CONNS"`
+	MaxIdleConnsPerHost int           `json:"max_idle_conns_per_host" env:"TASKER_HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST"`
+	IdleConnTimeout     time.Duration `json:"idle_conn_timeout" env:"TASKER_HTTP_CLIENT_IDLE_CONN_TIMEOUT"`
+	// Limits caps the requests sent to a target host, or to a group of hosts, keyed by the host or the group name
+	Limits map[string]OutboundLimit `json:"limits" env:"TASKER_HTTP_CLIENT_LIMITS"`
+	// DistributedLimits shares the limits between the instances through the execution_storage.redis server
+	DistributedLimits bool `json:"distributed_limits" env:"TASKER_HTTP_CLIENT_DISTRIBUTED_LIMITS"`
+}
+
+// OutboundLimit caps the api call steps requests, when Hosts is empty the limit key is the host it applies to
+type OutboundLimit struct {
+	Hosts []string `json:"hosts"`
+	// RatePerSecond refills a token bucket of Burst tokens, 0 disables the rate limit
+	RatePerSecond float64 `json:"rate_per_second"`
+	Burst         int     `json:"burst"`
+	// MaxConcurrent caps the requests in flight, 0 disables the concurrency limit
+	MaxConcurrent int `json:"max_concurrent"`
+	// OnLimit is wait, until the step times out, or fail to fail the step right away
+	OnLimit string `json:"on_limit"`
+}
+
+// Secrets configures the secrets store, disabled without a key
+type Secrets struct {
+	// Key is the base64 AES key secrets are encrypted with
+	Key string `json:"key" env:"TASKER_SECRETS_KEY" secret:"true"`
+}
+
+type SQLDataSource struct {
+	DSN      string `json:"dsn" secret:"dsn"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// Command configures the command steps
+type Command struct {
+	// Allowlist are the executables command steps are allowed to run, comma separated on env and flags
+	Allowlist []string `json:"allowlist" env:"TASKER_COMMAND_ALLOWLIST"`
+	WorkDir   string   `json:"work_dir" env:"TASKER_COMMAND_WORKDIR"`
+}
+
+// SMTP configures the relay used by the email steps
+type SMTP struct {
+	Host     string `json:"host" env:"TASKER_SMTP_HOST"`
+	Port     int    `json:"port" env:"TASKER_SMTP_PORT"`
+	Username string `json:"username" env:"TASKER_SMTP_USERNAME"`
+	Password string `json:"password" env:"TASKER_SMTP_PASSWORD" secret:"true"`
+	From     string `json:"from" env:"TASKER_SMTP_FROM"`
+	StartTLS bool   `json:"starttls" env:"TASKER_SMTP_STARTTLS"`
+}
+
+// Tracing configures the export of the execution and step spans
+type Tracing struct {
+	// Exporter is none, otlp or file
+	Exporter string `json:"exporter" env:"TASKER_TRACING_EXPORTER"`
+	// Endpoint is the OTLP/HTTP collector base URL
+	Endpoint      string        `json:"endpoint" env:"TASKER_TRACING_ENDPOINT"`
+	FilePath      string        `json:"file_path" env:"TASKER_TRACING_FILE_PATH"`
+	ServiceName   string        `json:"service_name" env:"TASKER_TRACING_SERVICE_NAME"`
+	FlushInterval time.Duration `json:"flush_interval" env:"TASKER_TRACING_FLUSH_INTERVAL"`
+}
+
+// Log configures the structured logs
+type Log struct {
+	// Level is debug, info, warn or error
+	Level string `json:"level" env:"TASKER_LOG_LEVEL"`
+	// Format is json or text
+	Format string `json:"format" env:"TASKER_LOG_FORMAT"`
+}
+
+// Auth configures the API keys authentication
+type Auth struct {
+	// Enabled requires an API key with the route scope on every endpoint but the health, metrics and openapi ones
+	Enabled bool `json:"enabled" env:"TASKER_AUTH_ENABLED"`
+}
+
+// Default returns the configuration used for the settings that are not provided
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Addr:              ":3333",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      2 * time.Minute,
+			IdleTimeout:       2 * time.Minute,
+		},
+		MgmtDB: MgmtDB{
+			Dialect:         "mysql",
+			DSN:             "username:password@tcp(localhost:3306)/database_name",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		ExecutionStorage: ExecutionStorage{
+			Backend: "redis",
+			Path:    "execution_storage.json",
+			Redis:   Redis{Addr: "localhost:6379"},
+		},
+		Scheduler: Scheduler{
+			TaskTimeout: 30 * time.Second,
+			Timezone:    "UTC",
+		},
+		HTTPClient: HTTPClient{
+			Timeout:             time.Minute,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			Limits:              map[string]OutboundLimit{},
+		},
+		SQLDataSources: map[string]SQLDataSource{},
+		SMTP: SMTP{
+			Port:     587,
+			StartTLS: true,
+		},
+		Tracing: Tracing{
+			Exporter:      "none",
+			Endpoint:      "http://localhost:4318",
+			FilePath:      "spans.jsonl",
+			ServiceName:   "tasker",
+			FlushInterval: 5 * time.Second,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Auth: Auth{
+			Enabled: true,
+		},
+		ShutdownTimeout:    30 * time.Second,
+		HealthCheckTimeout: 2 * time.Second,
+	}
+}
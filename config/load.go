@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const configFileEnv = "TASKER_CONFIG"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is a setting of the configuration with its dotted json key, e.g. http.addr
+type field struct {
+	key    string
+	env    string
+	secret string
+	value  reflect.Value
+}
+
+// fields walks the configuration settings in declaration order
+func fields(v reflect.Value, prefix string) []field {
+	var result []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := prefix + strings.Split(sf.Tag.Get("json"), ",")[0]
+		if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+			result = append(result, fields(v.Field(i), key+".")...)
+			continue
+		}
+		result = append(result, field{key: key, env: sf.Tag.Get("env"), secret: sf.Tag.Get("secret"), value: v.Field(i)})
+	}
+	return result
+}
+
+// set parses the text of an env variable, flag or file value into the field
+func (f field) set(text string) error {
+	v := f.value
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("%s: invalid duration %q", f.key, text)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(text)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("%s: invalid bool %q", f.key, text)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("%s: invalid integer %q", f.key, text)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Slice && !strings.HasPrefix(strings.TrimSpace(text), "["):
+		var items []string
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		//Slices given as JSON arrays and maps as JSON objects
+		decoded := reflect.New(v.Type())
+		if err := json.Unmarshal([]byte(text), decoded.Interface()); err != nil {
+			return fmt.Errorf("%s: invalid JSON: %w", f.key, err)
+		}
+		v.Set(decoded.Elem())
+	}
+	return nil
+}
+
+// Load builds the configuration from args, the command line without the program name, and returns the arguments
+// left after the flags
+func Load(args []string) (Config, []string, error) {
+	cfg := Default()
+	flds := fields(reflect.ValueOf(&cfg).Elem(), "")
+
+	//Flags are applied last, so their values are kept until the file and env are loaded
+	fs := flag.NewFlagSet("tasker", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := fs.String("config", os.Getenv(configFileEnv), "JSON configuration file")
+	type flagValue struct {
+		field field
+		text  string
+	}
+	var flagValues []flagValue
+	for _, f := range flds {
+		f := f
+		fs.Func(f.key, "env "+f.env, func(text string) error {
+			flagValues = append(flagValues, flagValue{field: f, text: text})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, fmt.Errorf("parsing flags: %w", err)
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, flds); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	for _, f := range flds {
+		if text, ok := os.LookupEnv(f.env); ok && f.env != "" && text != "" {
+			if err := f.set(text); err != nil {
+				return Config{}, nil, fmt.Errorf("env %s: %w", f.env, err)
+			}
+		}
+	}
+
+	for _, fv := range flagValues {
+		if err := fv.field.set(fv.text); err != nil {
+			return Config{}, nil, fmt.Errorf("flag -%w", err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// loadFile sets the fields present on the JSON file, nested as the json keys, e.g. {"http": {"addr": ":8080"}}
+func loadFile(path string, flds []field) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	var values map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	byKey := make(map[string]field, len(flds))
+	for _, f := range flds {
+		byKey[f.key] = f
+	}
+	return setFileValues(values, "", byKey)
+}
+
+func setFileValues(values map[string]any, prefix string, byKey map[string]field) error {
+	for name, value := range values {
+		key := prefix + name
+		f, found := byKey[key]
+		if !found {
+			nested, isObject := value.(map[string]any)
+			if !isObject {
+				return fmt.Errorf("config file: unknown setting %s", key)
+			}
+			if err := setFileValues(nested, key+".", byKey); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case map[string]any, []any:
+			encoded, _ := json.Marshal(v)
+			text = string(encoded)
+		default:
+			text = fmt.Sprint(v)
+		}
+		if err := f.set(text); err != nil {
+			return fmt.Errorf("config file: %w", err)
+		}
+	}
+	return nil
+}
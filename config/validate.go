@@ -0,0 +1,91 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Validate checks every setting and returns all the invalid ones joined
+func (c Config) Validate() error {
+	var errs []error
+	check := func(valid bool, format string, args ...any) {
+		if !valid {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr is required")
+	check(c.HTTP.ReadTimeout >= 0 && c.HTTP.ReadHeaderTimeout >= 0 && c.HTTP.WriteTimeout >= 0 && c.HTTP.IdleTimeout >= 0,
+		"http timeouts can't be negative")
+
+	check(oneOf(c.MgmtDB.Dialect, "mysql", "sqlite", "postgres"), "mgmt_db.dialect must be mysql, sqlite or postgres, got %q", c.MgmtDB.Dialect)
+	check(c.MgmtDB.DSN != "", "mgmt_db.dsn is required")
+	check(c.MgmtDB.MaxOpenConns >= 0 && c.MgmtDB.MaxIdleConns >= 0, "mgmt_db connection limits can't be negative")
+	check(c.MgmtDB.ConnMaxLifetime >= 0, "mgmt_db.conn_max_lifetime can't be negative")
+
+	switch c.ExecutionStorage.Backend {
+	case "redis":
+		check(c.ExecutionStorage.Redis.Addr != "", "execution_storage.redis.addr is required for the redis backend")
+		check(c.ExecutionStorage.Redis.DB >= 0, "execution_storage.redis.db can't be negative")
+	case "file":
+		check(c.ExecutionStorage.Path != "", "execution_storage.path is required for the file backend")
+	case "memory":
+	default:
+		check(false, "execution_storage.backend must be redis, memory or file, got %q", c.ExecutionStorage.Backend)
+	}
+
+	check(c.Scheduler.TaskTimeout > 0, "scheduler.task_timeout must be positive")
+	_, err := time.LoadLocation(c.Scheduler.Timezone)
+	check(err == nil, "scheduler.timezone %q is not a valid timezone", c.Scheduler.Timezone)
+
+	check(c.HTTPClient.Timeout >= 0 && c.HTTPClient.IdleConnTimeout >= 0, "http_client timeouts can't be negative")
+	check(c.HTTPClient.MaxIdleConns >= 0 && c.HTTPClient.MaxIdleConnsPerHost >= 0, "http_client connection limits can't be negative")
+	for name, limit := range c.HTTPClient.Limits {
+		check(limit.RatePerSecond >= 0 && limit.Burst >= 0 && limit.MaxConcurrent >= 0, "http_client.limits.%s values can't be negative", name)
+		check(limit.RatePerSecond > 0 || limit.MaxConcurrent > 0, "http_client.limits.%s needs a rate_per_second or a max_concurrent", name)
+		check(oneOf(limit.OnLimit, "", "wait", "fail"), "http_client.limits.%s.on_limit must be wait or fail, got %q", name, limit.OnLimit)
+	}
+	check(!c.HTTPClient.DistributedLimits || c.ExecutionStorage.Redis.Addr != "", "execution_storage.redis.addr is required for the http_client distributed limits")
+
+	if c.Secrets.Key != "" {
+		key, err := base64.StdEncoding.DecodeString(c.Secrets.Key)
+		check(err == nil && oneOf(len(key), 16, 24, 32), "secrets.key must be a base64 AES key of 16, 24 or 32 bytes")
+	}
+
+	for name, ds := range c.SQLDataSources {
+		check(ds.DSN != "", "sql_data_sources.%s.dsn is required", name)
+	}
+
+	check(c.SMTP.Port > 0 && c.SMTP.Port <= 65535, "smtp.port must be between 1 and 65535, got %d", c.SMTP.Port)
+
+	switch c.Tracing.Exporter {
+	case "none":
+	case "otlp":
+		check(c.Tracing.Endpoint != "", "tracing.endpoint is required for the otlp exporter")
+	case "file":
+		check(c.Tracing.FilePath != "", "tracing.file_path is required for the file exporter")
+	default:
+		check(false, "tracing.exporter must be none, otlp or file, got %q", c.Tracing.Exporter)
+	}
+	check(c.Tracing.FlushInterval > 0, "tracing.flush_interval must be positive")
+
+	check(oneOf(strings.ToLower(c.Log.Level), "debug", "info", "warn", "error"), "log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	check(oneOf(strings.ToLower(c.Log.Format), "json", "text"), "log.format must be json or text, got %q", c.Log.Format)
+
+	check(c.ShutdownTimeout >= 0, "shutdown_timeout can't be negative")
+	check(c.HealthCheckTimeout > 0, "health_check_timeout must be positive")
+
+	return errors.Join(errs...)
+}
+
+func oneOf[T comparable](value T, options ...T) bool {
+	for _, option := range options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
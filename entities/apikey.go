@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tasker/http"
+)
+
+// Scope grants an API key access to a group of routes
+type Scope string
+
+const (
+	TasksReadScope      Scope = "tasks:read"
+	TasksWriteScope     Scope = "tasks:write"
+	ExecutionsRunScope  Scope = "executions:run"
+	SchedulesAdminScope Scope = "schedules:admin"
+	SecretsWriteScope   Scope = "secrets:write"
+	AuditReadScope      Scope = "audit:read"
+	APIKeysAdminScope   Scope = "apikeys:admin"
+)
+
+func GetAllScopes() []Scope {
+	return []Scope{
+		TasksReadScope,
+		TasksWriteScope,
+		ExecutionsRunScope,
+		SchedulesAdminScope,
+		SecretsWriteScope,
+		AuditReadScope,
+		APIKeysAdminScope,
+	}
+}
+
+// APIKey identifies a caller of the API, the key itself is only known when it's created
+type APIKey struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// WorkspaceID is the only workspace the key has access to
+	WorkspaceID int `json:"workspace_id"`
+	// Prefix is the start of the key, to tell the keys apart without knowing them
+	Prefix    string     `json:"prefix"`
+	Scopes    []Scope    `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k APIKey) IsValid() error {
+	var fields []http.FieldError
+	if k.Name == "" {
+		fields = append(fields, http.FieldError{Field: "name", Detail: "must not be empty"})
+	}
+
+	if len(k.Scopes) == 0 {
+		fields = append(fields, http.FieldError{Field: "scopes", Detail: "must not be empty"})
+	}
+
+	for i, scope := range k.Scopes {
+		validScope := false
+		for _, s := range GetAllScopes() {
+			if scope == s {
+				validScope = true
+			}
+		}
+		if !validScope {
+			fields = append(fields, http.FieldError{Field: fmt.Sprintf("scopes[%d]", i), Detail: fmt.Sprintf("must be a known scope, got %q", scope)})
+		}
+	}
+
+	return http.ValidationError(fields)
+}
+
+func (k APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type auditAction string
+
+const (
+	CreateAuditAction = auditAction("create")
+	UpdateAuditAction = auditAction("update")
+	DeleteAuditAction = auditAction("delete")
+)
+
+type auditEntity string
+
+const (
+	TaskAuditEntity      = auditEntity("task")
+	ScheduleAuditEntity  = auditEntity("schedule")
+	SecretAuditEntity    = auditEntity("secret")
+	APIKeyAuditEntity    = auditEntity("api_key")
+	WorkspaceAuditEntity = auditEntity("workspace")
+)
+
+// AuditEvent records a change made to a task, schedule, secret, API key or workspace. Before is empty on creations and After on deletions,
+// Diff holds the top level fields that changed as {"field": {"before": ..., "after": ...}}
+type AuditEvent struct {
+	ID         int             `json:"id"`
+	Time       time.Time       `json:"time"`
+	Actor      string          `json:"actor"`
+	Action     auditAction     `json:"action"`
+	EntityType auditEntity     `json:"entity"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Diff       json.RawMessage `json:"diff,omitempty"`
+}
+
+// AuditFilter selects the audit events to return, the zero value fields don't filter
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	Actor      string
+	From       time.Time
+	// Limit caps the number of events, the newest are returned first
+	Limit int
+}
@@ -0,0 +1,192 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tasker/http"
+	"gopkg.in/yaml.v3"
+)
+
+// BundleVersion is the version of the bundle format written by the exports
+const BundleVersion = 1
+
+const (
+	YAMLBundleFormat = "yaml"
+	JSONBundleFormat = "json"
+)
+
+// Bundle is the portable description of the tasks and their schedules, kept in git and applied to any workspace. The
+// tasks and schedules are keyed by name instead of by ID, so a task name can only be used once on a workspace
+type Bundle struct {
+	Version int          `json:"version" yaml:"version"`
+	Tasks   []BundleTask `json:"tasks" yaml:"tasks"`
+}
+
+type BundleTask struct {
+	Name      string           `json:"name" yaml:"name"`
+	Steps     []BundleStep     `json:"steps" yaml:"steps"`
+	Schedules []BundleSchedule `json:"schedules,omitempty" yaml:"schedules,omitempty"`
+}
+
+type BundleStep struct {
+	Type        StepType          `json:"type" yaml:"type"`
+	Params      map[string]string `json:"params" yaml:"params"`
+	FailureStep *BundleStep       `json:"failure_step,omitempty" yaml:"failure_step,omitempty"`
+}
+
+// BundleSchedule is a schedule of the bundle task, keyed by its name on the task
+type BundleSchedule struct {
+	Name    string `json:"name" yaml:"name"`
+	Cron    string `json:"cron" yaml:"cron"`
+	Retries int    `json:"retries" yaml:"retries"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
+
+// ParseBundle decodes a YAML or a JSON bundle, JSON being valid YAML. Unknown fields are rejected so typos aren't
+// silently dropped
+func ParseBundle(data []byte) (Bundle, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var bundle Bundle
+	if err := decoder.Decode(&bundle); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("the bundle is empty")
+		}
+		return Bundle{}, http.WrapError(err, http.ErrBadRequest.WithMessage("invalid bundle: "+err.Error()))
+	}
+	return bundle, nil
+}
+
+// Marshal encodes the bundle on the yaml or json format
+func (b Bundle) Marshal(format string) ([]byte, error) {
+	switch format {
+	case JSONBundleFormat:
+		return json.MarshalIndent(b, "", "  ")
+	case YAMLBundleFormat:
+		var buffer bytes.Buffer
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(b); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown bundle format %s, must be yaml or json", format)
+	}
+}
+
+func (b Bundle) IsValid() error {
+	var fields []http.FieldError
+	if b.Version != BundleVersion {
+		fields = append(fields, http.FieldError{Field: "version", Detail: fmt.Sprintf("must be %d", BundleVersion)})
+	}
+
+	taskNames := map[string]int{}
+	for i, task := range b.Tasks {
+		prefix := fmt.Sprintf("tasks[%d].", i)
+		if other, found := taskNames[task.Name]; found && task.Name != "" {
+			fields = append(fields, http.FieldError{Field: prefix + "name", Detail: fmt.Sprintf("must be unique, tasks[%d] has it too", other)})
+		}
+		taskNames[task.Name] = i
+		fields = append(fields, task.Task().fieldErrors(prefix)...)
+
+		scheduleNames := map[string]int{}
+		for j, sch := range task.Schedules {
+			schPrefix := fmt.Sprintf("%sschedules[%d].", prefix, j)
+			switch other, found := scheduleNames[sch.Name]; {
+			case sch.Name == "":
+				fields = append(fields, http.FieldError{Field: schPrefix + "name", Detail: "must not be empty"})
+			case found:
+				fields = append(fields, http.FieldError{Field: schPrefix + "name", Detail: fmt.Sprintf("must be unique on the task, schedules[%d] has it too", other)})
+			}
+			scheduleNames[sch.Name] = j
+			if sch.Retries < 0 {
+				fields = append(fields, http.FieldError{Field: schPrefix + "retries", Detail: "must be at least 0"})
+			}
+			fields = append(fields, sch.ScheduledTask(0).fieldErrors(schPrefix)...)
+		}
+	}
+
+	return http.ValidationError(fields)
+}
+
+// Task is the bundle task without its schedules, the steps have no IDs
+func (t BundleTask) Task() Task {
+	task := Task{Name: t.Name, Steps: make([]Step, len(t.Steps))}
+	for i, step := range t.Steps {
+		task.Steps[i] = step.step()
+	}
+	return task
+}
+
+func (s BundleStep) step() Step {
+	step := Step{Type: s.Type, Params: s.Params}
+	if s.FailureStep != nil {
+		failureStep := s.FailureStep.step()
+		step.FailureStep = &failureStep
+	}
+	return step
+}
+
+// ScheduledTask is the bundle schedule of the task with the ID
+func (s BundleSchedule) ScheduledTask(taskID int) ScheduledTask {
+	return ScheduledTask{Name: s.Name, Cron: s.Cron, Retries: s.Retries, Enabled: s.Enabled, Task: Task{ID: taskID}}
+}
+
+// NewBundleTask describes the task and its schedules without their IDs
+func NewBundleTask(task Task, schedules []ScheduledTask) BundleTask {
+	bundleTask := BundleTask{Name: task.Name, Steps: make([]BundleStep, len(task.Steps))}
+	for i, step := range task.Steps {
+		bundleTask.Steps[i] = newBundleStep(step)
+	}
+	for _, sch := range schedules {
+		bundleTask.Schedules = append(bundleTask.Schedules, NewBundleSchedule(sch))
+	}
+	return bundleTask
+}
+
+func newBundleStep(step Step) BundleStep {
+	bundleStep := BundleStep{Type: step.Type, Params: step.Params}
+	if step.FailureStep != nil {
+		failureStep := newBundleStep(*step.FailureStep)
+		bundleStep.FailureStep = &failureStep
+	}
+	return bundleStep
+}
+
+func NewBundleSchedule(sch ScheduledTask) BundleSchedule {
+	return BundleSchedule{Name: sch.Name, Cron: sch.Cron, Retries: sch.Retries, Enabled: sch.Enabled}
+}
+
+type importAction string
+
+const (
+	CreateImportAction    = importAction("create")
+	UpdateImportAction    = importAction("update")
+	UnchangedImportAction = importAction("unchanged")
+)
+
+// ImportChange is what importing a bundle does to a task or a schedule, Diff holds the fields that change as
+// {"field": {"before": ..., "after": ...}} like the audit events
+type ImportChange struct {
+	Action     importAction `json:"action"`
+	EntityType auditEntity  `json:"entity"`
+	// Task is the name of the task, or of the task of the schedule
+	Task     string          `json:"task"`
+	Schedule string          `json:"schedule,omitempty"`
+	ID       int             `json:"id,omitempty"`
+	Diff     json.RawMessage `json:"diff,omitempty"`
+}
+
+// ImportResult lists the changes of an import, they are only planned when DryRun is set
+type ImportResult struct {
+	DryRun  bool           `json:"dry_run"`
+	Changes []ImportChange `json:"changes"`
+}
@@ -0,0 +1,95 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/http"
+)
+
+func TestParseBundle(t *testing.T) {
+	want := Bundle{Version: 1, Tasks: []BundleTask{{
+		Name: "report",
+		Steps: []BundleStep{{
+			Type: APICallStepType, Params: map[string]string{"url": "http://localhost", "timeout": "30"},
+			FailureStep: &BundleStep{Type: EmailStepType, Params: map[string]string{"to": "a@b.c"}},
+		}},
+		Schedules: []BundleSchedule{{Name: "nightly", Cron: "0 3 * * *", Retries: 2, Enabled: true}},
+	}}}
+
+	yamlBundle := `
+version: 1
+tasks:
+  - name: report
+    steps:
+      - type: api_call
+        params: {url: "http://localhost", timeout: 30}
+        failure_step:
+          type: email
+          params: {to: a@b.c}
+    schedules:
+      - {name: nightly, cron: "0 3 * * *", retries: 2, enabled: true}
+`
+	bundle, err := ParseBundle([]byte(yamlBundle))
+	assert.NoError(t, err)
+	assert.Equal(t, want, bundle)
+
+	// every format reads what it writes
+	for _, format := range []string{YAMLBundleFormat, JSONBundleFormat} {
+		data, err := want.Marshal(format)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		bundle, err := ParseBundle(data)
+		assert.NoError(t, err, format)
+		assert.Equal(t, want, bundle, format)
+	}
+
+	_, err = want.Marshal("xml")
+	assert.Error(t, err)
+
+	for name, data := range map[string]string{
+		"empty":         "",
+		"unknown field": "version: 1\ntasks:\n  - name: report\n    stpes: []\n",
+		"invalid":       `{"version": 1, "tasks": [`,
+	} {
+		_, err := ParseBundle([]byte(data))
+		var httpError http.Error
+		if assert.True(t, errors.As(err, &httpError), name) {
+			assert.Equal(t, "bad_request", httpError.Code(), name)
+		}
+	}
+}
+
+func TestBundle_IsValid(t *testing.T) {
+	step := BundleStep{Type: EmailStepType, Params: map[string]string{"to": "a@b.c"}}
+	bundle := Bundle{Version: 2, Tasks: []BundleTask{
+		{Name: "report", Steps: []BundleStep{step}, Schedules: []BundleSchedule{
+			{Name: "nightly", Cron: "0 3 * * *"},
+			{Name: "nightly", Cron: "every night", Retries: -1},
+			{Cron: "0 4 * * *"},
+		}},
+		{Name: "report"},
+	}}
+
+	err := bundle.IsValid()
+	var httpError http.Error
+	if assert.True(t, errors.As(err, &httpError)) {
+		assert.Equal(t, "validation_failed", httpError.Code())
+		assert.Equal(t, []http.FieldError{
+			{Field: "version", Detail: "must be 1"},
+			{Field: "tasks[0].schedules[1].name", Detail: "must be unique on the task, schedules[0] has it too"},
+			{Field: "tasks[0].schedules[1].retries", Detail: "must be at least 0"},
+			{Field: "tasks[0].schedules[1].cron", Detail: "must be a valid cron expression: expected exactly 5 fields, found 2: [every night]"},
+			{Field: "tasks[0].schedules[2].name", Detail: "must not be empty"},
+			{Field: "tasks[1].name", Detail: "must be unique, tasks[0] has it too"},
+			{Field: "tasks[1].steps", Detail: "must not be empty"},
+		}, httpError.FieldErrors())
+	}
+
+	bundle.Version = BundleVersion
+	bundle.Tasks = bundle.Tasks[:1]
+	bundle.Tasks[0].Schedules = bundle.Tasks[0].Schedules[:1]
+	assert.NoError(t, bundle.IsValid())
+}
@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"fmt"
+
+	"github.com/tasker/http"
+)
+
+// DryRun executes a task without persisting anything. The steps of the stubs return their output or error, the read
+// only ones run for real with RunReadOnly and the rest are skipped with an empty output
+type DryRun struct {
+	// Task is the unsaved task to run, only when no saved task is given
+	Task        *Task      `json:"task,omitempty"`
+	Stubs       []StepStub `json:"stubs,omitempty"`
+	RunReadOnly bool       `json:"run_read_only,omitempty"`
+}
+
+// StepStub replaces the step at the position of the task, or its failure step, with its output or its error
+type StepStub struct {
+	Step        int    `json:"step"`
+	FailureStep bool   `json:"failure_step,omitempty"`
+	Output      string `json:"output,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// DryRunResult is the status the execution would have finished with and the trace of its steps
+type DryRunResult struct {
+	Status executionStatus `json:"status"`
+	Steps  []StepTrace     `json:"steps"`
+}
+
+// IsValid checks the unsaved task, if any, and that the stubs replace steps of the task, once each
+func (d DryRun) IsValid(task Task) error {
+	var fields []http.FieldError
+	if d.Task != nil {
+		fields = append(fields, d.Task.fieldErrors("task.")...)
+	}
+
+	stubbed := map[StepStub]int{}
+	for i, stub := range d.Stubs {
+		prefix := fmt.Sprintf("stubs[%d].", i)
+		key := StepStub{Step: stub.Step, FailureStep: stub.FailureStep}
+		switch other, found := stubbed[key]; {
+		case stub.Step < 0 || stub.Step >= len(task.Steps):
+			fields = append(fields, http.FieldError{Field: prefix + "step", Detail: fmt.Sprintf("must be a step position, between 0 and %d", len(task.Steps)-1)})
+		case stub.FailureStep && task.Steps[stub.Step].FailureStep == nil:
+			fields = append(fields, http.FieldError{Field: prefix + "failure_step", Detail: fmt.Sprintf("must be false, step %d has no failure step", stub.Step)})
+		case found:
+			fields = append(fields, http.FieldError{Field: prefix + "step", Detail: fmt.Sprintf("must be stubbed once, stubs[%d] stubs it too", other)})
+		}
+		stubbed[key] = i
+	}
+
+	return http.ValidationError(fields)
+}
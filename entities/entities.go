@@ -1,7 +1,8 @@
 package entities
 
 import (
-	"errors"
+	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/tasker/http"
@@ -10,27 +11,61 @@ import (
 )
 
 type Task struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Steps []Step `json:"steps"`
+	ID          int    `json:"id"`
+	WorkspaceID int    `json:"workspace_id"`
+	Name        string `json:"name"`
+	Steps       []Step `json:"steps"`
 }
 
 func (t Task) IsValid() error {
+	return http.ValidationError(t.fieldErrors(""))
+}
+
+// fieldErrors validates the task, prefix is the path of the task on the request
+func (t Task) fieldErrors(prefix string) []http.FieldError {
+	var fields []http.FieldError
 	if t.Name == "" {
-		return http.WrapError(errors.New("task must have a name"), http.ErrBadRequest)
+		fields = append(fields, http.FieldError{Field: prefix + "name", Detail: "must not be empty"})
 	}
 
 	if len(t.Steps) == 0 {
-		return http.WrapError(errors.New("task must have steps"), http.ErrBadRequest)
+		fields = append(fields, http.FieldError{Field: prefix + "steps", Detail: "must not be empty"})
 	}
 
-	for _, step := range t.Steps {
-		if err := step.IsValid(); err != nil {
-			return err
-		}
+	for i, step := range t.Steps {
+		fields = append(fields, step.fieldErrors(fmt.Sprintf("%ssteps[%d].", prefix, i))...)
 	}
 
-	return nil
+	return fields
+}
+
+// TaskSummary is a task on the task listing, without its steps
+type TaskSummary struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	StepCount int    `json:"step_count"`
+	// LatestExecutionStatus is empty when the task never ran
+	LatestExecutionStatus executionStatus `json:"latest_execution_status,omitempty"`
+}
+
+// TaskFilter selects the tasks to list, the zero value fields don't filter
+type TaskFilter struct {
+	// Name matches the tasks whose name contains it, ignoring the case
+	Name string
+	// StepType matches the tasks with a step or a failure step of the type
+	StepType StepType
+	// Scheduled matches the tasks with an enabled schedule when true and the ones without any when false
+	Scheduled *bool
+	// AfterID is the cursor, only the tasks with a greater ID are listed
+	AfterID int
+	// Limit caps the number of tasks, listed by ID
+	Limit int
+}
+
+// TaskPage is a page of the task listing, NextAfterID is the AfterID of the next page, 0 on the last one
+type TaskPage struct {
+	Tasks       []TaskSummary
+	NextAfterID int
 }
 
 type StepType string
@@ -39,6 +74,10 @@ const (
 	APICallStepType      StepType = "api_call"
 	StorageReadStepType  StepType = "storage_read"
 	StorageWriteStepType StepType = "storage_write"
+	SQLQueryStepType     StepType = "sql_query"
+	CommandStepType      StepType = "command"
+	EmailStepType        StepType = "email"
+	StorageOpStepType    StepType = "storage_op"
 )
 
 func GetAllStepTypes() []StepType {
@@ -46,6 +85,10 @@ func GetAllStepTypes() []StepType {
 		APICallStepType,
 		StorageReadStepType,
 		StorageWriteStepType,
+		SQLQueryStepType,
+		CommandStepType,
+		EmailStepType,
+		StorageOpStepType,
 	}
 }
 
@@ -58,6 +101,12 @@ type Step struct {
 }
 
 func (s Step) IsValid() error {
+	return http.ValidationError(s.fieldErrors(""))
+}
+
+// fieldErrors validates the step, prefix is the path of the step on the request
+func (s Step) fieldErrors(prefix string) []http.FieldError {
+	var fields []http.FieldError
 	validStepType := false
 	for _, stepType := range GetAllStepTypes() {
 		if s.Type == stepType {
@@ -66,58 +115,87 @@ func (s Step) IsValid() error {
 	}
 
 	if !validStepType {
-		return http.WrapError(errors.New("step must have a valid step type"), http.ErrBadRequest)
+		fields = append(fields, http.FieldError{Field: prefix + "type", Detail: "must be a valid step type"})
 	}
 
 	if len(s.Params) == 0 {
-		return http.WrapError(errors.New("step must have a params"), http.ErrBadRequest)
+		fields = append(fields, http.FieldError{Field: prefix + "params", Detail: "must not be empty"})
 	}
 
 	//check for nested failure steps
 	if s.FailureStep != nil {
 		if s.FailureStep.FailureStep != nil {
-			return http.WrapError(errors.New("a failure step cant have its own failure step"), http.ErrBadRequest)
+			fields = append(fields, http.FieldError{Field: prefix + "failure_step.failure_step", Detail: "is not allowed, a failure step cant have its own failure step"})
 		}
 
 		//check for failure validity
-		if err := s.FailureStep.IsValid(); err != nil {
-			return err
-		}
+		failureStep := *s.FailureStep
+		failureStep.FailureStep = nil
+		fields = append(fields, failureStep.fieldErrors(prefix+"failure_step.")...)
 	}
 
-	return nil
+	return fields
 }
 
 type ScheduledTask struct {
-	ID       int
-	Name     string
-	Cron     string
-	Retries  int
-	Task     Task
-	Enabled  bool
-	LastRun  *time.Time
-	FirstRun *time.Time
+	ID          int
+	WorkspaceID int
+	Name        string
+	Cron        string
+	Retries     int
+	Task        Task
+	Enabled     bool
+	LastRun     *time.Time
+	FirstRun    *time.Time
 }
 
 func (s ScheduledTask) IsValid() error {
+	return http.ValidationError(s.fieldErrors(""))
+}
+
+// fieldErrors validates the schedule, prefix is the path of the schedule on the request
+func (s ScheduledTask) fieldErrors(prefix string) []http.FieldError {
 	//Check valid cron
 	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
 	if _, err := parser.Parse(s.Cron); err != nil {
-		return http.WrapError(err, http.ErrBadRequest.WithMessage(err.Error()))
+		return []http.FieldError{{Field: prefix + "cron", Detail: "must be a valid cron expression: " + err.Error()}}
 	}
 	return nil
 }
 
+var secretNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+type Secret struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+func (s Secret) IsValid() error {
+	var fields []http.FieldError
+	if !secretNameRegexp.MatchString(s.Name) {
+		fields = append(fields, http.FieldError{Field: "name", Detail: "must only contain letters, numbers, '_', '.' or '-'"})
+	}
+
+	if s.Value == "" {
+		fields = append(fields, http.FieldError{Field: "value", Detail: "must not be empty"})
+	}
+
+	return http.ValidationError(fields)
+}
+
 type executionStatus string
 
 const (
 	SuccessExecutionStatus        = executionStatus("success")
 	FailureExecutionStatus        = executionStatus("failure")
 	HandledFailureExecutionStatus = executionStatus("handled_failure")
+	// InterruptedExecutionStatus is set to the executions still running when the server shut down
+	InterruptedExecutionStatus = executionStatus("interrupted")
 )
 
 type Execution struct {
 	ID               int    `json:"id"`
+	WorkspaceID      int    `json:"workspace_id"`
 	TaskID           int    `json:"task_id"`
 	ScheduledTask    int    `json:"scheduled_task"`
 	IdempotencyToken string `json:"idempotency_token"`
@@ -127,6 +205,37 @@ type Execution struct {
 	ExecutedTime time.Time `json:"executed_time"`
 	//LastStatusChangeTime time.Time
 	//TODO: add ErrorMsg
+	// ResumedFrom is the failed execution this one resumed, if any
+	ResumedFrom int `json:"resumed_from,omitempty"`
+	// Steps is the trace of the steps, their params aren't saved
+	Steps []StepTrace `json:"steps,omitempty"`
+}
+
+type stepTraceMode string
+
+const (
+	// RunStepTraceMode steps ran for real
+	RunStepTraceMode = stepTraceMode("run")
+	// StubbedStepTraceMode steps returned the output or error the dry run stubbed
+	StubbedStepTraceMode = stepTraceMode("stubbed")
+	// SkippedStepTraceMode steps didn't run on a dry run, their output is empty
+	SkippedStepTraceMode = stepTraceMode("skipped")
+	// ReusedStepTraceMode steps didn't run on a resumed execution, their output is the one of the resumed execution
+	ReusedStepTraceMode = stepTraceMode("reused")
+)
+
+// StepTrace is what a step of an execution was sent and returned. Params hold the secret references, not their values
+type StepTrace struct {
+	// Step is the position of the step on the task, failure steps share it with their step
+	Step        int               `json:"step"`
+	FailureStep bool              `json:"failure_step,omitempty"`
+	Type        StepType          `json:"type"`
+	Mode        stepTraceMode     `json:"mode"`
+	Params      map[string]string `json:"params"`
+	Output      string            `json:"output"`
+	Error       string            `json:"error,omitempty"`
+	// ParamsHash identifies the params the step had on the task, a resume only reuses the outputs of unchanged steps
+	ParamsHash string `json:"-"`
 }
 
 /*
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/http"
+)
+
+func TestTask_IsValid(t *testing.T) {
+	tests := map[string]struct {
+		task       Task
+		wantFields []http.FieldError
+	}{
+		"valid": {
+			task: Task{Name: "task", Steps: []Step{{Type: APICallStepType, Params: map[string]string{"url": "http://localhost"}}}},
+		},
+		"no name nor steps": {
+			task:       Task{},
+			wantFields: []http.FieldError{{Field: "name", Detail: "must not be empty"}, {Field: "steps", Detail: "must not be empty"}},
+		},
+		"invalid steps": {
+			task: Task{Name: "task", Steps: []Step{
+				{Type: APICallStepType, Params: map[string]string{"url": "http://localhost"}},
+				{Type: "ftp", Params: map[string]string{"host": "localhost"}, FailureStep: &Step{
+					Type:        EmailStepType,
+					FailureStep: &Step{Type: EmailStepType, Params: map[string]string{"to": "a@b.c"}},
+				}},
+			}},
+			wantFields: []http.FieldError{
+				{Field: "steps[1].type", Detail: "must be a valid step type"},
+				{Field: "steps[1].failure_step.failure_step", Detail: "is not allowed, a failure step cant have its own failure step"},
+				{Field: "steps[1].failure_step.params", Detail: "must not be empty"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.task.IsValid()
+			if test.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var httpError http.Error
+			if assert.True(t, errors.As(err, &httpError)) {
+				status, _ := httpError.StatusAndMsg()
+				assert.Equal(t, 400, status)
+				assert.Equal(t, "validation_failed", httpError.Code())
+				assert.Equal(t, test.wantFields, httpError.FieldErrors())
+			}
+		})
+	}
+}
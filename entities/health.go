@@ -0,0 +1,37 @@
+package entities
+
+type dependencyStatus string
+
+const (
+	UpDependencyStatus   = dependencyStatus("up")
+	DownDependencyStatus = dependencyStatus("down")
+)
+
+type schedulerState string
+
+const (
+	// IdleSchedulerState means no scheduler loop was started, POST /jobs/execute-scheduled-tasks starts one
+	IdleSchedulerState     = schedulerState("idle")
+	RunningSchedulerState  = schedulerState("running")
+	StoppingSchedulerState = schedulerState("stopping")
+)
+
+type DependencyHealth struct {
+	Name      string           `json:"name"`
+	Status    dependencyStatus `json:"status"`
+	LatencyMS float64          `json:"latency_ms"`
+	Error     string           `json:"error,omitempty"`
+}
+
+type SchedulerHealth struct {
+	State             schedulerState `json:"state"`
+	ActiveSchedules   int            `json:"active_schedules"`
+	RunningExecutions int            `json:"running_executions"`
+}
+
+// Readiness is ready when every dependency is up and the service is not shutting down
+type Readiness struct {
+	Ready        bool               `json:"ready"`
+	Dependencies []DependencyHealth `json:"dependencies"`
+	Scheduler    SchedulerHealth    `json:"scheduler"`
+}
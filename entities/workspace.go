@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/tasker/http"
+)
+
+// DefaultWorkspaceID is the workspace of the rows created before the workspaces existed, and the one used when no
+// workspace is given
+const DefaultWorkspaceID = 1
+
+// Workspace isolates the tasks, schedules, executions, secrets and API keys of a tenant
+type Workspace struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (w Workspace) IsValid() error {
+	if w.Name == "" {
+		return http.ValidationError([]http.FieldError{{Field: "name", Detail: "must not be empty"}})
+	}
+
+	return nil
+}
@@ -1,16 +1,29 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/middleware"
 )
 
-// DebugMode writes the response to the requests with the full error message, if set to false returns user-friendly messages
-const DebugMode = true
+// DebugMode adds the full error message to the debug member of the problems, the detail is always the user-friendly
+// message. It's set from the http.debug setting at startup
+var DebugMode = false
+
+// ProblemContentType is the media type of the error responses, RFC 7807
+const ProblemContentType = "application/problem+json"
 
 type Error interface {
 	StatusAndMsg() (int, string)
+	// Code is the stable machine-readable code of the error
+	Code() string
+	// FieldErrors are the invalid fields of the request, if any
+	FieldErrors() []FieldError
 }
 
 type wrappedApiError struct {
@@ -26,18 +39,38 @@ func (a wrappedApiError) StatusAndMsg() (int, string) {
 	return a.apiErr.StatusAndMsg()
 }
 
+func (a wrappedApiError) Code() string {
+	return a.apiErr.Code()
+}
+
+func (a wrappedApiError) FieldErrors() []FieldError {
+	return a.apiErr.FieldErrors()
+}
+
 func WrapError(err error, apiErr apiError) error {
 	return wrappedApiError{error: err, apiErr: &apiErr}
 }
 
 var (
-	ErrNotFound   = apiError{msg: "not found", status: http.StatusNotFound}
-	ErrBadRequest = apiError{msg: "bad request", status: http.StatusBadRequest}
+	ErrNotFound   = apiError{code: "not_found", msg: "not found", status: http.StatusNotFound}
+	ErrBadRequest = apiError{code: "bad_request", msg: "bad request", status: http.StatusBadRequest}
+	// ErrValidation is a request with invalid fields, listed on its FieldErrors
+	ErrValidation = apiError{code: "validation_failed", msg: "validation failed", status: http.StatusBadRequest}
+	// ErrUnauthorized is a missing or invalid API key, ErrForbidden a valid key without the required scope
+	ErrUnauthorized = apiError{code: "unauthorized", msg: "unauthorized", status: http.StatusUnauthorized}
+	ErrForbidden    = apiError{code: "forbidden", msg: "forbidden", status: http.StatusForbidden}
+	// ErrConflict is a request that can't be applied to the current state of the resource
+	ErrConflict = apiError{code: "conflict", msg: "conflict", status: http.StatusConflict}
 )
 
+// internalErrorCode is the code of the errors that aren't an Error
+const internalErrorCode = "internal_error"
+
 type apiError struct {
+	code   string
 	msg    string
 	status int
+	fields []FieldError
 }
 
 func (a apiError) Error() string {
@@ -49,28 +82,101 @@ func (a apiError) WithMessage(msg string) apiError {
 	return a
 }
 
+// WithCode replaces the code of the error, codes are part of the API so they can't change once released
+func (a apiError) WithCode(code string) apiError {
+	a.code = code
+	return a
+}
+
+func (a apiError) WithFieldErrors(fields ...FieldError) apiError {
+	a.fields = fields
+	return a
+}
+
 func (a apiError) StatusAndMsg() (int, string) {
 	return a.status, a.msg
 }
 
-// JSONHandleError logs the error message and replies to the request with the right status code and a user-friendly msg
-func JSONHandleError(w http.ResponseWriter, err error) {
-	log.Printf("ERROR: %s\n", err.Error())
+func (a apiError) Code() string {
+	return a.code
+}
 
-	defaultMsg := "internal error"
-	if DebugMode {
-		defaultMsg = err.Error()
+func (a apiError) FieldErrors() []FieldError {
+	return a.fields
+}
+
+// FieldError is why a field of the request is invalid, the field is its JSON path, e.g. steps[0].type
+type FieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+func (f FieldError) Error() string {
+	return f.Field + " " + f.Detail
+}
+
+// ValidationError returns an ErrValidation with the invalid fields, or nil when there are none
+func ValidationError(fields []FieldError) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	details := make([]string, len(fields))
+	for i, field := range fields {
+		details[i] = field.Error()
+	}
+	msg := strings.Join(details, "; ")
+	return WrapError(errors.New(msg), ErrValidation.WithMessage(msg).WithFieldErrors(fields...))
+}
+
+// Problem is the RFC 7807 body of the error responses
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Code is stable, clients can rely on it unlike on the detail
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Debug is the full error message, only with DebugMode
+	Debug string `json:"debug,omitempty"`
+}
+
+// NewProblem describes the error, errors that aren't an Error are internal errors and only show their message on Debug
+func NewProblem(ctx context.Context, err error) Problem {
+	problem := Problem{
+		Type:      "about:blank",
+		Status:    http.StatusInternalServerError,
+		Detail:    "internal error",
+		Code:      internalErrorCode,
+		RequestID: middleware.GetReqID(ctx),
 	}
 
 	var httpError Error
 	if errors.As(err, &httpError) {
 		status, msg := httpError.StatusAndMsg()
-		if msg == "" || DebugMode {
-			msg = defaultMsg
+		problem.Status, problem.Code, problem.Errors = status, httpError.Code(), httpError.FieldErrors()
+		if msg != "" {
+			problem.Detail = msg
 		}
-		http.Error(w, msg, status)
-	} else {
-		http.Error(w, defaultMsg, http.StatusInternalServerError)
+	}
+	problem.Title = http.StatusText(problem.Status)
+	if DebugMode {
+		problem.Debug = err.Error()
+	}
+	return problem
+}
+
+// JSONHandleError logs the error message and replies to the request with the right status code and its problem
+func JSONHandleError(ctx context.Context, w http.ResponseWriter, err error) {
+	slog.ErrorContext(ctx, "handling request", "error", err)
+
+	problem := NewProblem(ctx, err)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		slog.ErrorContext(ctx, "writing error response", "error", err)
 	}
 }
 
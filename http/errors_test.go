@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONHandleError(t *testing.T) {
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "host/abc-000001")
+	tests := map[string]struct {
+		err         error
+		debug       bool
+		wantProblem Problem
+	}{
+		"wrapped error": {
+			err: WrapError(errors.New("sql: no rows in result set"), ErrNotFound.WithMessage("task not found")),
+			wantProblem: Problem{
+				Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "task not found",
+				Code: "not_found", RequestID: "host/abc-000001",
+			},
+		},
+		"validation error": {
+			err: ValidationError([]FieldError{{Field: "name", Detail: "must not be empty"}, {Field: "steps", Detail: "must not be empty"}}),
+			wantProblem: Problem{
+				Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest,
+				Detail: "name must not be empty; steps must not be empty", Code: "validation_failed", RequestID: "host/abc-000001",
+				Errors: []FieldError{{Field: "name", Detail: "must not be empty"}, {Field: "steps", Detail: "must not be empty"}},
+			},
+		},
+		"internal error hides its message": {
+			err: errors.New("dial tcp 10.0.0.3:3306: connection refused"),
+			wantProblem: Problem{
+				Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError,
+				Detail: "internal error", Code: "internal_error", RequestID: "host/abc-000001",
+			},
+		},
+		"internal error on debug": {
+			err:   errors.New("dial tcp 10.0.0.3:3306: connection refused"),
+			debug: true,
+			wantProblem: Problem{
+				Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError,
+				Detail: "internal error", Code: "internal_error", RequestID: "host/abc-000001",
+				Debug: "dial tcp 10.0.0.3:3306: connection refused",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			DebugMode = test.debug
+			defer func() { DebugMode = false }()
+
+			recorder := httptest.NewRecorder()
+			JSONHandleError(ctx, recorder, test.err)
+
+			assert.Equal(t, test.wantProblem.Status, recorder.Code)
+			assert.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+			var problem Problem
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+			assert.Equal(t, test.wantProblem, problem)
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	assert.NoError(t, ValidationError(nil))
+
+	err := ValidationError([]FieldError{{Field: "cron", Detail: "must be a valid cron expression"}})
+	assert.EqualError(t, err, "cron must be a valid cron expression")
+	assert.True(t, errors.Is(err, err))
+	var httpError Error
+	if assert.ErrorAs(t, err, &httpError) {
+		assert.Equal(t, "validation_failed", httpError.Code())
+	}
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/tasker/service"
+	"github.com/tasker/tracing"
+)
+
+// The fields added to every record logged with a context holding them
+const (
+	RequestIDKey        = "request_id"
+	WorkspaceIDKey      = "workspace_id"
+	TaskIDKey           = "task_id"
+	ScheduleIDKey       = "schedule_id"
+	IdempotencyTokenKey = "idempotency_token"
+	StepIndexKey        = "step_index"
+	TraceIDKey          = "trace_id"
+	SpanIDKey           = "span_id"
+)
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", level)
+	}
+	return l, nil
+}
+
+// New creates a logger writing JSON, or text for local development, that adds the correlation fields of the context
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewJSONHandler(w, opts)
+	if strings.EqualFold(format, "text") {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// contextHandler adds the request, workspace, execution and trace identifiers found on the context to the records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestID := middleware.GetReqID(ctx); requestID != "" {
+		r.AddAttrs(slog.String(RequestIDKey, requestID))
+	}
+	if workspaceID, found := service.WorkspaceFromContext(ctx); found {
+		r.AddAttrs(slog.Int(WorkspaceIDKey, workspaceID))
+	}
+	if exec, found := service.ExecutionInfoFromContext(ctx); found {
+		// The idempotency token identifies the execution, its ID only exists once it's saved
+		r.AddAttrs(slog.Int(TaskIDKey, exec.TaskID), slog.Int(ScheduleIDKey, exec.ScheduleID), slog.String(IdempotencyTokenKey, exec.Token))
+	}
+	if stepIndex, found := service.StepIndexFromContext(ctx); found {
+		r.AddAttrs(slog.Int(StepIndexKey, stepIndex))
+	}
+	if sc := tracing.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String(TraceIDKey, sc.TraceID.String()), slog.String(SpanIDKey, sc.SpanID.String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
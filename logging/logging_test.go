@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/service"
+	"github.com/tasker/tracing"
+)
+
+func decodeRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	buf.Reset()
+	return record
+}
+
+func TestNew_ContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo, "json")
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "host/abc-000001")
+	ctx = service.ContextWithWorkspace(ctx, 4)
+	ctx = service.ContextWithExecutionInfo(ctx, service.ExecutionInfo{WorkspaceID: 4, TaskID: 1, ScheduleID: 2, Token: "idemp-token"})
+	ctx = service.ContextWithStepIndex(ctx, 3)
+	ctx, span := tracing.NewTracer(nil, 0).Start(ctx, "step", tracing.InternalSpanKind)
+
+	logger.With("component", "test").ErrorContext(ctx, "step failed", "error", "boom")
+
+	record := decodeRecord(t, &buf)
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "step failed", record["msg"])
+	assert.Equal(t, "test", record["component"])
+	assert.Equal(t, "boom", record["error"])
+	assert.Equal(t, "host/abc-000001", record[RequestIDKey])
+	assert.Equal(t, float64(4), record[WorkspaceIDKey])
+	assert.Equal(t, float64(1), record[TaskIDKey])
+	assert.Equal(t, float64(2), record[ScheduleIDKey])
+	assert.Equal(t, "idemp-token", record[IdempotencyTokenKey])
+	assert.Equal(t, float64(3), record[StepIndexKey])
+	assert.Equal(t, span.SpanContext().TraceID.String(), record[TraceIDKey])
+
+	//Records without context only have their own fields, and the level filters them
+	logger.Debug("hidden")
+	assert.Empty(t, buf.String())
+	logger.Info("plain")
+	record = decodeRecord(t, &buf)
+	assert.NotContains(t, record, RequestIDKey)
+	assert.NotContains(t, record, WorkspaceIDKey)
+	assert.NotContains(t, record, TaskIDKey)
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("warn")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(New(&buf, slog.LevelInfo, "json"))
+	defer slog.SetDefault(defaultLogger)
+
+	handler := middleware.RequestID(RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("internal error"))
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/", nil))
+
+	record := decodeRecord(t, &buf)
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "POST", record["method"])
+	assert.Equal(t, "/task/", record["path"])
+	assert.Equal(t, float64(500), record["status"])
+	assert.Equal(t, float64(len("internal error")), record["bytes"])
+	assert.NotEmpty(t, record[RequestIDKey])
+}
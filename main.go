@@ -4,107 +4,357 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
 	"github.com/go-chi/render"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tasker/config"
 	"github.com/tasker/entities"
+	http2 "github.com/tasker/http"
+	"github.com/tasker/logging"
+	"github.com/tasker/metrics"
+	"github.com/tasker/openapi"
 	apicall2 "github.com/tasker/repo/apicall"
+	command2 "github.com/tasker/repo/command"
+	email2 "github.com/tasker/repo/email"
 	"github.com/tasker/repo/executionDB"
 	"github.com/tasker/repo/mgmtDB"
+	sqlquery2 "github.com/tasker/repo/sqlquery"
 	"github.com/tasker/service"
 	"github.com/tasker/service/apicall"
+	"github.com/tasker/service/command"
+	"github.com/tasker/service/email"
+	"github.com/tasker/service/secrets"
+	"github.com/tasker/service/sqlquery"
+	"github.com/tasker/service/storageop"
 	"github.com/tasker/service/storageread"
 	"github.com/tasker/service/storagewrite"
+	"github.com/tasker/tracing"
 	"github.com/tasker/web"
 
 	"github.com/redis/go-redis/v9"
 )
 
 func main() {
+	//Load configuration
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	logLevel, err := logging.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		panic(err.Error())
+	}
+	slog.SetDefault(logging.New(os.Stderr, logLevel, cfg.Log.Format))
+	slog.Info("effective configuration", "config", cfg.String())
+	http2.DebugMode = cfg.HTTP.Debug
+
 	//Setup sql DB
-	sqlDB, err := setupMgmtDB()
+	sqlDB, dialect, err := setupMgmtDB(cfg.MgmtDB)
 	if err != nil {
 		panic(err.Error())
 	}
 	defer sqlDB.Close()
 
-	//Setup redis DB
-	redis, err := setupExecutionDB()
+	//Manage the schema with: tasker migrate up|down [n]|status
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(sqlDB, dialect, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	//Refuse to serve on an outdated schema
+	migrator, err := mgmtDB.NewMigrator(sqlDB, dialect)
 	if err != nil {
 		panic(err.Error())
 	}
+	if err := migrator.CheckUpToDate(context.Background()); err != nil {
+		panic(err.Error())
+	}
+
+	//Setup execution DB
+	executionStorage, err := setupExecutionDB(cfg.ExecutionStorage)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	//Move the keys written before the workspaces to the default one with: tasker storage move-legacy-keys
+	if len(args) > 0 && args[0] == "storage" {
+		if err := runStorage(executionStorage, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	//Setup sql query steps data sources
+	dataSources, err := setupSQLDataSources(cfg.SQLDataSources)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	//Setup metrics and tracing
+	metricsRegistry := metrics.NewRegistry()
+	tracer := tracing.NewTracer(setupSpanExporter(cfg.Tracing), cfg.Tracing.FlushInterval)
 
 	//Setup http client
-	httpClient := http.Client{}
+	httpClient := http.Client{
+		Timeout: cfg.HTTPClient.Timeout,
+		Transport: metrics.InstrumentTransport(metricsRegistry, &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        cfg.HTTPClient.MaxIdleConns,
+			MaxIdleConnsPerHost: cfg.HTTPClient.MaxIdleConnsPerHost,
+			IdleConnTimeout:     cfg.HTTPClient.IdleConnTimeout,
+		}),
+	}
 
 	//Create repos
-	mgmtRepo := mgmtDB.NewRepository(sqlDB)
-	executionRepo := executionDB.NewRepository(redis)
-	apicallRepo := apicall2.NewRepository(httpClient)
+	mgmtRepo := mgmtDB.NewRepositoryWithDialect(sqlDB, dialect)
+	executionRepo := executionDB.NewScopedRepository(executionStorage)
+	outboundLimits, err := setupOutboundLimits(cfg)
+	if err != nil {
+		panic(err.Error())
+	}
+	apicallRepo := apicall2.NewRepositoryWithLimits(httpClient, outboundLimits)
+	sqlqueryRepo := sqlquery2.NewRepository(dataSources)
+	commandRepo := command2.NewRepository(cfg.Command.Allowlist, commandWorkDir(cfg.Command))
+	emailRepo := email2.NewRepository(email2.SMTPConfig{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		Username: cfg.SMTP.Username,
+		Password: cfg.SMTP.Password,
+		From:     cfg.SMTP.From,
+		StartTLS: cfg.SMTP.StartTLS,
+	})
 
 	//Create Step Runners
 	apiCallerStepRunner := apicall.NewStepRunner(apicallRepo)
 	storageReadStepRunner := storageread.NewStepRunner(executionRepo)
 	storageWriteStepRunner := storagewrite.NewStepRunner(executionRepo)
+	storageOpStepRunner := storageop.NewStepRunner(executionRepo)
+	sqlQueryStepRunner := sqlquery.NewStepRunner(sqlqueryRepo)
+	commandStepRunner := command.NewStepRunner(commandRepo)
+	emailStepRunner := email.NewStepRunner(emailRepo)
 	stepRunners := map[entities.StepType]service.StepRunner{
 		entities.APICallStepType:      apiCallerStepRunner,
 		entities.StorageReadStepType:  storageReadStepRunner,
 		entities.StorageWriteStepType: storageWriteStepRunner,
+		entities.SQLQueryStepType:     sqlQueryStepRunner,
+		entities.CommandStepType:      commandStepRunner,
+		entities.EmailStepType:        emailStepRunner,
+		entities.StorageOpStepType:    storageOpStepRunner,
+	}
+
+	//Create service, the secrets store is only enabled when an encryption key is provided
+	location, err := time.LoadLocation(cfg.Scheduler.Timezone)
+	if err != nil {
+		panic(err.Error())
+	}
+	srvOpts := []service.Option{
+		service.WithExecutionCleaner(executionRepo),
+		service.WithAuditLog(mgmtRepo),
+		service.WithScheduler(cfg.Scheduler.TaskTimeout, location),
+		service.WithDependency("mgmt_db", mgmtRepo),
+		service.WithDependency("execution_storage", executionRepo),
+		service.WithHealthCheckTimeout(cfg.HealthCheckTimeout),
+		service.WithMetrics(metrics.NewTasker(metricsRegistry)),
+		service.WithTracer(tracer),
+	}
+	if cfg.Secrets.Key != "" {
+		cipher, err := secrets.NewAESGCMFromBase64(cfg.Secrets.Key)
+		if err != nil {
+			panic(err.Error())
+		}
+		srvOpts = append(srvOpts, service.WithCipher(cipher))
 	}
+	srv := service.NewService(mgmtRepo, stepRunners, srvOpts...)
 
-	//Create service
-	srv := service.NewService(mgmtRepo, stepRunners)
+	//Manage the workspaces with: tasker workspace create <name>|list
+	//their API keys with: tasker apikey [-workspace <name>] create <name> <scope,...>|list|revoke <id>
+	//and move their tasks with: tasker export [-workspace <name>] [-format yaml|json]
+	//and: tasker import [-workspace <name>] [-dry-run] <file|->
+	commands := map[string]func(service.Service, []string) error{
+		"workspace": runWorkspace,
+		"apikey":    runAPIKey,
+		"export":    runExport,
+		"import":    runImport,
+	}
+	if len(args) > 0 {
+		if run, found := commands[args[0]]; found {
+			if err := run(srv, args[1:]); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	if !cfg.Auth.Enabled {
+		slog.Warn("authentication is disabled, every route is open to anyone reaching the server")
+	}
 
 	//Create adapter
-	adapter := web.NewAdapter(srv)
+	adapter := web.NewAdapter(srv, cfg.Auth.Enabled)
+
+	//Load the API description, requests are validated against it
+	apiDoc, err := openapi.Load()
+	if err != nil {
+		panic(err.Error())
+	}
 
 	//Create router
 	r := chi.NewRouter()
 	cors := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"}, // Accept any origin
-
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", web.APIKeyHeader, web.ActorHeader},
 	})
 	r.Use(cors.Handler)
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Logger)
+	r.Use(logging.RequestLogger)
+	r.Use(web.Actor)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.URLFormat)
 	r.Use(render.SetContentType(render.ContentTypeJSON))
 
-	r.Route("/task", func(r chi.Router) {
-		r.Post("/", adapter.CreateTask) // POST /articles
-		r.Get("/{taskID}", adapter.GetTask)
-		r.Post("/{taskID}/execute/{scheduleID}", adapter.ExecuteTask)
-	})
+	r.Get("/healthz", adapter.Healthz)
+	r.Get("/readyz", adapter.Readyz)
+	r.Method(http.MethodGet, "/metrics", metricsRegistry)
+	//Served on /openapi.json, URLFormat strips the extension before routing
+	r.Method(http.MethodGet, "/openapi", apiDoc)
 
-	r.Route("/schedule", func(r chi.Router) {
-		r.Post("/", adapter.CreateSchedule) // POST /articles
-	})
+	//Every other route needs an API key with the route scope, checked before validating the request so callers without
+	//access learn nothing from the validation errors
+	requires := func(scope entities.Scope) []func(http.Handler) http.Handler {
+		return []func(http.Handler) http.Handler{adapter.RequireScope(scope), apiDoc.Validate}
+	}
+	r.Group(func(r chi.Router) {
+		r.Use(adapter.Authenticate)
+
+		r.Route("/task", func(r chi.Router) {
+			r.With(requires(entities.TasksWriteScope)...).Post("/", adapter.CreateTask) // POST /articles
+			r.With(requires(entities.TasksReadScope)...).Get("/", adapter.GetTasks)
+			r.With(requires(entities.TasksReadScope)...).Get("/{taskID}", adapter.GetTask)
+			r.With(requires(entities.ExecutionsRunScope)...).Post("/{taskID}/execute/{scheduleID}", adapter.ExecuteTask)
+			//An unsaved task runs whatever read only step it's sent, like a task that's written first
+			r.With(append([]func(http.Handler) http.Handler{adapter.RequireScope(entities.TasksWriteScope)}, requires(entities.ExecutionsRunScope)...)...).Post("/dry-run", adapter.DryRunTask)
+			r.With(requires(entities.ExecutionsRunScope)...).Post("/{taskID}/dry-run", adapter.DryRunTask)
+		})
+
+		r.Route("/execution", func(r chi.Router) {
+			r.With(requires(entities.ExecutionsRunScope)...).Post("/{executionID}/resume", adapter.ResumeExecution)
+		})
+
+		r.Route("/schedule", func(r chi.Router) {
+			r.With(requires(entities.SchedulesAdminScope)...).Post("/", adapter.CreateSchedule) // POST /articles
+		})
 
-	r.Route("/jobs", func(r chi.Router) {
-		r.Post("/execute-scheduled-tasks", adapter.ExecuteScheduledTasks) // POST /articles
+		r.Route("/secret", func(r chi.Router) {
+			r.With(requires(entities.SecretsWriteScope)...).Post("/", adapter.CreateSecret)
+		})
+
+		r.With(requires(entities.AuditReadScope)...).Get("/audit", adapter.GetAuditEvents)
+
+		//Imports change tasks and schedules, so they need both scopes
+		r.With(requires(entities.TasksReadScope)...).Get("/export", adapter.ExportBundle)
+		r.With(append([]func(http.Handler) http.Handler{adapter.RequireScope(entities.TasksWriteScope)}, requires(entities.SchedulesAdminScope)...)...).Post("/import", adapter.ImportBundle)
+
+		r.Route("/apikey", func(r chi.Router) {
+			r.Use(requires(entities.APIKeysAdminScope)...)
+			r.Post("/", adapter.CreateAPIKey)
+			r.Get("/", adapter.GetAPIKeys)
+			r.Delete("/{keyID}", adapter.RevokeAPIKey)
+		})
+
+		r.Route("/jobs", func(r chi.Router) {
+			r.With(requires(entities.SchedulesAdminScope)...).Post("/execute-scheduled-tasks", adapter.ExecuteScheduledTasks) // POST /articles
+		})
 	})
 
 	chi.Walk(r, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
-		fmt.Printf("%s %s\n", method, route)
+		slog.Debug("route registered", "method", method, "route", route)
 		return nil
 	})
 
-	http.ListenAndServe(":3333", r)
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           r,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+	//Serve until SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	slog.Info("serving", "addr", cfg.HTTP.Addr)
+	select {
+	case err := <-serveErr:
+		panic(err.Error())
+	case <-ctx.Done():
+	}
+
+	//Stop accepting requests and drain the running executions, both share the shutdown deadline
+	slog.Info("shutting down, waiting for the running executions", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	serverShutdown := make(chan error, 1)
+	go func() {
+		serverShutdown <- server.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("shutting down the service", "error", err)
+	}
+	if err := <-serverShutdown; err != nil {
+		slog.Error("shutting down the http server", "error", err)
+	}
+	//The drain may have spent the shutdown deadline, the last spans get their own
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), tracesFlushTimeout)
+	defer cancelFlush()
+	if err := tracer.Shutdown(flushCtx); err != nil {
+		slog.Error("exporting the last spans", "error", err)
+	}
+	slog.Info("shutdown finished")
+}
+
+// setupExecutionDB creates the execution storage of the configured backend: redis, memory or file
+func setupExecutionDB(cfg config.ExecutionStorage) (executionDB.Repository, error) {
+	switch cfg.Backend {
+	case "memory":
+		return executionDB.NewMemoryRepository(), nil
+	case "file":
+		return executionDB.NewFileRepository(cfg.Path)
+	}
+
+	client, err := setupRedisClient(cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+
+	return executionDB.NewRepository(client), nil
 }
 
-func setupExecutionDB() (*redis.Client, error) {
+// setupRedisClient connects to the configured redis
+func setupRedisClient(cfg config.Redis) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "", // no password set
-		DB:       0,  // use default DB
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
 	})
 
 	// Ping the Redis server to check the connection
@@ -116,45 +366,86 @@ func setupExecutionDB() (*redis.Client, error) {
 	return client, nil
 }
 
-func setupMgmtDB() (*sql.DB, error) {
-	// Connect to database
-	db, err := sql.Open("mysql", "username:password@tcp(localhost:3306)/database_name")
-	if err != nil {
-		return nil, err
+// tracesFlushTimeout limits the export of the spans still pending once the server is shut down
+const tracesFlushTimeout = 5 * time.Second
+
+// outboundLimitLease is how long a distributed slot outlives a crashed instance, it's renewed while the request runs
+const outboundLimitLease = 30 * time.Second
+
+// setupOutboundLimits creates the api call limits, shared through the execution storage redis when distributed
+func setupOutboundLimits(cfg config.Config) (*apicall2.Limits, error) {
+	limits := make([]apicall2.Limit, 0, len(cfg.HTTPClient.Limits))
+	for name, limit := range cfg.HTTPClient.Limits {
+		limits = append(limits, apicall2.Limit{
+			Name:          name,
+			Hosts:         limit.Hosts,
+			Rate:          limit.RatePerSecond,
+			Burst:         limit.Burst,
+			MaxConcurrent: limit.MaxConcurrent,
+			FailFast:      limit.OnLimit == "fail",
+		})
 	}
 
-	//Create tables
-	if err := createTables(db); err != nil {
-		return nil, err
+	limiter := apicall2.NewLocalLimiter()
+	if cfg.HTTPClient.DistributedLimits && len(limits) > 0 {
+		client, err := setupRedisClient(cfg.ExecutionStorage.Redis)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to the outbound limits redis: %w", err)
+		}
+		limiter = apicall2.NewRedisLimiter(client, outboundLimitLease)
+	}
+
+	return apicall2.NewLimits(limits, limiter)
+}
+
+// setupSpanExporter creates the configured span exporter, nil when tracing is disabled
+func setupSpanExporter(cfg config.Tracing) tracing.Exporter {
+	switch cfg.Exporter {
+	case "otlp":
+		return tracing.NewOTLPExporter(cfg.Endpoint, cfg.ServiceName, 10*time.Second)
+	case "file":
+		return tracing.NewFileExporter(cfg.FilePath)
+	default:
+		return nil
 	}
-	return db, nil
 }
 
-func createTables(db *sql.DB) error {
-	// Read the SQL file
-	file, err := os.Open("tables.sql")
+// setupMgmtDB connects to the management database of the configured dialect
+func setupMgmtDB(cfg config.MgmtDB) (*sql.DB, mgmtDB.Dialect, error) {
+	dialect, err := mgmtDB.DialectByName(cfg.Dialect)
 	if err != nil {
-		log.Fatal(err)
+		return nil, mgmtDB.Dialect{}, err
 	}
-	defer file.Close()
 
-	// Read the SQL file
-	sqlBytes, err := os.ReadFile("tables.sql")
+	// Connect to database
+	db, err := sql.Open(dialect.DriverName(), cfg.DSN)
 	if err != nil {
-		log.Fatal(err)
+		return nil, mgmtDB.Dialect{}, err
 	}
-	sqlStatements := strings.Split(string(sqlBytes), ";")
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
-	// Execute each SQL statement in the file
-	for _, stmt := range sqlStatements {
-		trimmedStmt := strings.TrimSpace(stmt)
-		if trimmedStmt != "" {
-			_, err := db.Exec(trimmedStmt)
-			if err != nil {
-				log.Fatal(err)
-			}
+	return db, dialect, nil
+}
+
+// setupSQLDataSources opens the databases sql query steps run on
+func setupSQLDataSources(configs map[string]config.SQLDataSource) (map[string]sqlquery2.DataSource, error) {
+	dataSources := map[string]sqlquery2.DataSource{}
+	for name, config := range configs {
+		db, err := sql.Open("mysql", config.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("opening sql data source %s: %w", name, err)
 		}
+		dataSources[name] = sqlquery2.DataSource{DB: db, ReadOnly: config.ReadOnly}
 	}
 
-	return nil
+	return dataSources, nil
+}
+
+func commandWorkDir(cfg config.Command) string {
+	if cfg.WorkDir != "" {
+		return cfg.WorkDir
+	}
+	return os.TempDir()
 }
@@ -0,0 +1,212 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the histogram upper bounds in seconds, from 5ms to 5 minutes
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// collector is a metric family written on the Prometheus text format
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry holds the metrics exposed on /metrics
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes every metric on the Prometheus text exposition format
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// ServeHTTP replies with the metrics, so the registry can be mounted as the /metrics handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// family holds the series of a metric by their label values
+type family struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// buckets, sum and count are only used by histograms
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+func (f *family) get(labelValues []string, newSeries func() *series) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, found := f.series[key]
+	if !found {
+		s = newSeries()
+		s.labelValues = append([]string(nil), labelValues...)
+		f.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series ordered by their label values, so the output is stable
+func (f *family) sorted() []*series {
+	list := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.Join(list[i].labelValues, "\xff") < strings.Join(list[j].labelValues, "\xff")
+	})
+	return list
+}
+
+func (f *family) header(w io.Writer, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, metricType)
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	family
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{family{name: name, help: help, labels: labels, series: map[string]*series{}}}
+	r.register(c)
+	return c
+}
+
+// Add increases the counter of the label values, given in the order the labels were declared
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(labelValues, func() *series { return &series{} }).value += value
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	for _, s := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, s.labelValues), formatValue(s.value))
+	}
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	family
+	bounds []float64
+}
+
+// NewHistogramVec creates a histogram with the bucket upper bounds, sorted ascending
+func (r *Registry) NewHistogramVec(name, help string, bounds []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{family: family{name: name, help: help, labels: labels, series: map[string]*series{}}, bounds: bounds}
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues, func() *series { return &series{buckets: make([]uint64, len(h.bounds))} })
+	for i, bound := range h.bounds {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+	for _, s := range h.sorted() {
+		for i, bound := range h.bounds {
+			labels := formatLabels(bucketLabels, append(append([]string(nil), s.labelValues...), formatValue(bound)))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labels, s.buckets[i])
+		}
+		labels := formatLabels(bucketLabels, append(append([]string(nil), s.labelValues...), "+Inf"))
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labels, s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, s.labelValues), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, s.labelValues), s.count)
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(values[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
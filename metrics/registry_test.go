@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/entities"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounterVec("test_total", "Test counter.", "name")
+	histogram := r.NewHistogramVec("test_seconds", "Test histogram.", []float64{0.1, 1}, "kind")
+
+	counter.Inc(`quo"te`)
+	counter.Add(2, "b")
+	counter.Inc("b")
+	histogram.Observe(0.05, "fast")
+	histogram.Observe(0.5, "fast")
+	histogram.Observe(3, "fast")
+
+	var sb strings.Builder
+	r.Write(&sb)
+
+	assert.Equal(t, `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{name="b"} 3
+test_total{name="quo\"te"} 1
+# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{kind="fast",le="0.1"} 1
+test_seconds_bucket{kind="fast",le="1"} 2
+test_seconds_bucket{kind="fast",le="+Inf"} 3
+test_seconds_sum{kind="fast"} 3.55
+test_seconds_count{kind="fast"} 3
+`, sb.String())
+}
+
+func TestTasker(t *testing.T) {
+	r := NewRegistry()
+	tasker := NewTasker(r)
+
+	tasker.ExecutionFinished(entities.Execution{TaskID: 1, ScheduledTask: 2, Status: entities.FailureExecutionStatus}, time.Second)
+	tasker.StepFinished(entities.APICallStepType, true, time.Millisecond)
+	tasker.ScheduleFired(entities.ScheduledTask{ID: 2}, 20*time.Millisecond)
+	tasker.ScheduleRetried(entities.ScheduledTask{ID: 2})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	assert.Contains(t, body, `tasker_executions_total{task_id="1",schedule_id="2",status="failure"} 1`)
+	assert.Contains(t, body, `tasker_execution_duration_seconds_count{task_id="1",status="failure"} 1`)
+	assert.Contains(t, body, `tasker_step_failures_total{step_type="api_call"} 1`)
+	assert.Contains(t, body, `tasker_schedule_fire_lag_seconds_bucket{schedule_id="2",le="0.025"} 1`)
+	assert.Contains(t, body, `tasker_schedule_retries_total{schedule_id="2"} 1`)
+}
+
+func TestInstrumentTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	r := NewRegistry()
+	client := http.Client{Transport: InstrumentTransport(r, nil)}
+
+	resp, err := client.Post(server.URL, "text/plain", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	_, err = client.Get("http://127.0.0.1:0")
+	assert.Error(t, err)
+
+	var sb strings.Builder
+	r.Write(&sb)
+	assert.Contains(t, sb.String(), `tasker_api_call_responses_total{method="GET",status_code="error"} 1`)
+	assert.Contains(t, sb.String(), `tasker_api_call_responses_total{method="POST",status_code="418"} 1`)
+}
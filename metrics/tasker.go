@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/tasker/entities"
+)
+
+// Tasker records the service metrics on the registry
+type Tasker struct {
+	executions        *CounterVec
+	executionDuration *HistogramVec
+	stepDuration      *HistogramVec
+	stepFailures      *CounterVec
+	scheduleLag       *HistogramVec
+	scheduleRetries   *CounterVec
+}
+
+func NewTasker(r *Registry) *Tasker {
+	return &Tasker{
+		executions: r.NewCounterVec("tasker_executions_total",
+			"Finished task executions by task, schedule and status.", "task_id", "schedule_id", "status"),
+		executionDuration: r.NewHistogramVec("tasker_execution_duration_seconds",
+			"Duration of the task executions.", DefaultBuckets, "task_id", "status"),
+		stepDuration: r.NewHistogramVec("tasker_step_duration_seconds",
+			"Duration of the steps by step type.", DefaultBuckets, "step_type"),
+		stepFailures: r.NewCounterVec("tasker_step_failures_total",
+			"Failed steps by step type.", "step_type"),
+		scheduleLag: r.NewHistogramVec("tasker_schedule_fire_lag_seconds",
+			"Delay between the planned time of a cron and the time it fired.", DefaultBuckets, "schedule_id"),
+		scheduleRetries: r.NewCounterVec("tasker_schedule_retries_total",
+			"Retried attempts of the scheduled executions.", "schedule_id"),
+	}
+}
+
+func (t *Tasker) ExecutionFinished(exec entities.Execution, duration time.Duration) {
+	taskID, status := strconv.Itoa(exec.TaskID), string(exec.Status)
+	t.executions.Inc(taskID, strconv.Itoa(exec.ScheduledTask), status)
+	t.executionDuration.Observe(duration.Seconds(), taskID, status)
+}
+
+func (t *Tasker) StepFinished(stepType entities.StepType, failed bool, duration time.Duration) {
+	t.stepDuration.Observe(duration.Seconds(), string(stepType))
+	if failed {
+		t.stepFailures.Inc(string(stepType))
+	}
+}
+
+func (t *Tasker) ScheduleFired(sch entities.ScheduledTask, lag time.Duration) {
+	t.scheduleLag.Observe(lag.Seconds(), strconv.Itoa(sch.ID))
+}
+
+func (t *Tasker) ScheduleRetried(sch entities.ScheduledTask) {
+	t.scheduleRetries.Inc(strconv.Itoa(sch.ID))
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// transport records the outbound requests of the api call steps
+type transport struct {
+	next      http.RoundTripper
+	responses *CounterVec
+	duration  *HistogramVec
+}
+
+// InstrumentTransport wraps the transport of the api call steps client, counting the responses by method and status
+// code. Requests that get no response are counted with the "error" status code
+func InstrumentTransport(r *Registry, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return transport{
+		next: next,
+		responses: r.NewCounterVec("tasker_api_call_responses_total",
+			"Outbound api call responses by method and status code.", "method", "status_code"),
+		duration: r.NewHistogramVec("tasker_api_call_duration_seconds",
+			"Duration of the outbound api calls.", DefaultBuckets, "method"),
+	}
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.duration.Observe(time.Since(start).Seconds(), req.Method)
+
+	statusCode := "error"
+	if err == nil {
+		statusCode = strconv.Itoa(resp.StatusCode)
+	}
+	t.responses.Inc(req.Method, statusCode)
+	return resp, err
+}
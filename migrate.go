@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/tasker/repo/mgmtDB"
+)
+
+const migrateUsage = "usage: tasker migrate up|down [n]|status"
+
+// runMigrate runs the migrate subcommand, down reverts the last migration unless a number of steps is given
+func runMigrate(db *sql.DB, dialect mgmtDB.Dialect, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	migrator, err := mgmtDB.NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q, %s", args[1], migrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range status {
+			appliedAt := "pending"
+			if st.AppliedAt != nil {
+				appliedAt = "applied at " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", st.Version, st.Name, appliedAt)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+	}
+}
@@ -0,0 +1,233 @@
+// Package openapi serves the OpenAPI document of the API and validates the incoming requests against it
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// Document is the subset of an OpenAPI 3.0 document needed to validate requests
+type Document struct {
+	Paths      map[string]PathItem `json:"paths"`
+	Components struct {
+		Schemas    map[string]*Schema    `json:"schemas"`
+		Parameters map[string]*Parameter `json:"parameters"`
+	} `json:"components"`
+
+	raw    []byte
+	routes []route
+}
+
+// PathItem holds the operations of a path by lowercase method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// route is an operation with its path template split in segments, {name} segments match any value
+type route struct {
+	method    string
+	segments  []string
+	operation *Operation
+}
+
+// Load parses the embedded document, resolving its references
+func Load() (*Document, error) {
+	return Parse(document)
+}
+
+// Parse parses an OpenAPI document, resolving its references
+func Parse(raw []byte) (*Document, error) {
+	d := &Document{raw: raw}
+	if err := json.Unmarshal(raw, d); err != nil {
+		return nil, fmt.Errorf("decoding openapi document: %w", err)
+	}
+
+	for name, schema := range d.Components.Schemas {
+		if err := d.resolveSchema(schema); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+	for path, item := range d.Paths {
+		for method, operation := range item {
+			if err := d.resolveOperation(operation); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			d.routes = append(d.routes, route{
+				method:    strings.ToUpper(method),
+				segments:  splitPath(path),
+				operation: operation,
+			})
+		}
+	}
+
+	//Literal segments take precedence over parameters, e.g. /task/search over /task/{taskID}
+	sort.Slice(d.routes, func(i, j int) bool {
+		return d.routes[i].parameters() < d.routes[j].parameters()
+	})
+
+	return d, nil
+}
+
+func (r route) parameters() int {
+	count := 0
+	for _, segment := range r.segments {
+		if isParameter(segment) {
+			count++
+		}
+	}
+	return count
+}
+
+func isParameter(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func (d *Document) resolveOperation(operation *Operation) error {
+	for i, parameter := range operation.Parameters {
+		if parameter.Ref != "" {
+			name, found := strings.CutPrefix(parameter.Ref, "#/components/parameters/")
+			resolved := d.Components.Parameters[name]
+			if !found || resolved == nil {
+				return fmt.Errorf("unknown parameter %s", parameter.Ref)
+			}
+			operation.Parameters[i], parameter = resolved, resolved
+		}
+		if parameter.Schema == nil {
+			return fmt.Errorf("parameter %s has no schema", parameter.Name)
+		}
+		if err := d.resolveSchema(parameter.Schema); err != nil {
+			return fmt.Errorf("parameter %s: %w", parameter.Name, err)
+		}
+	}
+
+	if operation.RequestBody != nil {
+		for contentType, media := range operation.RequestBody.Content {
+			if media.Schema == nil {
+				continue
+			}
+			if err := d.resolveSchema(media.Schema); err != nil {
+				return fmt.Errorf("%s request body: %w", contentType, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveSchema links the $ref of the schema and its subschemas to the component schemas and compiles the patterns
+func (d *Document) resolveSchema(schema *Schema) error {
+	if schema.resolved {
+		return nil
+	}
+	schema.resolved = true
+
+	if schema.Ref != "" {
+		name, found := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		schema.target = d.Components.Schemas[name]
+		if !found || schema.target == nil {
+			return fmt.Errorf("unknown schema %s", schema.Ref)
+		}
+		return d.resolveSchema(schema.target)
+	}
+
+	if schema.Pattern != "" {
+		pattern, err := regexp.Compile(schema.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		schema.pattern = pattern
+	}
+
+	if len(schema.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(schema.AdditionalProperties, &allowed); err == nil {
+			schema.closed = !allowed
+		} else {
+			schema.additional = &Schema{}
+			if err := json.Unmarshal(schema.AdditionalProperties, schema.additional); err != nil {
+				return fmt.Errorf("invalid additionalProperties: %w", err)
+			}
+		}
+	}
+
+	subschemas := append([]*Schema{schema.Items, schema.additional}, schema.AllOf...)
+	for _, property := range schema.Properties {
+		subschemas = append(subschemas, property)
+	}
+	for _, subschema := range subschemas {
+		if subschema == nil {
+			continue
+		}
+		if err := d.resolveSchema(subschema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP replies with the document
+func (d *Document) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(d.raw)
+}
+
+// find returns the operation of the request and the values of the path parameters, nil when the API doesn't have it
+func (d *Document) find(method, path string) (*Operation, map[string]string) {
+	segments := splitPath(path)
+	for _, route := range d.routes {
+		if route.method != method || len(route.segments) != len(segments) {
+			continue
+		}
+
+		params := map[string]string{}
+		matches := true
+		for i, segment := range route.segments {
+			if isParameter(segment) {
+				params[segment[1:len(segment)-1]] = segments[i]
+				continue
+			}
+			if segment != segments[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return route.operation, params
+		}
+	}
+	return nil, nil
+}
+
+// splitPath splits the path on its segments, ignoring the trailing slash
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
@@ -0,0 +1,148 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	httpErr "github.com/tasker/http"
+)
+
+// ValidationError lists every way a request doesn't match the document, the fields are prefixed with where they are
+// on the request: body, path, query or header
+type ValidationError struct {
+	Violations []httpErr.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	violations := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		violations[i] = violation.Error()
+	}
+	return "invalid request: " + strings.Join(violations, "; ")
+}
+
+const jsonMediaType = "application/json"
+
+func violation(field, detail string) httpErr.FieldError {
+	return httpErr.FieldError{Field: field, Detail: detail}
+}
+
+// Validate replies with 400 to the requests whose parameters or JSON body don't match the document, requests to
+// routes the document doesn't describe are left to the router
+func (d *Document) Validate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operation, pathParams := d.find(r.Method, r.URL.Path)
+		if operation == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		violations := validateParameters(r, operation, pathParams)
+
+		if operation.RequestBody != nil {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				httpErr.JSONHandleError(r.Context(), w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("reading request body")))
+				return
+			}
+			//Give the handler the body back
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			violations = validateBody(body, r.Header.Get("Content-Type"), operation.RequestBody, violations)
+		}
+
+		if len(violations) > 0 {
+			err := &ValidationError{Violations: violations}
+			httpErr.JSONHandleError(r.Context(), w, httpErr.WrapError(err, httpErr.ErrValidation.WithMessage(err.Error()).WithFieldErrors(violations...)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validateParameters(r *http.Request, operation *Operation, pathParams map[string]string) []httpErr.FieldError {
+	var violations []httpErr.FieldError
+	query := r.URL.Query()
+	for _, parameter := range operation.Parameters {
+		var value string
+		var found bool
+		switch parameter.In {
+		case "path":
+			value, found = pathParams[parameter.Name]
+		case "query":
+			value, found = query.Get(parameter.Name), query.Has(parameter.Name)
+		case "header":
+			value = r.Header.Get(parameter.Name)
+			found = value != ""
+		default:
+			continue
+		}
+
+		path := parameter.In + "." + parameter.Name
+		if !found {
+			if parameter.Required {
+				violations = append(violations, violation(path, "is required"))
+			}
+			continue
+		}
+		violations = parameter.Schema.validate(path, parameterValue(parameter.Schema, value), violations)
+	}
+	return violations
+}
+
+// parameterValue converts the text of a parameter to the value the schema type expects, as a JSON decoder would
+func parameterValue(schema *Schema, value string) any {
+	for schema.target != nil {
+		schema = schema.target
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// validateBody checks the body against the schema of its content type, the JSON one when the operation doesn't describe
+// it. Only JSON bodies are validated, the handlers parse the others
+func validateBody(body []byte, contentType string, requestBody *RequestBody, violations []httpErr.FieldError) []httpErr.FieldError {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if requestBody.Required {
+			violations = append(violations, violation("body", "is required"))
+		}
+		return violations
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if _, found := requestBody.Content[mediaType]; !found {
+		mediaType = jsonMediaType
+	}
+	if mediaType != jsonMediaType {
+		return violations
+	}
+	media, found := requestBody.Content[mediaType]
+	if !found || media.Schema == nil {
+		return violations
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return append(violations, violation("body", fmt.Sprintf("must be valid JSON: %s", err)))
+	}
+	if decoder.More() {
+		return append(violations, violation("body", "must hold a single JSON value"))
+	}
+	return media.Schema.validate("body", value, violations)
+}
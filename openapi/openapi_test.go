@@ -0,0 +1,193 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	httpErr "github.com/tasker/http"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	//Every path parameter of the templates is described
+	for _, route := range doc.routes {
+		for _, segment := range route.segments {
+			if !isParameter(segment) {
+				continue
+			}
+			name := segment[1 : len(segment)-1]
+			described := false
+			for _, parameter := range route.operation.Parameters {
+				described = described || parameter.In == "path" && parameter.Name == name
+			}
+			assert.True(t, described, "%s %s misses the %s path parameter", route.method, strings.Join(route.segments, "/"), name)
+		}
+	}
+}
+
+func TestParse_UnknownReference(t *testing.T) {
+	_, err := Parse([]byte(`{"paths": {"/task/": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}}}}}}}`))
+
+	assert.ErrorContains(t, err, "unknown schema #/components/schemas/Missing")
+}
+
+func TestDocument_ServeHTTP(t *testing.T) {
+	doc, err := Load()
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	doc.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var served map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &served))
+	assert.Equal(t, "3.0.3", served["openapi"])
+}
+
+func TestDocument_Validate(t *testing.T) {
+	doc, err := Load()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	validStep := `{"type": "api_call", "params": {"url": "http://localhost"}}`
+	tests := map[string]struct {
+		method, target, body string
+		contentType          string
+		wantViolations       []string
+	}{
+		"valid task": {
+			method: http.MethodPost, target: "/task/",
+			body: `{"name": "t", "steps": [{"type": "api_call", "params": {"url": "http://localhost"}, "failure_step": ` + validStep + `}]}`,
+		},
+		"task with null failure step": {
+			method: http.MethodPost, target: "/task",
+			body: `{"name": "t", "steps": [{"type": "email", "params": {"to": "a@b.c"}, "failure_step": null}]}`,
+		},
+		"invalid task": {
+			method: http.MethodPost, target: "/task/",
+			body: `{"steps": [{"type": "ftp", "params": {"retries": 3}, "extra": true}, {"type": "command", "params": {}, "failure_step": {"type": "email"}}]}`,
+			wantViolations: []string{
+				"body.name is required",
+				"body.steps[0].extra is not allowed",
+				"body.steps[0].params.retries must be a string",
+				"body.steps[0].type must be one of api_call, storage_read, storage_write, sql_query, command, email, storage_op",
+				"body.steps[1].failure_step.params is required",
+				"body.steps[1].params must have at least 1 properties",
+			},
+		},
+		"missing body": {
+			method: http.MethodPost, target: "/secret/",
+			wantViolations: []string{"body is required"},
+		},
+		"malformed body": {
+			method: http.MethodPost, target: "/schedule/", body: `{"cron": `,
+			wantViolations: []string{"body must be valid JSON: unexpected EOF"},
+		},
+		"invalid secret": {
+			method: http.MethodPost, target: "/secret/", body: `{"name": "api key", "value": ""}`,
+			wantViolations: []string{"body.name must match ^[A-Za-z0-9_.-]+$", "body.value must not be empty"},
+		},
+		"invalid schedule": {
+			method: http.MethodPost, target: "/schedule/", body: `{"cron": "* * * * *", "task_id": 1.5, "retries": -1, "enabled": "yes"}`,
+			wantViolations: []string{"body.enabled must be a boolean", "body.retries must be at least 0", "body.task_id must be an integer"},
+		},
+		"invalid path parameters": {
+			method: http.MethodPost, target: "/task/abc/execute/1", body: `{"idempotency_token": "token"}`,
+			wantViolations: []string{"path.taskID must be an integer"},
+		},
+		"valid query parameters": {
+			method: http.MethodGet, target: "/audit?entity=schedule&from=2024-01-01T00:00:00Z&limit=10",
+		},
+		"invalid query parameters": {
+			method: http.MethodGet, target: "/audit?entity=user&from=yesterday&limit=5000",
+			wantViolations: []string{
+				"query.entity must be one of task, schedule, secret, api_key, workspace",
+				"query.from must be an RFC 3339 date",
+				"query.limit must be at most 1000",
+			},
+		},
+		"valid task listing": {
+			method: http.MethodGet, target: "/task?name=report&step_type=email&scheduled=true&limit=20",
+		},
+		"invalid task listing": {
+			method: http.MethodGet, target: "/task/?step_type=ftp&scheduled=maybe&limit=0",
+			wantViolations: []string{
+				"query.step_type must be one of api_call, storage_read, storage_write, sql_query, command, email, storage_op",
+				"query.scheduled must be a boolean",
+				"query.limit must be at least 1",
+			},
+		},
+		"yaml import": {
+			method: http.MethodPost, target: "/import?dry_run=true", contentType: "application/yaml; charset=utf-8",
+			body: "version: 1\ntasks:\n  - name: t\n",
+		},
+		"invalid json import": {
+			method: http.MethodPost, target: "/import", contentType: "application/json",
+			body:           `{"version": 2, "tasks": [{"name": "t", "schedules": [{"name": "", "cron": "* * * * *"}]}]}`,
+			wantViolations: []string{"body.tasks[0].steps is required", "body.tasks[0].schedules[0].name must not be empty", "body.version must be one of 1"},
+		},
+		"dry run without body": {
+			method: http.MethodPost, target: "/task/3/dry-run",
+		},
+		"invalid dry run": {
+			method: http.MethodPost, target: "/task/dry-run",
+			body:           `{"task": {"name": "t", "steps": [` + validStep + `]}, "stubs": [{"step": -1, "output": 3}], "run_read_only": "yes"}`,
+			wantViolations: []string{"body.run_read_only must be a boolean", "body.stubs[0].output must be a string", "body.stubs[0].step must be at least 0"},
+		},
+		"invalid resume": {
+			method: http.MethodPost, target: "/execution/abc/resume", body: `{"idempotency_token": ""}`,
+			wantViolations: []string{"path.executionID must be an integer", "body.idempotency_token must not be empty"},
+		},
+		"undescribed route": {
+			method: http.MethodPost, target: "/unknown", body: `not json`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var handledBody string
+			handler := doc.Validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				handledBody = string(body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			request := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			if test.contentType != "" {
+				request.Header.Set("Content-Type", test.contentType)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if len(test.wantViolations) == 0 {
+				assert.Equal(t, http.StatusNoContent, recorder.Code, recorder.Body.String())
+				assert.Equal(t, test.body, handledBody)
+				return
+			}
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			assert.Equal(t, httpErr.ProblemContentType, recorder.Header().Get("Content-Type"))
+			var problem httpErr.Problem
+			if !assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem)) {
+				return
+			}
+			assert.Equal(t, "validation_failed", problem.Code)
+			assert.Equal(t, "invalid request: "+strings.Join(test.wantViolations, "; "), problem.Detail)
+			violations := make([]string, len(problem.Errors))
+			for i, violation := range problem.Errors {
+				violations[i] = violation.Error()
+			}
+			assert.Equal(t, test.wantViolations, violations)
+		})
+	}
+}
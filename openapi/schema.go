@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	httpErr "github.com/tasker/http"
+)
+
+// Schema is the subset of the OpenAPI 3.0 schema object the validation supports
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Nullable             bool               `json:"nullable"`
+	Enum                 []any              `json:"enum"`
+	AllOf                []*Schema          `json:"allOf"`
+	MinLength            *int               `json:"minLength"`
+	Pattern              string             `json:"pattern"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Items                *Schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	MinProperties        *int               `json:"minProperties"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+
+	resolved bool
+	target   *Schema
+	pattern  *regexp.Regexp
+	// closed rejects the properties not listed, additional validates them
+	closed     bool
+	additional *Schema
+}
+
+// validate appends to violations why value, decoded with json.Decoder.UseNumber, doesn't match the schema
+func (s *Schema) validate(path string, value any, violations []httpErr.FieldError) []httpErr.FieldError {
+	if s.target != nil {
+		return s.target.validate(path, value, violations)
+	}
+
+	if value == nil {
+		if s.Nullable || s.Type == "" && len(s.AllOf) == 0 {
+			return violations
+		}
+		return append(violations, violation(path, "must not be null"))
+	}
+
+	for _, schema := range s.AllOf {
+		violations = schema.validate(path, value, violations)
+	}
+
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		options := make([]string, len(s.Enum))
+		for i, option := range s.Enum {
+			options[i] = fmt.Sprint(option)
+		}
+		return append(violations, violation(path, fmt.Sprintf("must be one of %s", strings.Join(options, ", "))))
+	}
+
+	switch s.Type {
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return append(violations, violation(path, "must be a string"))
+		}
+		return s.validateString(path, text, violations)
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return append(violations, violation(path, "must be an integer"))
+		}
+		if _, err := number.Int64(); err != nil {
+			return append(violations, violation(path, "must be an integer"))
+		}
+		return s.validateNumber(path, number, violations)
+	case "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return append(violations, violation(path, "must be a number"))
+		}
+		return s.validateNumber(path, number, violations)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return append(violations, violation(path, "must be a boolean"))
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return append(violations, violation(path, "must be an array"))
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			violations = append(violations, violation(path, fmt.Sprintf("must have at least %d items", *s.MinItems)))
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				violations = s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return append(violations, violation(path, "must be an object"))
+		}
+		return s.validateObject(path, object, violations)
+	}
+	return violations
+}
+
+func (s *Schema) inEnum(value any) bool {
+	for _, option := range s.Enum {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) validateString(path, text string, violations []httpErr.FieldError) []httpErr.FieldError {
+	if s.MinLength != nil && len(text) < *s.MinLength {
+		if *s.MinLength == 1 {
+			return append(violations, violation(path, "must not be empty"))
+		}
+		return append(violations, violation(path, fmt.Sprintf("must have at least %d characters", *s.MinLength)))
+	}
+	if s.pattern != nil && !s.pattern.MatchString(text) {
+		return append(violations, violation(path, fmt.Sprintf("must match %s", s.Pattern)))
+	}
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, text); err != nil {
+			return append(violations, violation(path, "must be an RFC 3339 date"))
+		}
+	}
+	return violations
+}
+
+func (s *Schema) validateNumber(path string, number json.Number, violations []httpErr.FieldError) []httpErr.FieldError {
+	value, err := number.Float64()
+	if err != nil {
+		return append(violations, violation(path, "must be a number"))
+	}
+	if s.Minimum != nil && value < *s.Minimum {
+		violations = append(violations, violation(path, fmt.Sprintf("must be at least %v", *s.Minimum)))
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		violations = append(violations, violation(path, fmt.Sprintf("must be at most %v", *s.Maximum)))
+	}
+	return violations
+}
+
+func (s *Schema) validateObject(path string, object map[string]any, violations []httpErr.FieldError) []httpErr.FieldError {
+	for _, name := range s.Required {
+		if _, found := object[name]; !found {
+			violations = append(violations, violation(path+"."+name, "is required"))
+		}
+	}
+	if s.MinProperties != nil && len(object) < *s.MinProperties {
+		violations = append(violations, violation(path, fmt.Sprintf("must have at least %d properties", *s.MinProperties)))
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPath := path + "." + name
+		switch property, found := s.Properties[name]; {
+		case found:
+			violations = property.validate(propertyPath, object[name], violations)
+		case s.additional != nil:
+			violations = s.additional.validate(propertyPath, object[name], violations)
+		case s.closed:
+			violations = append(violations, violation(propertyPath, "is not allowed"))
+		}
+	}
+	return violations
+}
@@ -6,8 +6,11 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/tasker/service/apicall"
+	"github.com/tasker/service/secrets"
+	"github.com/tasker/tracing"
 )
 
 func (r repository) ApiCall(ctx context.Context, method, url, body string, headers map[string][]string) (apicall.Response, error) {
@@ -19,11 +22,28 @@ func (r repository) ApiCall(ctx context.Context, method, url, body string, heade
 	if err != nil {
 		return apicall.Response{}, fmt.Errorf("preparing API request to %s: %w", url, err)
 	}
+
+	// Propagate the trace context of the step, so the downstream service joins the execution trace
+	tracing.Inject(ctx, request.Header)
+	span := tracing.SpanFromContext(ctx)
+	span.SetAttribute("http.method", method)
+	span.SetAttribute("http.url", secrets.RedactorFromContext(ctx).Redact(url))
+
+	// Wait for the outbound limit of the host, the slot is held until the response is read
+	waitStart := time.Now()
+	release, err := r.limits.acquire(ctx, url)
+	if err != nil {
+		return apicall.Response{}, fmt.Errorf("API call to %s: %w", url, err)
+	}
+	defer release()
+	span.SetAttribute("outbound_limit.wait_ms", time.Since(waitStart).Milliseconds())
+
 	resp, err := r.client.Do(request)
 	if err != nil {
 		return apicall.Response{}, fmt.Errorf("making API call to %s: %w", url, err)
 	}
 	defer resp.Body.Close()
+	span.SetAttribute("http.status_code", resp.StatusCode)
 
 	// Read the Response body
 	responseBody, err := io.ReadAll(resp.Body)
@@ -0,0 +1,162 @@
+package apicall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLimitReached fails the api calls over an outbound limit that fails fast
+var ErrLimitReached = errors.New("outbound limit reached")
+
+// busyRetryInterval is how often a call waiting for a concurrency slot checks again
+const busyRetryInterval = 20 * time.Millisecond
+
+// Limit caps the requests sent to its hosts by every execution of the process, or of every instance with the redis
+// limiter
+type Limit struct {
+	// Name identifies the limit, it's the target host when Hosts is empty
+	Name string
+	// Hosts share the limit as a group, the requests to any of them count together
+	Hosts []string
+	// Rate is the requests per second refilled on the token bucket, 0 disables the rate limit
+	Rate float64
+	// Burst is the token bucket size, the requests that can be sent at once after being idle
+	Burst int
+	// MaxConcurrent caps the requests in flight, 0 disables the concurrency limit
+	MaxConcurrent int
+	// FailFast fails the call right away when the limit is reached, instead of waiting until the step times out
+	FailFast bool
+}
+
+// Limiter takes the slots of the limits
+type Limiter interface {
+	// TryAcquire takes a rate token and a concurrency slot of the limit, release gives the slot back once the request
+	// ends. When the limit is reached it takes none, release is nil and retryAfter tells when to try again
+	TryAcquire(ctx context.Context, limit Limit) (release func(), retryAfter time.Duration, err error)
+}
+
+// Limits finds the limit of each target host and waits for its slots
+type Limits struct {
+	byHost  map[string]Limit
+	limiter Limiter
+}
+
+// NewLimits validates the limits, a host can only be on one of them
+func NewLimits(limits []Limit, limiter Limiter) (*Limits, error) {
+	byHost := map[string]Limit{}
+	for _, limit := range limits {
+		if limit.Rate <= 0 && limit.MaxConcurrent <= 0 {
+			return nil, fmt.Errorf("outbound limit %s must have a rate or a max concurrency", limit.Name)
+		}
+		if limit.Rate > 0 && limit.Burst < 1 {
+			limit.Burst = int(math.Max(1, math.Ceil(limit.Rate)))
+		}
+
+		hosts := limit.Hosts
+		if len(hosts) == 0 {
+			hosts = []string{limit.Name}
+		}
+		for _, host := range hosts {
+			host = strings.ToLower(host)
+			if other, found := byHost[host]; found {
+				return nil, fmt.Errorf("host %s is on the outbound limits %s and %s", host, other.Name, limit.Name)
+			}
+			byHost[host] = limit
+		}
+	}
+
+	return &Limits{byHost: byHost, limiter: limiter}, nil
+}
+
+// acquire waits for the slots of the limit of the URL host, the returned func releases them. Hosts without a limit
+// are not waited for
+func (l *Limits) acquire(ctx context.Context, rawURL string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing url: %w", err)
+	}
+	limit, found := l.byHost[strings.ToLower(target.Hostname())]
+	if !found {
+		return func() {}, nil
+	}
+
+	for {
+		release, retryAfter, err := l.limiter.TryAcquire(ctx, limit)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("checking the %s outbound limit: %w", limit.Name, err)
+		case release != nil:
+			return release, nil
+		case limit.FailFast:
+			return nil, fmt.Errorf("%w: %s", ErrLimitReached, limit.Name)
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("waiting for the %s outbound limit: %w", limit.Name, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// localLimiter keeps the limits in memory, they are only shared by the executions of the process
+type localLimiter struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	states map[string]*localLimitState
+}
+
+type localLimitState struct {
+	tokens   float64
+	refilled time.Time
+	inFlight int
+}
+
+func NewLocalLimiter() Limiter {
+	return &localLimiter{now: time.Now, states: map[string]*localLimitState{}}
+}
+
+func (l *localLimiter) TryAcquire(_ context.Context, limit Limit) (func(), time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	state, found := l.states[limit.Name]
+	if !found {
+		state = &localLimitState{tokens: float64(limit.Burst), refilled: now}
+		l.states[limit.Name] = state
+	}
+
+	if limit.MaxConcurrent > 0 && state.inFlight >= limit.MaxConcurrent {
+		return nil, busyRetryInterval, nil
+	}
+	if limit.Rate > 0 {
+		state.tokens = math.Min(float64(limit.Burst), state.tokens+now.Sub(state.refilled).Seconds()*limit.Rate)
+		state.refilled = now
+		if state.tokens < 1 {
+			return nil, time.Duration((1 - state.tokens) / limit.Rate * float64(time.Second)), nil
+		}
+		state.tokens--
+	}
+
+	state.inFlight++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			state.inFlight--
+		})
+	}, 0, nil
+}
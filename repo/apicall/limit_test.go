@@ -0,0 +1,205 @@
+package apicall
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLimits(t *testing.T) {
+	tests := map[string]struct {
+		limits  []Limit
+		wantErr string
+	}{
+		"no rate nor concurrency": {
+			limits:  []Limit{{Name: "api.partner.com"}},
+			wantErr: "outbound limit api.partner.com must have a rate or a max concurrency",
+		},
+		"host on two limits": {
+			limits:  []Limit{{Name: "partner", Hosts: []string{"api.partner.com"}, Rate: 1}, {Name: "API.partner.com", MaxConcurrent: 1}},
+			wantErr: "host api.partner.com is on the outbound limits partner and API.partner.com",
+		},
+		"valid": {
+			limits: []Limit{{Name: "partner", Hosts: []string{"api.partner.com", "eu.partner.com"}, Rate: 1}, {Name: "other.com", MaxConcurrent: 1}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewLimits(tt.limits, NewLocalLimiter())
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLimits_acquire(t *testing.T) {
+	t.Run("host without limit", func(t *testing.T) {
+		limits, err := NewLimits([]Limit{{Name: "api.partner.com", MaxConcurrent: 1, FailFast: true}}, NewLocalLimiter())
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := limits.acquire(context.Background(), "https://other.com/resource")
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("nil limits", func(t *testing.T) {
+		var limits *Limits
+		release, err := limits.acquire(context.Background(), "https://api.partner.com")
+		assert.NoError(t, err)
+		release()
+	})
+
+	t.Run("fail fast on the concurrency of a group", func(t *testing.T) {
+		limits, err := NewLimits([]Limit{{Name: "partner", Hosts: []string{"api.partner.com", "eu.partner.com"}, MaxConcurrent: 1, FailFast: true}}, NewLocalLimiter())
+		require.NoError(t, err)
+
+		release, err := limits.acquire(context.Background(), "https://API.partner.com:8443/orders")
+		require.NoError(t, err)
+
+		_, err = limits.acquire(context.Background(), "https://eu.partner.com/orders")
+		assert.ErrorIs(t, err, ErrLimitReached)
+
+		release()
+		release()
+		release, err = limits.acquire(context.Background(), "https://eu.partner.com/orders")
+		assert.NoError(t, err)
+		release()
+	})
+
+	t.Run("waits for the concurrency slot", func(t *testing.T) {
+		limits, err := NewLimits([]Limit{{Name: "api.partner.com", MaxConcurrent: 1}}, NewLocalLimiter())
+		require.NoError(t, err)
+
+		release, err := limits.acquire(context.Background(), "https://api.partner.com")
+		require.NoError(t, err)
+		time.AfterFunc(50*time.Millisecond, release)
+
+		start := time.Now()
+		release, err = limits.acquire(context.Background(), "https://api.partner.com")
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		release()
+	})
+
+	t.Run("waits until the context is done", func(t *testing.T) {
+		limits, err := NewLimits([]Limit{{Name: "api.partner.com", Rate: 0.1}}, NewLocalLimiter())
+		require.NoError(t, err)
+
+		_, err = limits.acquire(context.Background(), "https://api.partner.com")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+		_, err = limits.acquire(ctx, "https://api.partner.com")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestLocalLimiter_TryAcquire_Rate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := &localLimiter{now: func() time.Time { return now }, states: map[string]*localLimitState{}}
+	limit := Limit{Name: "api.partner.com", Rate: 2, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		release, _, err := limiter.TryAcquire(context.Background(), limit)
+		require.NoError(t, err)
+		assert.NotNil(t, release)
+	}
+
+	release, retryAfter, err := limiter.TryAcquire(context.Background(), limit)
+	assert.NoError(t, err)
+	assert.Nil(t, release)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	release, _, err = limiter.TryAcquire(context.Background(), limit)
+	assert.NoError(t, err)
+	assert.NotNil(t, release)
+}
+
+// renewRecorder fakes the redis client, counting the lease renewals of each slot
+type renewRecorder struct {
+	mu       sync.Mutex
+	renewals int
+}
+
+func (r *renewRecorder) Eval(ctx context.Context, script string, _ []string, _ ...interface{}) *redis.Cmd {
+	if script == renewScript {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.renewals++
+	}
+	return redis.NewCmdResult(int64(0), nil)
+}
+
+func (r *renewRecorder) ZRem(ctx context.Context, _ string, _ ...interface{}) *redis.IntCmd {
+	return redis.NewIntResult(1, nil)
+}
+
+func (r *renewRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.renewals
+}
+
+func TestRedisLimiter_RenewsLeaseUntilReleased(t *testing.T) {
+	db := &renewRecorder{}
+	limiter := NewRedisLimiter(db, 30*time.Millisecond)
+
+	release, _, err := limiter.TryAcquire(context.Background(), Limit{Name: "partner", MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.NotNil(t, release)
+
+	//A request longer than the lease keeps its slot
+	assert.Eventually(t, func() bool { return db.count() >= 2 }, time.Second, 5*time.Millisecond)
+	release()
+	renewals := db.count()
+	time.Sleep(60 * time.Millisecond)
+	assert.LessOrEqual(t, db.count(), renewals+1)
+}
+
+// TestRedisLimiter needs a running redis, e.g. TASKER_TEST_REDIS_ADDR=localhost:6379 from the docker compose
+func TestRedisLimiter(t *testing.T) {
+	addr := os.Getenv("TASKER_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TASKER_TEST_REDIS_ADDR not set")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("connecting to redis: %s", err)
+	}
+
+	ctx := context.Background()
+	limit := Limit{Name: "test-" + time.Now().Format(time.RFC3339Nano), Rate: 1, Burst: 1, MaxConcurrent: 1}
+	tokensKey, inFlightKey := limitKeys(limit)
+	defer client.Del(ctx, tokensKey, inFlightKey)
+	limiter := NewRedisLimiter(client, time.Minute)
+
+	release, _, err := limiter.TryAcquire(ctx, limit)
+	require.NoError(t, err)
+	require.NotNil(t, release)
+
+	busy, retryAfter, err := limiter.TryAcquire(ctx, limit)
+	assert.NoError(t, err)
+	assert.Nil(t, busy)
+	assert.Equal(t, busyRetryInterval, retryAfter)
+
+	release()
+	noToken, retryAfter, err := limiter.TryAcquire(ctx, limit)
+	assert.NoError(t, err)
+	assert.Nil(t, noToken)
+	assert.Greater(t, retryAfter, time.Duration(0))
+	assert.LessOrEqual(t, retryAfter, time.Second)
+}
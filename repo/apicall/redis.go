@@ -0,0 +1,127 @@
+package apicall
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript takes a concurrency slot and a rate token of a limit atomically, using the redis clock so every
+// instance agrees on it. The in flight requests are a sorted set scored by the end of their lease, so the slots of a
+// crashed instance are freed once the lease ends. Returns 0 when acquired, -1 when the concurrency limit is reached or
+// the milliseconds until the next token otherwise
+const acquireScript = `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local rate, burst, max = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local lease, holder = tonumber(ARGV[4]), ARGV[5]
+
+if max > 0 then
+	redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', now)
+	if redis.call('ZCARD', KEYS[2]) >= max then
+		return -1
+	end
+end
+
+if rate > 0 then
+	local state = redis.call('HMGET', KEYS[1], 'tokens', 'refilled')
+	local tokens = tonumber(state[1]) or burst
+	local refilled = tonumber(state[2]) or now
+	tokens = math.min(burst, tokens + (now - refilled) * rate / 1000)
+	if tokens < 1 then
+		return math.ceil((1 - tokens) * 1000 / rate)
+	end
+	redis.call('HSET', KEYS[1], 'tokens', tostring(tokens - 1), 'refilled', now)
+	redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+end
+
+if max > 0 then
+	redis.call('ZADD', KEYS[2], now + lease, holder)
+	redis.call('PEXPIRE', KEYS[2], lease)
+end
+return 0
+`
+
+// renewScript extends the lease of a slot still held, a released or expired slot isn't taken again
+const renewScript = `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local lease, holder = tonumber(ARGV[1]), ARGV[2]
+
+if redis.call('ZSCORE', KEYS[1], holder) then
+	redis.call('ZADD', KEYS[1], now + lease, holder)
+	redis.call('PEXPIRE', KEYS[1], lease)
+end
+return 0
+`
+
+// RedisDB is the part of the redis client the limiter uses
+type RedisDB interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+	ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+}
+
+// redisLimiter shares the limits between every instance using the same redis
+type redisLimiter struct {
+	db RedisDB
+	// lease is how long a slot is held when it is never released, it's renewed while the request is in flight
+	lease time.Duration
+}
+
+func NewRedisLimiter(db RedisDB, lease time.Duration) Limiter {
+	return &redisLimiter{db: db, lease: lease}
+}
+
+func (l *redisLimiter) TryAcquire(ctx context.Context, limit Limit) (func(), time.Duration, error) {
+	tokensKey, inFlightKey := limitKeys(limit)
+	holder := uuid.New().String()
+	wait, err := l.db.Eval(ctx, acquireScript, []string{tokensKey, inFlightKey},
+		limit.Rate, limit.Burst, limit.MaxConcurrent, l.lease.Milliseconds(), holder).Int64()
+	switch {
+	case err != nil:
+		return nil, 0, fmt.Errorf("acquiring outbound limit on redis: %w", err)
+	case wait < 0:
+		return nil, busyRetryInterval, nil
+	case wait > 0:
+		return nil, time.Duration(wait) * time.Millisecond, nil
+	}
+
+	if limit.MaxConcurrent <= 0 {
+		return func() {}, 0, nil
+	}
+	stop := make(chan struct{})
+	go l.renew(context.WithoutCancel(ctx), inFlightKey, holder, stop)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			//The request context may be over already, and if the release fails the lease frees the slot later
+			_ = l.db.ZRem(context.WithoutCancel(ctx), inFlightKey, holder).Err()
+		})
+	}, 0, nil
+}
+
+// renew extends the slot lease until it's released, so the requests longer than the lease keep their slot. A crashed
+// instance stops renewing and its slots are freed once their lease ends
+func (l *redisLimiter) renew(ctx context.Context, inFlightKey, holder string, stop <-chan struct{}) {
+	ticker := time.NewTicker(l.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			//A failed renewal is tried again on the next tick, the lease lasts for a few of them
+			_ = l.db.Eval(ctx, renewScript, []string{inFlightKey}, l.lease.Milliseconds(), holder).Err()
+		}
+	}
+}
+
+func limitKeys(limit Limit) (tokens string, inFlight string) {
+	prefix := "tasker:outbound_limit:" + limit.Name
+	return prefix + ":tokens", prefix + ":in_flight"
+}
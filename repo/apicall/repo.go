@@ -13,8 +13,14 @@ type Repository interface {
 
 type repository struct {
 	client http.Client
+	limits *Limits
 }
 
 func NewRepository(client http.Client) Repository {
-	return &repository{client: client}
+	return NewRepositoryWithLimits(client, nil)
+}
+
+// NewRepositoryWithLimits waits for the outbound limit of the target host before each call, nil limits don't limit
+func NewRepositoryWithLimits(client http.Client, limits *Limits) Repository {
+	return &repository{client: client, limits: limits}
 }
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tasker/http"
+	"github.com/tasker/service/command"
+)
+
+const (
+	// maxOutputBytes caps stdout and stderr so a noisy command can't exhaust the memory
+	maxOutputBytes = 1 << 20
+	// killWaitDelay is how long we wait for the output pipes after killing a timed out command
+	killWaitDelay = time.Second
+)
+
+var (
+	// envNameRegexp is the only shape of variable the tasks can set, the uppercase names of the application settings
+	envNameRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	// deniedEnvNames change how the shells, the loaders and the interpreters find or start what they run
+	deniedEnvNames = map[string]bool{
+		"PATH": true, "IFS": true, "ENV": true, "BASH_ENV": true, "CDPATH": true, "SHELLOPTS": true, "BASHOPTS": true,
+		"PS4": true, "GLOBIGNORE": true, "HOSTALIASES": true, "LOCALDOMAIN": true, "RES_OPTIONS": true, "NLSPATH": true,
+		"LOCPATH": true, "CLASSPATH": true,
+	}
+	// deniedEnvPrefixes are the families of variables of the loaders and the interpreters
+	deniedEnvPrefixes = []string{"LD_", "DYLD_", "GCONV_", "BASH_", "NODE_", "PYTHON", "PERL", "RUBY", "JAVA_", "JDK_", "LUA_", "GIT_"}
+)
+
+func (r repository) Run(ctx context.Context, cmd command.Command) (command.Result, error) {
+	if !r.allowed[cmd.Executable] {
+		return command.Result{}, http.WrapError(fmt.Errorf("executable %s is not whitelisted", cmd.Executable), http.ErrBadRequest.WithMessage("executable not allowed"))
+	}
+
+	env, err := buildEnv(cmd.Env)
+	if err != nil {
+		return command.Result{}, http.WrapError(err, http.ErrBadRequest.WithMessage(err.Error()))
+	}
+
+	path, err := exec.LookPath(cmd.Executable)
+	if err != nil {
+		return command.Result{}, fmt.Errorf("looking up executable: %w", err)
+	}
+
+	stdout, stderr := &limitedBuffer{limit: maxOutputBytes}, &limitedBuffer{limit: maxOutputBytes}
+	execCmd := exec.CommandContext(ctx, path, cmd.Args...)
+	execCmd.Dir = r.workDir
+	execCmd.Env = env
+	execCmd.Stdin = strings.NewReader(cmd.Stdin)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+	execCmd.WaitDelay = killWaitDelay
+
+	err = execCmd.Run()
+	if ctx.Err() != nil {
+		return command.Result{}, fmt.Errorf("command interrupted: %w", ctx.Err())
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		return command.Result{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: exitErr.ExitCode()}, nil
+	case err != nil:
+		return command.Result{}, err
+	}
+
+	return command.Result{Stdout: stdout.String(), Stderr: stderr.String()}, nil
+}
+
+// buildEnv only keeps PATH from the tasker process, so the commands can't read our own credentials. The variables that
+// change which binary runs or what it loads are denied, they would get around the allowed executables
+func buildEnv(env map[string]string) ([]string, error) {
+	result := []string{"PATH=" + os.Getenv("PATH")}
+	for k, v := range env {
+		if !allowedEnv(k) {
+			return nil, fmt.Errorf("environment variable %s is not allowed", k)
+		}
+		result = append(result, k+"="+v)
+	}
+	return result, nil
+}
+
+func allowedEnv(name string) bool {
+	if !envNameRegexp.MatchString(name) || deniedEnvNames[name] {
+		return false
+	}
+	for _, prefix := range deniedEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// limitedBuffer silently discards anything written after reaching its limit
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); remaining < len(p) {
+		if remaining > 0 {
+			b.Buffer.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/http"
+	"github.com/tasker/service/command"
+)
+
+func TestRun_NotWhitelisted(t *testing.T) {
+	repo := NewRepository([]string{"echo"}, t.TempDir())
+
+	_, err := repo.Run(context.Background(), command.Command{Executable: "sh"})
+
+	status, _ := err.(http.Error).StatusAndMsg()
+	assert.Equal(t, 400, status)
+}
+
+func TestRun_DeniedEnv(t *testing.T) {
+	repo := NewRepository([]string{"echo"}, t.TempDir())
+
+	for _, name := range []string{"PATH", "LD_PRELOAD", "LD_LIBRARY_PATH", "BASH_ENV", "ENV", "IFS", "GCONV_PATH", "NODE_OPTIONS",
+		"PYTHONSTARTUP", "PERL5OPT", "_JAVA_OPTIONS", "lower_case", "BASH_FUNC_echo%%"} {
+		_, err := repo.Run(context.Background(), command.Command{Executable: "echo", Env: map[string]string{name: "/tmp/evil"}})
+
+		assert.EqualError(t, err, "environment variable "+name+" is not allowed", name)
+		status, _ := err.(http.Error).StatusAndMsg()
+		assert.Equal(t, 400, status, name)
+	}
+}
+
+func TestRun(t *testing.T) {
+	repo := NewRepository([]string{"sh"}, t.TempDir())
+
+	result, err := repo.Run(context.Background(), command.Command{
+		Executable: "sh",
+		Args:       []string{"-c", `read line; echo "$line $GREETING"; echo oops >&2; exit 3`},
+		Env:        map[string]string{"GREETING": "world"},
+		Stdin:      "hello\n",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, command.Result{Stdout: "hello world\n", Stderr: "oops\n", ExitCode: 3}, result)
+}
+
+func TestRun_Timeout(t *testing.T) {
+	repo := NewRepository([]string{"sleep"}, t.TempDir())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := repo.Run(ctx, command.Command{Executable: "sleep", Args: []string{"5"}})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"context"
+
+	"github.com/tasker/service/command"
+)
+
+type Repository interface {
+	Run(ctx context.Context, cmd command.Command) (command.Result, error)
+}
+
+type repository struct {
+	allowed map[string]bool
+	workDir string
+}
+
+// NewRepository creates a command runner that only runs the allowed executables (names resolved on PATH or absolute
+// paths), inside workDir and without inheriting the tasker process environment
+func NewRepository(allowedExecutables []string, workDir string) Repository {
+	allowed := make(map[string]bool, len(allowedExecutables))
+	for _, executable := range allowedExecutables {
+		allowed[executable] = true
+	}
+	return &repository{allowed: allowed, workDir: workDir}
+}
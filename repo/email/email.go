@@ -0,0 +1,109 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tasker/service/email"
+)
+
+const dialTimeout = time.Second * 10
+
+func (r repository) Send(ctx context.Context, msg email.Message) error {
+	if r.config.Host == "" {
+		return errors.New("smtp relay is not configured")
+	}
+
+	//The display names only go on the headers, the smtp envelope takes the bare addresses
+	var recipients []string
+	for _, recipient := range append(append([]string{}, msg.To...), msg.Cc...) {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", recipient, err)
+		}
+		recipients = append(recipients, address.Address)
+	}
+
+	addr := net.JoinHostPort(r.config.Host, strconv.Itoa(r.config.Port))
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("connecting to smtp relay %s: %w", addr, err)
+	}
+	// The smtp client has no context support, the deadline bounds the whole conversation
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, r.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("starting smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if r.config.StartTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: r.config.Host}); err != nil {
+			return fmt.Errorf("starting tls: %w", err)
+		}
+	}
+
+	if r.config.Username != "" {
+		auth := smtp.PlainAuth("", r.config.Username, r.config.Password, r.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	if err := client.Mail(r.config.From); err != nil {
+		return fmt.Errorf("setting sender: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("adding recipient %s: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("starting message data: %w", err)
+	}
+	if _, err := writer.Write(r.buildMessage(msg)); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (r repository) buildMessage(msg email.Message) []byte {
+	contentType := "text/plain"
+	if msg.HTML {
+		contentType = "text/html"
+	}
+
+	var b strings.Builder
+	b.WriteString("From: " + r.config.From + "\r\n")
+	b.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	if len(msg.Cc) > 0 {
+		b.WriteString("Cc: " + strings.Join(msg.Cc, ", ") + "\r\n")
+	}
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: " + contentType + "; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
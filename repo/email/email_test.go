@@ -0,0 +1,118 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/service/email"
+)
+
+// fakeSMTPServer accepts a single session and sends the recipients and message data it received
+func fakeSMTPServer(t *testing.T) (int, <-chan []string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("starting fake smtp server: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	recipientsCh, dataCh := make(chan []string, 1), make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		reader, writer := bufio.NewReader(conn), bufio.NewWriter(conn)
+		reply := func(line string) {
+			writer.WriteString(line + "\r\n")
+			writer.Flush()
+		}
+
+		reply("220 fake ESMTP")
+		var recipients []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); {
+			case cmd == "EHLO" || cmd == "HELO":
+				reply("250 fake")
+			case cmd == "MAIL":
+				reply("250 OK")
+			case cmd == "RCPT":
+				recipients = append(recipients, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				recipientsCh <- recipients
+				dataCh <- data.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, recipientsCh, dataCh
+}
+
+func TestSend(t *testing.T) {
+	port, recipientsCh, dataCh := fakeSMTPServer(t)
+	repo := NewRepository(SMTPConfig{Host: "127.0.0.1", Port: port, From: "tasker@example.com"})
+
+	err := repo.Send(context.Background(), email.Message{
+		To:      []string{"ops@example.com"},
+		Cc:      []string{"team@example.com"},
+		Subject: "Task failed",
+		Body:    "<b>step 3</b> failed",
+		HTML:    true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ops@example.com", "team@example.com"}, <-recipientsCh)
+	data := <-dataCh
+	assert.Contains(t, data, "To: ops@example.com\r\n")
+	assert.Contains(t, data, "Cc: team@example.com\r\n")
+	assert.Contains(t, data, "Subject: Task failed\r\n")
+	assert.Contains(t, data, "Content-Type: text/html; charset=utf-8\r\n")
+	assert.True(t, strings.HasSuffix(data, "\r\n\r\n<b>step 3</b> failed\r\n"))
+}
+
+func TestSend_DisplayNameRecipient(t *testing.T) {
+	port, recipientsCh, dataCh := fakeSMTPServer(t)
+	repo := NewRepository(SMTPConfig{Host: "127.0.0.1", Port: port, From: "tasker@example.com"})
+
+	err := repo.Send(context.Background(), email.Message{To: []string{"Ops <ops@example.com>"}, Subject: "Task failed", Body: "failed"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ops@example.com"}, <-recipientsCh)
+	assert.Contains(t, <-dataCh, "To: Ops <ops@example.com>\r\n")
+}
+
+func TestSend_InvalidRecipient(t *testing.T) {
+	repo := NewRepository(SMTPConfig{Host: "127.0.0.1", Port: 25, From: "tasker@example.com"})
+
+	err := repo.Send(context.Background(), email.Message{To: []string{"not an address"}})
+
+	assert.ErrorContains(t, err, "invalid recipient")
+}
@@ -0,0 +1,30 @@
+package email
+
+import (
+	"context"
+
+	"github.com/tasker/service/email"
+)
+
+type Repository interface {
+	Send(ctx context.Context, msg email.Message) error
+}
+
+// SMTPConfig is the relay used to send every email
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// StartTLS upgrades the connection before authenticating, required by most relays
+	StartTLS bool
+}
+
+type repository struct {
+	config SMTPConfig
+}
+
+func NewRepository(config SMTPConfig) Repository {
+	return &repository{config: config}
+}
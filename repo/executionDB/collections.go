@@ -0,0 +1,42 @@
+package executionDB
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (r repository) ListPush(ctx context.Context, key, value string, front bool) (int64, error) {
+	if front {
+		return r.db.LPush(ctx, key, value).Result()
+	}
+	return r.db.RPush(ctx, key, value).Result()
+}
+
+func (r repository) ListPop(ctx context.Context, key string, front bool) (string, error) {
+	var cmd *redis.StringCmd
+	if front {
+		cmd = r.db.LPop(ctx, key)
+	} else {
+		cmd = r.db.RPop(ctx, key)
+	}
+
+	result, err := cmd.Result()
+	if errors.Is(err, redis.Nil) {
+		return "", NotFound
+	}
+	return result, err
+}
+
+func (r repository) HashGet(ctx context.Context, key, field string) (string, error) {
+	result, err := r.db.HGet(ctx, key, field).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", NotFound
+	}
+	return result, err
+}
+
+func (r repository) HashSet(ctx context.Context, key, field, value string) error {
+	return r.db.HSet(ctx, key, field, value).Err()
+}
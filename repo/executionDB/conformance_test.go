@@ -0,0 +1,230 @@
+package executionDB
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTTL is short enough to wait for it and long enough for the round trips to redis
+const testTTL = 200 * time.Millisecond
+
+// runConformanceSuite checks the behaviour every execution storage backend must share. Keys are prefixed with a
+// random id so the suite can run against a shared redis
+func runConformanceSuite(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	prefix := uuid.New().String() + ":"
+	k := func(key string) string { return prefix + key }
+
+	t.Run("ping", func(t *testing.T) {
+		assert.NoError(t, repo.Ping(ctx))
+	})
+
+	t.Run("get missing key", func(t *testing.T) {
+		_, err := repo.Get(ctx, k("missing"))
+		assert.ErrorIs(t, err, NotFound)
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		assert.NoError(t, repo.Set(ctx, k("set"), "value", 0))
+		value, err := repo.Get(ctx, k("set"))
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("set with ttl expires", func(t *testing.T) {
+		assert.NoError(t, repo.Set(ctx, k("ttl"), "value", testTTL))
+		exists, err := repo.Exists(ctx, k("ttl"))
+		assert.NoError(t, err)
+		assert.True(t, exists)
+
+		time.Sleep(testTTL * 2)
+		_, err = repo.Get(ctx, k("ttl"))
+		assert.ErrorIs(t, err, NotFound)
+	})
+
+	t.Run("delete and exists", func(t *testing.T) {
+		assert.NoError(t, repo.Set(ctx, k("delete"), "value", 0))
+		deleted, err := repo.Delete(ctx, k("delete"))
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+
+		deleted, err = repo.Delete(ctx, k("delete"))
+		assert.NoError(t, err)
+		assert.False(t, deleted)
+
+		exists, err := repo.Exists(ctx, k("delete"))
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		found, err := repo.Expire(ctx, k("expire-missing"), time.Minute)
+		assert.NoError(t, err)
+		assert.False(t, found)
+
+		assert.NoError(t, repo.Set(ctx, k("expire"), "value", 0))
+		found, err = repo.Expire(ctx, k("expire"), testTTL)
+		assert.NoError(t, err)
+		assert.True(t, found)
+
+		time.Sleep(testTTL * 2)
+		exists, err := repo.Exists(ctx, k("expire"))
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("increment", func(t *testing.T) {
+		value, err := repo.Increment(ctx, k("counter"), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), value)
+
+		value, err = repo.Increment(ctx, k("counter"), 5)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), value)
+
+		stored, err := repo.Get(ctx, k("counter"))
+		assert.NoError(t, err)
+		assert.Equal(t, "6", stored)
+	})
+
+	t.Run("increment non integer", func(t *testing.T) {
+		assert.NoError(t, repo.Set(ctx, k("not-a-counter"), "abc", 0))
+		_, err := repo.Increment(ctx, k("not-a-counter"), 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("compare and set", func(t *testing.T) {
+		swapped, err := repo.CompareAndSet(ctx, k("cas"), "", "first", 0)
+		assert.NoError(t, err)
+		assert.True(t, swapped)
+
+		swapped, err = repo.CompareAndSet(ctx, k("cas"), "", "second", 0)
+		assert.NoError(t, err)
+		assert.False(t, swapped, "empty expected value requires a missing key")
+
+		swapped, err = repo.CompareAndSet(ctx, k("cas"), "wrong", "second", 0)
+		assert.NoError(t, err)
+		assert.False(t, swapped)
+
+		swapped, err = repo.CompareAndSet(ctx, k("cas"), "first", "second", 0)
+		assert.NoError(t, err)
+		assert.True(t, swapped)
+
+		value, err := repo.Get(ctx, k("cas"))
+		assert.NoError(t, err)
+		assert.Equal(t, "second", value)
+	})
+
+	t.Run("compare and set with ttl", func(t *testing.T) {
+		swapped, err := repo.CompareAndSet(ctx, k("cas-ttl"), "", "value", testTTL)
+		assert.NoError(t, err)
+		assert.True(t, swapped)
+
+		time.Sleep(testTTL * 2)
+		swapped, err = repo.CompareAndSet(ctx, k("cas-ttl"), "", "value", 0)
+		assert.NoError(t, err)
+		assert.True(t, swapped, "the key should have expired")
+	})
+
+	t.Run("lists", func(t *testing.T) {
+		length, err := repo.ListPush(ctx, k("list"), "b", false)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), length)
+		_, err = repo.ListPush(ctx, k("list"), "c", false)
+		assert.NoError(t, err)
+		length, err = repo.ListPush(ctx, k("list"), "a", true)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), length)
+
+		value, err := repo.ListPop(ctx, k("list"), true)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", value)
+		value, err = repo.ListPop(ctx, k("list"), false)
+		assert.NoError(t, err)
+		assert.Equal(t, "c", value)
+		value, err = repo.ListPop(ctx, k("list"), false)
+		assert.NoError(t, err)
+		assert.Equal(t, "b", value)
+
+		_, err = repo.ListPop(ctx, k("list"), true)
+		assert.ErrorIs(t, err, NotFound)
+		exists, err := repo.Exists(ctx, k("list"))
+		assert.NoError(t, err)
+		assert.False(t, exists, "empty lists are removed")
+	})
+
+	t.Run("hashes", func(t *testing.T) {
+		_, err := repo.HashGet(ctx, k("hash"), "field")
+		assert.ErrorIs(t, err, NotFound)
+
+		assert.NoError(t, repo.HashSet(ctx, k("hash"), "field", "value"))
+		value, err := repo.HashGet(ctx, k("hash"), "field")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+
+		_, err = repo.HashGet(ctx, k("hash"), "other")
+		assert.ErrorIs(t, err, NotFound)
+	})
+
+	t.Run("wrong kind of value", func(t *testing.T) {
+		assert.NoError(t, repo.Set(ctx, k("string"), "value", 0))
+		_, err := repo.ListPush(ctx, k("string"), "value", false)
+		assert.Error(t, err)
+		_, err = repo.HashGet(ctx, k("string"), "field")
+		assert.Error(t, err)
+	})
+}
+
+func TestMemoryRepository(t *testing.T) {
+	runConformanceSuite(t, NewMemoryRepository())
+}
+
+func TestFileRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "execution.json")
+	repo, err := NewFileRepository(path)
+	assert.NoError(t, err)
+
+	runConformanceSuite(t, repo)
+}
+
+func TestFileRepository_PersistsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "execution.json")
+	repo, err := NewFileRepository(path)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Set(ctx, "key", "value", 0))
+	assert.NoError(t, repo.HashSet(ctx, "hash", "field", "value"))
+
+	reopened, err := NewFileRepository(path)
+	assert.NoError(t, err)
+
+	value, err := reopened.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	value, err = reopened.HashGet(ctx, "hash", "field")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+// TestRedisRepository needs a running redis, e.g. TASKER_TEST_REDIS_ADDR=localhost:6379 from the docker compose
+func TestRedisRepository(t *testing.T) {
+	addr := os.Getenv("TASKER_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TASKER_TEST_REDIS_ADDR not set")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("connecting to redis: %s", err)
+	}
+
+	runConformanceSuite(t, NewRepository(client))
+}
@@ -0,0 +1,61 @@
+package executionDB
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// NewFileRepository creates an execution storage kept in memory and persisted as a JSON file on every change, so
+// single node setups keep their keys across restarts without running redis
+func NewFileRepository(path string) (Repository, error) {
+	entries := map[string]*memoryEntry{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading execution storage file: %w", err)
+	default:
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("execution storage file %s is corrupted: %w", path, err)
+		}
+	}
+
+	return &memoryRepository{
+		entries: entries,
+		now:     time.Now,
+		persist: func(entries map[string]*memoryEntry) error {
+			return writeFileAtomic(path, entries)
+		},
+	}, nil
+}
+
+// writeFileAtomic writes to a temporary file and renames it, a crash can't leave a half written storage
+func writeFileAtomic(path string, entries map[string]*memoryEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -3,6 +3,7 @@ package executionDB
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -13,12 +14,12 @@ var (
 
 func (r repository) Get(ctx context.Context, key string) (string, error) {
 	result, err := r.db.Get(ctx, key).Result()
-	if errors.As(err, redis.Nil) {
+	if errors.Is(err, redis.Nil) {
 		return "", NotFound
 	}
 	return result, err
 }
 
-func (r repository) Set(ctx context.Context, key, value string) error {
-	return r.db.Set(ctx, key, value, 0).Err()
+func (r repository) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return r.db.Set(ctx, key, value, ttl).Err()
 }
@@ -0,0 +1,45 @@
+package executionDB
+
+import (
+	"context"
+	"time"
+)
+
+// compareAndSetScript swaps the value only if it still holds the expected one, ARGV[3] is the ttl in milliseconds
+const compareAndSetScript = `
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+end
+return 1`
+
+func (r repository) Delete(ctx context.Context, key string) (bool, error) {
+	deleted, err := r.db.Del(ctx, key).Result()
+	return deleted > 0, err
+}
+
+func (r repository) Exists(ctx context.Context, key string) (bool, error) {
+	found, err := r.db.Exists(ctx, key).Result()
+	return found > 0, err
+}
+
+func (r repository) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.db.Expire(ctx, key, ttl).Result()
+}
+
+func (r repository) Increment(ctx context.Context, key string, by int64) (int64, error) {
+	return r.db.IncrBy(ctx, key, by).Result()
+}
+
+func (r repository) CompareAndSet(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error) {
+	if expected == "" {
+		return r.db.SetNX(ctx, key, value, ttl).Result()
+	}
+
+	swapped, err := r.db.Eval(ctx, compareAndSetScript, []string{key}, expected, value, ttl.Milliseconds()).Int()
+	return swapped == 1, err
+}
@@ -0,0 +1,86 @@
+package executionDB
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tasker/entities"
+)
+
+// unmovedKeyPrefixes are the keys already namespaced when the workspaces came: the workspace keys themselves and the
+// outbound limits sharing the redis
+var unmovedKeyPrefixes = []string{"workspace:", "tasker:"}
+
+// legacyKeysMover is implemented by the backends that can go through their keys
+type legacyKeysMover interface {
+	moveLegacyKeys(ctx context.Context) (int, error)
+}
+
+// MoveLegacyKeys moves the keys written before the workspaces existed under the default workspace, so the global scope
+// of its steps finds them again. A key whose target already exists is left where it is. It returns how many keys were
+// moved, it's meant to be run once on a storage no other application writes to
+func MoveLegacyKeys(ctx context.Context, repo Repository) (int, error) {
+	mover, ok := repo.(legacyKeysMover)
+	if !ok {
+		return 0, fmt.Errorf("the execution storage can't list its keys")
+	}
+	return mover.moveLegacyKeys(ctx)
+}
+
+func isLegacyKey(key string) bool {
+	for _, prefix := range unmovedKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func legacyKeyTarget(key string) string {
+	return workspaceKey(entities.DefaultWorkspaceID, key)
+}
+
+func (r repository) moveLegacyKeys(ctx context.Context) (int, error) {
+	moved := 0
+	//The moved keys get the workspace prefix, the scan skips them if it sees them again
+	iter := r.db.Scan(ctx, 0, "*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if !isLegacyKey(key) {
+			continue
+		}
+		renamed, err := r.db.RenameNX(ctx, key, legacyKeyTarget(key)).Result()
+		if err != nil {
+			return moved, fmt.Errorf("moving key %s: %w", key, err)
+		}
+		if renamed {
+			moved++
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return moved, fmt.Errorf("listing keys: %w", err)
+	}
+	return moved, nil
+}
+
+func (m *memoryRepository) moveLegacyKeys(_ context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	moved := 0
+	for key := range m.entries {
+		entry := m.get(key)
+		target := legacyKeyTarget(key)
+		if entry == nil || !isLegacyKey(key) || m.get(target) != nil {
+			continue
+		}
+		m.entries[target] = entry
+		delete(m.entries, key)
+		moved++
+	}
+	if moved == 0 {
+		return 0, nil
+	}
+	return moved, m.changed()
+}
@@ -0,0 +1,53 @@
+package executionDB
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveLegacyKeys(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	repo, err := NewFileRepository(path)
+	require.NoError(t, err)
+	require.NoError(t, repo.Set(ctx, "token", "legacy", 0))
+	_, err = repo.ListPush(ctx, "queue", "job", false)
+	require.NoError(t, err)
+	require.NoError(t, repo.Set(ctx, "kept", "legacy", 0))
+	require.NoError(t, repo.Set(ctx, "workspace:1:kept", "current", 0))
+	require.NoError(t, repo.Set(ctx, "workspace:2:token", "other", 0))
+
+	moved, err := MoveLegacyKeys(ctx, repo)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, moved)
+	//The steps of the default workspace find the moved keys on the global scope
+	scoped := NewScopedRepository(repo)
+	token, err := scoped.Get(ctx, "token")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy", token)
+	job, err := scoped.ListPop(ctx, "queue", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "job", job)
+	//A target that already exists is never overwritten
+	kept, err := scoped.Get(ctx, "kept")
+	assert.NoError(t, err)
+	assert.Equal(t, "current", kept)
+	legacy, err := repo.Get(ctx, "kept")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy", legacy)
+
+	//The move is persisted, and running it again moves nothing
+	reopened, err := NewFileRepository(path)
+	require.NoError(t, err)
+	exists, err := reopened.Exists(ctx, "token")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	moved, err = MoveLegacyKeys(ctx, reopened)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, moved)
+}
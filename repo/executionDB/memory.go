@@ -0,0 +1,280 @@
+package executionDB
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var errWrongType = errors.New("operation against a key holding the wrong kind of value")
+
+type entryKind string
+
+const (
+	stringKind entryKind = "string"
+	listKind   entryKind = "list"
+	hashKind   entryKind = "hash"
+)
+
+// memoryEntry is a key of the in-memory storage, exported fields are the ones persisted by the file storage
+type memoryEntry struct {
+	Kind      entryKind         `json:"kind"`
+	Value     string            `json:"value,omitempty"`
+	List      []string          `json:"list,omitempty"`
+	Hash      map[string]string `json:"hash,omitempty"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty"`
+}
+
+// sweepInterval is how often the expired keys that were never read again are removed
+const sweepInterval = time.Minute
+
+type memoryRepository struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	now       func() time.Time
+	lastSweep time.Time
+	// persist is called with the lock held after every change, it is only set by the file storage
+	persist func(entries map[string]*memoryEntry) error
+}
+
+// NewMemoryRepository creates an execution storage that lives in the process memory, meant for local development and
+// tests. Keys are lost when the process stops
+func NewMemoryRepository() Repository {
+	return &memoryRepository{entries: map[string]*memoryEntry{}, now: time.Now}
+}
+
+// get returns the live entry of the key, removing it if it expired
+func (m *memoryRepository) get(key string) *memoryEntry {
+	entry, found := m.entries[key]
+	if !found {
+		return nil
+	}
+	if entry.ExpiresAt != nil && !m.now().Before(*entry.ExpiresAt) {
+		delete(m.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// getKind returns the live entry of the key, failing if it holds another kind of value
+func (m *memoryRepository) getKind(key string, kind entryKind) (*memoryEntry, error) {
+	entry := m.get(key)
+	if entry != nil && entry.Kind != kind {
+		return nil, errWrongType
+	}
+	return entry, nil
+}
+
+func (m *memoryRepository) expiration(ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	expiresAt := m.now().Add(ttl)
+	return &expiresAt
+}
+
+// changed sweeps the expired keys from time to time and persists the entries if needed
+func (m *memoryRepository) changed() error {
+	if now := m.now(); now.Sub(m.lastSweep) > sweepInterval {
+		for key := range m.entries {
+			m.get(key)
+		}
+		m.lastSweep = now
+	}
+
+	if m.persist == nil {
+		return nil
+	}
+	if err := m.persist(m.entries); err != nil {
+		return fmt.Errorf("persisting execution storage: %w", err)
+	}
+	return nil
+}
+
+func (m *memoryRepository) Get(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.getKind(key, stringKind)
+	switch {
+	case err != nil:
+		return "", err
+	case entry == nil:
+		return "", NotFound
+	}
+	return entry.Value, nil
+}
+
+func (m *memoryRepository) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = &memoryEntry{Kind: stringKind, Value: value, ExpiresAt: m.expiration(ttl)}
+	return m.changed()
+}
+
+func (m *memoryRepository) Delete(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.get(key) == nil {
+		return false, nil
+	}
+	delete(m.entries, key)
+	return true, m.changed()
+}
+
+func (m *memoryRepository) Exists(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.get(key) != nil, nil
+}
+
+func (m *memoryRepository) Expire(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.get(key)
+	if entry == nil {
+		return false, nil
+	}
+	//Same as redis, a non positive ttl expires the key right away
+	if ttl <= 0 {
+		delete(m.entries, key)
+	} else {
+		entry.ExpiresAt = m.expiration(ttl)
+	}
+	return true, m.changed()
+}
+
+func (m *memoryRepository) Increment(_ context.Context, key string, by int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.getKind(key, stringKind)
+	if err != nil {
+		return 0, err
+	}
+	if entry == nil {
+		entry = &memoryEntry{Kind: stringKind, Value: "0"}
+		m.entries[key] = entry
+	}
+
+	current, err := strconv.ParseInt(entry.Value, 10, 64)
+	if err != nil {
+		return 0, errors.New("value is not an integer")
+	}
+	entry.Value = strconv.FormatInt(current+by, 10)
+	return current + by, m.changed()
+}
+
+func (m *memoryRepository) CompareAndSet(_ context.Context, key, expected, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.get(key)
+	switch {
+	case expected == "" && entry != nil:
+		return false, nil
+	case expected == "":
+		m.entries[key] = &memoryEntry{Kind: stringKind, Value: value, ExpiresAt: m.expiration(ttl)}
+		return true, m.changed()
+	case entry == nil || entry.Kind != stringKind || entry.Value != expected:
+		return false, nil
+	}
+
+	entry.Value = value
+	if ttl > 0 {
+		entry.ExpiresAt = m.expiration(ttl)
+	}
+	return true, m.changed()
+}
+
+func (m *memoryRepository) ListPush(_ context.Context, key, value string, front bool) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.getKind(key, listKind)
+	if err != nil {
+		return 0, err
+	}
+	if entry == nil {
+		entry = &memoryEntry{Kind: listKind}
+		m.entries[key] = entry
+	}
+
+	if front {
+		entry.List = append([]string{value}, entry.List...)
+	} else {
+		entry.List = append(entry.List, value)
+	}
+	return int64(len(entry.List)), m.changed()
+}
+
+func (m *memoryRepository) ListPop(_ context.Context, key string, front bool) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.getKind(key, listKind)
+	switch {
+	case err != nil:
+		return "", err
+	case entry == nil || len(entry.List) == 0:
+		return "", NotFound
+	}
+
+	var value string
+	if front {
+		value, entry.List = entry.List[0], entry.List[1:]
+	} else {
+		value, entry.List = entry.List[len(entry.List)-1], entry.List[:len(entry.List)-1]
+	}
+	//Same as redis, empty lists don't exist
+	if len(entry.List) == 0 {
+		delete(m.entries, key)
+	}
+	return value, m.changed()
+}
+
+func (m *memoryRepository) HashGet(_ context.Context, key, field string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.getKind(key, hashKind)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return "", NotFound
+	}
+	value, found := entry.Hash[field]
+	if !found {
+		return "", NotFound
+	}
+	return value, nil
+}
+
+func (m *memoryRepository) HashSet(_ context.Context, key, field, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.getKind(key, hashKind)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		entry = &memoryEntry{Kind: hashKind, Hash: map[string]string{}}
+		m.entries[key] = entry
+	}
+	entry.Hash[field] = value
+	return m.changed()
+}
+
+// Ping always succeeds, the memory storage can't be unreachable
+func (m *memoryRepository) Ping(ctx context.Context) error {
+	return nil
+}
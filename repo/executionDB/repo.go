@@ -9,12 +9,46 @@ import (
 
 type Repository interface {
 	Get(ctx context.Context, key string) (string, error)
-	Set(ctx context.Context, key, value string) error
+	// Set writes the value, a ttl of 0 keeps the key forever
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// Delete returns false if the key didn't exist
+	Delete(ctx context.Context, key string) (bool, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	// Expire returns false if the key didn't exist
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Increment atomically adds by to the integer value of the key, missing keys start at 0
+	Increment(ctx context.Context, key string, by int64) (int64, error)
+	// CompareAndSet atomically writes the value only if the current one is the expected, an empty expected value
+	// means the key must not exist. Returns false if nothing was written
+	CompareAndSet(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error)
+	// ListPush adds the value to the front (left) or back of the list and returns its new length
+	ListPush(ctx context.Context, key, value string, front bool) (int64, error)
+	// ListPop removes and returns the value on the front (left) or back of the list
+	ListPop(ctx context.Context, key string, front bool) (string, error)
+	HashGet(ctx context.Context, key, field string) (string, error)
+	HashSet(ctx context.Context, key, field, value string) error
+	// Ping checks the storage is reachable
+	Ping(ctx context.Context) error
 }
 
 type DB interface {
 	Get(ctx context.Context, key string) *redis.StringCmd
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Exists(ctx context.Context, keys ...string) *redis.IntCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+	IncrBy(ctx context.Context, key string, value int64) *redis.IntCmd
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+	LPush(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
+	RPush(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
+	LPop(ctx context.Context, key string) *redis.StringCmd
+	RPop(ctx context.Context, key string) *redis.StringCmd
+	HGet(ctx context.Context, key, field string) *redis.StringCmd
+	HSet(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
+	RenameNX(ctx context.Context, key, newkey string) *redis.BoolCmd
+	Ping(ctx context.Context) *redis.StatusCmd
 }
 
 type repository struct {
@@ -24,3 +58,7 @@ type repository struct {
 func NewRepository(db DB) Repository {
 	return &repository{db: db}
 }
+
+func (r repository) Ping(ctx context.Context) error {
+	return r.db.Ping(ctx).Err()
+}
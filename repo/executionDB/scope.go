@@ -0,0 +1,226 @@
+package executionDB
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tasker/service"
+)
+
+// executionKeysTTL is a safety net for execution scoped keys, in case the execution never gets to clean them
+const executionKeysTTL = time.Hour * 24
+
+// reservedKeyPrefixes namespace the keys of the other scopes, a global key starting with them would reach their keys
+var reservedKeyPrefixes = []string{"task:", "schedule:", "execution:"}
+
+// ScopedRepository prefixes every key with the workspace and the storage scope found on the context
+type ScopedRepository interface {
+	Repository
+	// CleanExecution deletes every execution scoped key written by the execution
+	CleanExecution(ctx context.Context, exec service.ExecutionInfo) error
+}
+
+type scopedRepository struct {
+	repo Repository
+}
+
+// NewScopedRepository wraps any execution storage backend adding the storage scopes on top of it
+func NewScopedRepository(repo Repository) ScopedRepository {
+	return &scopedRepository{repo: repo}
+}
+
+func (s scopedRepository) scopedKey(ctx context.Context, key string) (string, error) {
+	scope := service.StorageScopeFromContext(ctx)
+	if scope == service.GlobalStorageScope {
+		for _, prefix := range reservedKeyPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				return "", fmt.Errorf("global storage keys can't start with %s, it's reserved for the scoped keys", prefix)
+			}
+		}
+		workspaceID, _ := service.WorkspaceFromContext(ctx)
+		return workspaceKey(workspaceID, key), nil
+	}
+
+	exec, found := service.ExecutionInfoFromContext(ctx)
+	if !found {
+		return "", fmt.Errorf("%s storage scope can only be used inside an execution", scope)
+	}
+
+	switch scope {
+	case service.TaskStorageScope:
+		return workspaceKey(exec.WorkspaceID, fmt.Sprintf("task:%d:%s", exec.TaskID, key)), nil
+	case service.ScheduleStorageScope:
+		return workspaceKey(exec.WorkspaceID, fmt.Sprintf("schedule:%d:%s", exec.ScheduleID, key)), nil
+	case service.ExecutionStorageScope:
+		return fmt.Sprintf("%s:%s", executionIndexKey(exec), key), nil
+	default:
+		return "", fmt.Errorf("unknown storage scope %s", scope)
+	}
+}
+
+// workspaceKey prefixes every key with its workspace, so the workspaces never share keys, not even the global ones
+func workspaceKey(workspaceID int, key string) string {
+	return fmt.Sprintf("workspace:%d:%s", workspaceID, key)
+}
+
+// tokenEscaper escapes the delimiter on the idempotency tokens, a token holding one would reach the keys of another
+// execution. The uuid tokens are left as they are
+var tokenEscaper = strings.NewReplacer(`\`, `\\`, ":", `\:`)
+
+// executionIndexKey holds the list of keys written by the execution
+func executionIndexKey(exec service.ExecutionInfo) string {
+	return workspaceKey(exec.WorkspaceID, "execution:"+tokenEscaper.Replace(exec.Token))
+}
+
+// track registers the written key on the execution index, so it gets deleted when the execution ends. keepTTL is
+// set when the write already defined the key ttl
+func (s scopedRepository) track(ctx context.Context, key string, keepTTL bool) error {
+	if service.StorageScopeFromContext(ctx) != service.ExecutionStorageScope {
+		return nil
+	}
+
+	exec, _ := service.ExecutionInfoFromContext(ctx)
+	index := executionIndexKey(exec)
+	if _, err := s.repo.ListPush(ctx, index, key, false); err != nil {
+		return fmt.Errorf("tracking execution key: %w", err)
+	}
+	if _, err := s.repo.Expire(ctx, index, executionKeysTTL); err != nil {
+		return fmt.Errorf("tracking execution key: %w", err)
+	}
+	if !keepTTL {
+		if _, err := s.repo.Expire(ctx, key, executionKeysTTL); err != nil {
+			return fmt.Errorf("tracking execution key: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s scopedRepository) CleanExecution(ctx context.Context, exec service.ExecutionInfo) error {
+	index := executionIndexKey(exec)
+	for {
+		key, err := s.repo.ListPop(ctx, index, true)
+		if errors.Is(err, NotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading execution keys: %w", err)
+		}
+
+		if _, err := s.repo.Delete(ctx, key); err != nil {
+			return fmt.Errorf("deleting execution key %s: %w", key, err)
+		}
+	}
+}
+
+func (s scopedRepository) Ping(ctx context.Context) error {
+	return s.repo.Ping(ctx)
+}
+
+func (s scopedRepository) Get(ctx context.Context, key string) (string, error) {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return s.repo.Get(ctx, key)
+}
+
+func (s scopedRepository) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	return s.track(ctx, key, ttl > 0)
+}
+
+func (s scopedRepository) Delete(ctx context.Context, key string) (bool, error) {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return s.repo.Delete(ctx, key)
+}
+
+func (s scopedRepository) Exists(ctx context.Context, key string) (bool, error) {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return s.repo.Exists(ctx, key)
+}
+
+func (s scopedRepository) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return s.repo.Expire(ctx, key, ttl)
+}
+
+func (s scopedRepository) Increment(ctx context.Context, key string, by int64) (int64, error) {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	result, err := s.repo.Increment(ctx, key, by)
+	if err != nil {
+		return 0, err
+	}
+	return result, s.track(ctx, key, false)
+}
+
+func (s scopedRepository) CompareAndSet(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error) {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	swapped, err := s.repo.CompareAndSet(ctx, key, expected, value, ttl)
+	if err != nil || !swapped {
+		return swapped, err
+	}
+	return true, s.track(ctx, key, ttl > 0)
+}
+
+func (s scopedRepository) ListPush(ctx context.Context, key, value string, front bool) (int64, error) {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	length, err := s.repo.ListPush(ctx, key, value, front)
+	if err != nil {
+		return 0, err
+	}
+	return length, s.track(ctx, key, false)
+}
+
+func (s scopedRepository) ListPop(ctx context.Context, key string, front bool) (string, error) {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return s.repo.ListPop(ctx, key, front)
+}
+
+func (s scopedRepository) HashGet(ctx context.Context, key, field string) (string, error) {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return s.repo.HashGet(ctx, key, field)
+}
+
+func (s scopedRepository) HashSet(ctx context.Context, key, field, value string) error {
+	key, err := s.scopedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.HashSet(ctx, key, field, value); err != nil {
+		return err
+	}
+	return s.track(ctx, key, false)
+}
@@ -0,0 +1,174 @@
+package executionDB
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/service"
+)
+
+type mockRepository struct {
+	mock.Mock
+}
+
+func (m *mockRepository) Ping(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockRepository) Get(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRepository) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return m.Called(ctx, key, value, ttl).Error(0)
+}
+
+func (m *mockRepository) Delete(ctx context.Context, key string) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) Exists(ctx context.Context, key string) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, key, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) Increment(ctx context.Context, key string, by int64) (int64, error) {
+	args := m.Called(ctx, key, by)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepository) CompareAndSet(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, key, expected, value, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) ListPush(ctx context.Context, key, value string, front bool) (int64, error) {
+	args := m.Called(ctx, key, value, front)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepository) ListPop(ctx context.Context, key string, front bool) (string, error) {
+	args := m.Called(ctx, key, front)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRepository) HashGet(ctx context.Context, key, field string) (string, error) {
+	args := m.Called(ctx, key, field)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRepository) HashSet(ctx context.Context, key, field, value string) error {
+	return m.Called(ctx, key, field, value).Error(0)
+}
+
+var testExecution = service.ExecutionInfo{WorkspaceID: 2, TaskID: 3, ScheduleID: 5, Token: "token"}
+
+func scopedContext(t *testing.T, scope service.StorageScope) context.Context {
+	ctx := service.ContextWithWorkspace(context.Background(), testExecution.WorkspaceID)
+	ctx = service.ContextWithExecutionInfo(ctx, testExecution)
+	ctx, err := service.ContextWithStorageScopeParam(ctx, map[string]string{service.StorageScopeParam: string(scope)})
+	assert.NoError(t, err)
+	return ctx
+}
+
+func TestScopedRepository_GlobalKeysArePrefixedWithTheWorkspace(t *testing.T) {
+	repo := &mockRepository{}
+	repo.On("Get", mock.Anything, "workspace:1:token").Return("default-value", nil)
+	repo.On("Get", mock.Anything, "workspace:2:token").Return("value", nil)
+	scoped := NewScopedRepository(repo)
+
+	defaultValue, err := scoped.Get(context.Background(), "token")
+	assert.NoError(t, err)
+	value, err := scoped.Get(service.ContextWithWorkspace(context.Background(), 2), "token")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "default-value", defaultValue)
+	assert.Equal(t, "value", value)
+	repo.AssertExpectations(t)
+}
+
+func TestScopedRepository_GlobalKeysCantReachOtherScopes(t *testing.T) {
+	scoped := NewScopedRepository(&mockRepository{})
+
+	for _, key := range []string{"task:3:token", "schedule:5:token", "execution:token:tmp", "execution:token"} {
+		_, err := scoped.Get(scopedContext(t, service.GlobalStorageScope), key)
+
+		assert.Error(t, err, key)
+	}
+}
+
+func TestScopedRepository_ScopeOutsideExecution(t *testing.T) {
+	ctx, err := service.ContextWithStorageScopeParam(context.Background(), map[string]string{service.StorageScopeParam: "task"})
+	assert.NoError(t, err)
+
+	_, err = NewScopedRepository(&mockRepository{}).Get(ctx, "token")
+
+	assert.EqualError(t, err, "task storage scope can only be used inside an execution")
+}
+
+func TestScopedRepository_TaskAndScheduleKeys(t *testing.T) {
+	repo := &mockRepository{}
+	repo.On("Get", mock.Anything, "workspace:2:task:3:token").Return("task-value", nil)
+	repo.On("Get", mock.Anything, "workspace:2:schedule:5:token").Return("schedule-value", nil)
+	scoped := NewScopedRepository(repo)
+
+	taskValue, err := scoped.Get(scopedContext(t, service.TaskStorageScope), "token")
+	assert.NoError(t, err)
+	scheduleValue, err := scoped.Get(scopedContext(t, service.ScheduleStorageScope), "token")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "task-value", taskValue)
+	assert.Equal(t, "schedule-value", scheduleValue)
+	repo.AssertExpectations(t)
+}
+
+func TestScopedRepository_ExecutionKeysAreTrackedAndCleaned(t *testing.T) {
+	repo := &mockRepository{}
+	repo.On("Set", mock.Anything, "workspace:2:execution:token:tmp", "value", time.Duration(0)).Return(nil)
+	repo.On("ListPush", mock.Anything, "workspace:2:execution:token", "workspace:2:execution:token:tmp", false).Return(int64(1), nil)
+	repo.On("Expire", mock.Anything, "workspace:2:execution:token", executionKeysTTL).Return(true, nil)
+	repo.On("Expire", mock.Anything, "workspace:2:execution:token:tmp", executionKeysTTL).Return(true, nil)
+	repo.On("ListPop", mock.Anything, "workspace:2:execution:token", true).Return("workspace:2:execution:token:tmp", nil).Once()
+	repo.On("ListPop", mock.Anything, "workspace:2:execution:token", true).Return("", NotFound).Once()
+	repo.On("Delete", mock.Anything, "workspace:2:execution:token:tmp").Return(true, nil)
+	scoped := NewScopedRepository(repo)
+
+	err := scoped.Set(scopedContext(t, service.ExecutionStorageScope), "tmp", "value", 0)
+	assert.NoError(t, err)
+	err = scoped.CleanExecution(context.Background(), testExecution)
+	assert.NoError(t, err)
+
+	repo.AssertExpectations(t)
+}
+
+func TestScopedRepository_ExecutionTokensCantReachOtherExecutions(t *testing.T) {
+	repo := &mockRepository{}
+	repo.On("Get", mock.Anything, `workspace:2:execution:a\:b:c`).Return("value of a:b", nil)
+	repo.On("Get", mock.Anything, "workspace:2:execution:a:b:c").Return("value of a", nil)
+	scoped := NewScopedRepository(repo)
+	tokenContext := func(token string) context.Context {
+		ctx := service.ContextWithExecutionInfo(context.Background(), service.ExecutionInfo{WorkspaceID: 2, Token: token})
+		ctx, err := service.ContextWithStorageScopeParam(ctx, map[string]string{service.StorageScopeParam: string(service.ExecutionStorageScope)})
+		assert.NoError(t, err)
+		return ctx
+	}
+
+	withDelimiter, err := scoped.Get(tokenContext("a:b"), "c")
+	assert.NoError(t, err)
+	other, err := scoped.Get(tokenContext("a"), "b:c")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "value of a:b", withDelimiter)
+	assert.Equal(t, "value of a", other)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,132 @@
+package mgmtDB
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+)
+
+const (
+	InsertAPIKeyQr = "INSERT INTO api_key (workspace_id, name, key_prefix, key_hash, scopes, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	GetAPIKeysQr   = "SELECT id, workspace_id, name, key_prefix, scopes, created_at, revoked_at FROM api_key"
+	RevokeAPIKeyQr = "UPDATE api_key SET revoked_at = ? WHERE id = ? AND workspace_id = ? AND revoked_at IS NULL"
+)
+
+// SaveAPIKey saves the key bound to the context workspace, only its hash is stored
+func (r repository) SaveAPIKey(ctx context.Context, key entities.APIKey, keyHash string) (entities.APIKey, error) {
+	key.CreatedAt = key.CreatedAt.UTC()
+	key.WorkspaceID = workspaceID(ctx)
+	result, err := r.db.InsertContext(ctx, InsertAPIKeyQr, key.WorkspaceID, key.Name, key.Prefix, keyHash, joinScopes(key.Scopes), key.CreatedAt)
+	if err != nil {
+		return entities.APIKey{}, fmt.Errorf("inserting api key: %w", err)
+	}
+
+	keyID, err := result.LastInsertId()
+	if err != nil {
+		return entities.APIKey{}, err
+	}
+
+	key.ID = int(keyID)
+	return key, nil
+}
+
+func (r repository) GetAPIKey(ctx context.Context, keyID int) (entities.APIKey, error) {
+	return r.getAPIKey(ctx, GetAPIKeysQr+" WHERE id = ? AND workspace_id = ?", keyID, workspaceID(ctx))
+}
+
+// GetAPIKeyByHash returns the key, revoked or not, whose hash is keyHash. It looks on every workspace, the key tells
+// the workspace of the request
+func (r repository) GetAPIKeyByHash(ctx context.Context, keyHash string) (entities.APIKey, error) {
+	return r.getAPIKey(ctx, GetAPIKeysQr+" WHERE key_hash = ?", keyHash)
+}
+
+func (r repository) getAPIKey(ctx context.Context, query string, args ...any) (entities.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, args...))
+	switch {
+	case err == sql.ErrNoRows:
+		return entities.APIKey{}, http.WrapError(err, http.ErrNotFound.WithMessage("api key not found"))
+	case err != nil:
+		return entities.APIKey{}, fmt.Errorf("getting api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r repository) GetAPIKeys(ctx context.Context) ([]entities.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, GetAPIKeysQr+" WHERE workspace_id = ? ORDER BY id", workspaceID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("getting api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []entities.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r repository) RevokeAPIKey(ctx context.Context, keyID int, revokedAt time.Time) error {
+	result, err := r.db.ExecContext(ctx, RevokeAPIKeyQr, revokedAt.UTC(), keyID, workspaceID(ctx))
+	if err != nil {
+		return fmt.Errorf("revoking api key: %w", err)
+	}
+
+	rAffect, err := result.RowsAffected()
+	switch {
+	case err != nil:
+		return err
+	case rAffect == 0:
+		return http.WrapError(fmt.Errorf("api key %d not found or already revoked", keyID), http.ErrNotFound.WithMessage("api key not found"))
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (entities.APIKey, error) {
+	var key entities.APIKey
+	var scopes string
+	var createdAt, revokedAt dbTime
+	if err := row.Scan(&key.ID, &key.WorkspaceID, &key.Name, &key.Prefix, &scopes, &createdAt, &revokedAt); err != nil {
+		return entities.APIKey{}, err
+	}
+
+	if createdAt.Time != nil {
+		key.CreatedAt = createdAt.Time.UTC()
+	}
+	if revokedAt.Time != nil {
+		revoked := revokedAt.Time.UTC()
+		key.RevokedAt = &revoked
+	}
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope != "" {
+			key.Scopes = append(key.Scopes, entities.Scope(scope))
+		}
+	}
+	return key, nil
+}
+
+func joinScopes(scopes []entities.Scope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, ",")
+}
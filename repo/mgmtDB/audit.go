@@ -0,0 +1,97 @@
+package mgmtDB
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tasker/entities"
+)
+
+const (
+	InsertAuditEventQr = "INSERT INTO audit_event (workspace_id, occurred_at, actor, action, entity_type, entity_id, before_state, after_state, diff) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	GetAuditEventsQr   = "SELECT id, occurred_at, actor, action, entity_type, entity_id, before_state, after_state, diff FROM audit_event"
+)
+
+func (r repository) SaveAuditEvent(ctx context.Context, event entities.AuditEvent) error {
+	_, err := r.db.ExecContext(ctx, InsertAuditEventQr, workspaceID(ctx), event.Time.UTC(), event.Actor, event.Action, event.EntityType,
+		event.EntityID, nullableJSON(event.Before), nullableJSON(event.After), nullableJSON(event.Diff))
+	if err != nil {
+		return fmt.Errorf("inserting audit event: %w", err)
+	}
+
+	return nil
+}
+
+// GetAuditEvents returns the events matching the filter, newest first
+func (r repository) GetAuditEvents(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error) {
+	conditions := []string{"workspace_id = ?"}
+	args := []any{workspaceID(ctx)}
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "occurred_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+
+	query := GetAuditEventsQr + " WHERE " + strings.Join(conditions, " AND ")
+	query += " ORDER BY occurred_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("getting audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []entities.AuditEvent{}
+	for rows.Next() {
+		var event entities.AuditEvent
+		var occurredAt dbTime
+		var before, after, diff sql.NullString
+		err := rows.Scan(&event.ID, &occurredAt, &event.Actor, &event.Action, &event.EntityType, &event.EntityID, &before, &after, &diff)
+		if err != nil {
+			return nil, fmt.Errorf("scanning audit event: %w", err)
+		}
+
+		if occurredAt.Time != nil {
+			event.Time = occurredAt.Time.UTC()
+		}
+		if before.Valid {
+			event.Before = []byte(before.String)
+		}
+		if after.Valid {
+			event.After = []byte(after.String)
+		}
+		if diff.Valid {
+			event.Diff = []byte(diff.String)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit events: %w", err)
+	}
+
+	return events, nil
+}
+
+// nullableJSON stores the missing JSON documents as NULL
+func nullableJSON(document []byte) any {
+	if len(document) == 0 {
+		return nil
+	}
+	return string(document)
+}
@@ -0,0 +1,71 @@
+package mgmtDB
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/entities"
+)
+
+func TestGetAuditEvents_Filter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRepositoryWithDialect(db, PostgreSQL)
+
+	from := time.Date(2023, 7, 1, 10, 30, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "occurred_at", "actor", "action", "entity_type", "entity_id", "before_state", "after_state", "diff"}).
+		AddRow(3, from, "alice", "update", "schedule", "7", `{"enabled":true}`, `{"enabled":false}`, `{"enabled":{"before":true,"after":false}}`)
+	mock.ExpectQuery("^SELECT .* FROM audit_event WHERE workspace_id = \\$1 AND entity_type = \\$2 AND actor = \\$3 AND occurred_at >= \\$4 ORDER BY occurred_at DESC, id DESC LIMIT 10$").
+		WithArgs(entities.DefaultWorkspaceID, "schedule", "alice", from).WillReturnRows(rows)
+
+	events, err := repo.GetAuditEvents(context.Background(), entities.AuditFilter{EntityType: "schedule", Actor: "alice", From: from, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.AuditEvent{{
+		ID:         3,
+		Time:       from,
+		Actor:      "alice",
+		Action:     entities.UpdateAuditAction,
+		EntityType: entities.ScheduleAuditEntity,
+		EntityID:   "7",
+		Before:     []byte(`{"enabled":true}`),
+		After:      []byte(`{"enabled":false}`),
+		Diff:       []byte(`{"enabled":{"before":true,"after":false}}`),
+	}}, events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveAuditEvent_NullStates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRepository(db)
+
+	occurredAt := time.Date(2023, 7, 1, 10, 30, 0, 0, time.UTC)
+	mock.ExpectExec("INSERT INTO audit_event").
+		WithArgs(entities.DefaultWorkspaceID, occurredAt, "alice", entities.CreateAuditAction, entities.TaskAuditEntity, "1", nil, `{"name":"a"}`, `{"name":{"after":"a"}}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.SaveAuditEvent(context.Background(), entities.AuditEvent{
+		Time:       occurredAt,
+		Actor:      "alice",
+		Action:     entities.CreateAuditAction,
+		EntityType: entities.TaskAuditEntity,
+		EntityID:   "1",
+		After:      []byte(`{"name":"a"}`),
+		Diff:       []byte(`{"name":{"after":"a"}}`),
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,115 @@
+package mgmtDB
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect holds the differences between the SQL databases the management storage can run on. Queries are written
+// with ? placeholders and rebound to the dialect ones
+type Dialect struct {
+	name       string
+	driverName string
+	// numberedPlaceholders uses $1, $2... instead of ?
+	numberedPlaceholders bool
+	// returningID gets the id of the inserted rows with a RETURNING clause, for drivers without LastInsertId
+	returningID bool
+	// timestampType is the column type for dates
+	timestampType string
+	// lockQr and unlockQr hold a lock across instances while migrating, dialects without them rely on transactional DDL.
+	// lockQr returns whether it got the lock, as a boolean or 1 and 0, and is retried while it doesn't
+	lockQr, unlockQr string
+	// upsertFmt is appended to an insert to update the %[2]s columns on conflict with the %[1]s key, a comma separated
+	// column list
+	upsertFmt func(conflictColumn string, updateColumns []string) string
+}
+
+var (
+	MySQL = Dialect{
+		name:          "mysql",
+		driverName:    "mysql",
+		timestampType: "DATETIME",
+		lockQr:        "SELECT GET_LOCK('tasker_schema_migrations', 60)",
+		unlockQr:      "SELECT RELEASE_LOCK('tasker_schema_migrations')",
+		upsertFmt: func(_ string, updateColumns []string) string {
+			sets := make([]string, len(updateColumns))
+			for i, column := range updateColumns {
+				sets[i] = fmt.Sprintf("%s = VALUES(%s)", column, column)
+			}
+			return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+		},
+	}
+	SQLite = Dialect{
+		name:          "sqlite",
+		driverName:    "sqlite3",
+		timestampType: "DATETIME",
+		upsertFmt:     onConflictUpsert,
+	}
+	PostgreSQL = Dialect{
+		name:                 "postgres",
+		driverName:           "postgres",
+		numberedPlaceholders: true,
+		returningID:          true,
+		timestampType:        "TIMESTAMP",
+		lockQr:               "SELECT pg_try_advisory_lock(7274537)",
+		unlockQr:             "SELECT pg_advisory_unlock(7274537)",
+		upsertFmt:            onConflictUpsert,
+	}
+)
+
+// DialectByName returns the dialect called mysql, sqlite or postgres
+func DialectByName(name string) (Dialect, error) {
+	for _, dialect := range []Dialect{MySQL, SQLite, PostgreSQL} {
+		if dialect.name == name {
+			return dialect, nil
+		}
+	}
+	return Dialect{}, fmt.Errorf("unknown sql dialect %s, must be mysql, sqlite or postgres", name)
+}
+
+func (d Dialect) Name() string {
+	return d.name
+}
+
+// DriverName is the database/sql driver registered for the dialect
+func (d Dialect) DriverName() string {
+	return d.driverName
+}
+
+// rebind replaces the ? placeholders of the query with the dialect ones, ignoring the ones inside quotes
+func (d Dialect) rebind(query string) string {
+	if !d.numberedPlaceholders || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	inQuotes := false
+	n := 0
+	for _, c := range query {
+		switch {
+		case c == '\'':
+			inQuotes = !inQuotes
+		case c == '?' && !inQuotes:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// upsert appends to the insert query the clause that updates the columns when the conflict column already exists
+func (d Dialect) upsert(insertQuery, conflictColumn string, updateColumns ...string) string {
+	return insertQuery + d.upsertFmt(conflictColumn, updateColumns)
+}
+
+func onConflictUpsert(conflictColumn string, updateColumns []string) string {
+	sets := make([]string, len(updateColumns))
+	for i, column := range updateColumns {
+		sets[i] = fmt.Sprintf("%s = excluded.%s", column, column)
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", conflictColumn, strings.Join(sets, ", "))
+}
@@ -4,20 +4,40 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"time"
+	"log/slog"
 
 	"github.com/tasker/entities"
 	"github.com/tasker/http"
 )
 
 const (
-	InsertExecQr         = "INSERT INTO execution (scheduled_task_id, task_id, status, idempotency_token, executed_time) VALUES (?, ?, ?, ?, ?);"
-	GetExecIdempotencyQr = "SELECT * FROM execution WHERE idempotency_token = ?"
+	InsertExecQr         = "INSERT INTO execution (workspace_id, scheduled_task_id, task_id, status, idempotency_token, executed_time, resumed_from) VALUES (?, ?, ?, ?, ?, ?, ?);"
+	GetExecIdempotencyQr = "SELECT id, scheduled_task_id, task_id, status, idempotency_token, executed_time, resumed_from FROM execution WHERE idempotency_token = ? AND workspace_id = ?"
+	GetExecQr            = "SELECT id, scheduled_task_id, task_id, status, idempotency_token, executed_time, resumed_from FROM execution WHERE id = ? AND workspace_id = ?"
+	GetExecResumeQr      = "SELECT id, scheduled_task_id, task_id, status, idempotency_token, executed_time FROM execution WHERE resumed_from = ? AND workspace_id = ?"
+	InsertExecStepQr     = "INSERT INTO execution_step (execution_id, position, failure_step, step_type, mode, output, error, params_hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	GetExecStepsQr       = "SELECT position, failure_step, step_type, mode, output, error, params_hash FROM execution_step WHERE execution_id = ? ORDER BY id"
 )
 
-func (r repository) SaveExecution(ctx context.Context, exec entities.Execution) (entities.Execution, error) {
-	result, err := r.db.ExecContext(ctx, InsertExecQr, exec.ScheduledTask, exec.TaskID, exec.Status, exec.IdempotencyToken, exec.ExecutedTime)
+func (r repository) SaveExecution(ctx context.Context, exec entities.Execution) (savedExec entities.Execution, err error) {
+	ctx, err = r.db.Begin(ctx)
+	if err != nil {
+		return entities.Execution{}, fmt.Errorf("starting execution saving transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if err := r.db.Rollback(ctx); err != nil {
+				slog.ErrorContext(ctx, "rollbacking save execution transaction", "error", err)
+			}
+		}
+	}()
+
+	//Only the resumed executions link another one
+	var resumedFrom *int
+	if exec.ResumedFrom != 0 {
+		resumedFrom = &exec.ResumedFrom
+	}
+	result, err := r.db.InsertContext(ctx, InsertExecQr, workspaceID(ctx), exec.ScheduledTask, exec.TaskID, exec.Status, exec.IdempotencyToken, exec.ExecutedTime, resumedFrom)
 	if err != nil {
 		return entities.Execution{}, fmt.Errorf("inserting execution: %w", err)
 	}
@@ -35,28 +55,96 @@ func (r repository) SaveExecution(ctx context.Context, exec entities.Execution)
 		return entities.Execution{}, err
 	}
 
+	for _, trace := range exec.Steps {
+		if _, err = r.db.ExecContext(ctx, InsertExecStepQr, execID, trace.Step, trace.FailureStep, trace.Type, trace.Mode, trace.Output, trace.Error, trace.ParamsHash); err != nil {
+			return entities.Execution{}, fmt.Errorf("inserting execution step: %w", err)
+		}
+	}
+
+	if err = r.db.Commit(ctx); err != nil {
+		return entities.Execution{}, err
+	}
+
 	exec.ID = int(execID)
+	exec.WorkspaceID = workspaceID(ctx)
+	return exec, nil
+}
+
+// GetExecution returns the execution with the trace of its steps, in the order they ran
+func (r repository) GetExecution(ctx context.Context, executionID int) (entities.Execution, error) {
+	exec := entities.Execution{WorkspaceID: workspaceID(ctx)}
+	var executedTime dbTime
+	var scheduledTask, resumedFrom sql.NullInt64
+	row := r.db.QueryRowContext(ctx, GetExecQr, executionID, exec.WorkspaceID)
+	err := row.Scan(&exec.ID, &scheduledTask, &exec.TaskID, &exec.Status, &exec.IdempotencyToken, &executedTime, &resumedFrom)
+	switch {
+	case err == sql.ErrNoRows:
+		return entities.Execution{}, http.WrapError(err, http.ErrNotFound.WithMessage("execution not found"))
+	case err != nil:
+		return entities.Execution{}, fmt.Errorf("getting execution: %w", err)
+	}
+	exec.ScheduledTask, exec.ResumedFrom = int(scheduledTask.Int64), int(resumedFrom.Int64)
+	if executedTime.Time != nil {
+		exec.ExecutedTime = *executedTime.Time
+	}
+
+	rows, err := r.db.QueryContext(ctx, GetExecStepsQr, exec.ID)
+	if err != nil {
+		return entities.Execution{}, fmt.Errorf("getting execution steps: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var trace entities.StepTrace
+		if err := rows.Scan(&trace.Step, &trace.FailureStep, &trace.Type, &trace.Mode, &trace.Output, &trace.Error, &trace.ParamsHash); err != nil {
+			return entities.Execution{}, fmt.Errorf("scanning execution step: %w", err)
+		}
+		exec.Steps = append(exec.Steps, trace)
+	}
+	if err := rows.Err(); err != nil {
+		return entities.Execution{}, fmt.Errorf("getting execution steps: %w", err)
+	}
+
 	return exec, nil
 }
 
 func (r repository) GetExecutionIdempotency(ctx context.Context, idempToken string) (entities.Execution, error) {
-	var aux *string
-	exec := entities.Execution{}
-	execTimeString := ""
-	row := r.db.QueryRowContext(ctx, GetExecIdempotencyQr, idempToken)
-	err := row.Scan(&exec.ID, &exec.ScheduledTask, &exec.TaskID, &aux, &exec.Status, &exec.IdempotencyToken, &aux, &execTimeString, &aux)
+	exec := entities.Execution{WorkspaceID: workspaceID(ctx)}
+	var executedTime dbTime
+	var resumedFrom sql.NullInt64
+	row := r.db.QueryRowContext(ctx, GetExecIdempotencyQr, idempToken, exec.WorkspaceID)
+	err := row.Scan(&exec.ID, &exec.ScheduledTask, &exec.TaskID, &exec.Status, &exec.IdempotencyToken, &executedTime, &resumedFrom)
 	switch {
 	case err == sql.ErrNoRows:
 		return entities.Execution{}, http.WrapError(err, http.ErrNotFound.WithMessage("execution not found"))
 	case err != nil:
 		return entities.Execution{}, fmt.Errorf("getting task: %w", err)
 	}
+	exec.ResumedFrom = int(resumedFrom.Int64)
 
-	parsedTime, err := time.Parse(time.DateTime, execTimeString)
-	if err != nil {
-		log.Printf("Error unmarshalling JSON: %s. unmarshalling executed_time", err)
+	if executedTime.Time != nil {
+		exec.ExecutedTime = *executedTime.Time
+	}
+
+	return exec, nil
+}
+
+// GetExecutionResume returns the execution that resumed the given one, without its steps
+func (r repository) GetExecutionResume(ctx context.Context, executionID int) (entities.Execution, error) {
+	exec := entities.Execution{WorkspaceID: workspaceID(ctx), ResumedFrom: executionID}
+	var executedTime dbTime
+	var scheduledTask sql.NullInt64
+	row := r.db.QueryRowContext(ctx, GetExecResumeQr, executionID, exec.WorkspaceID)
+	err := row.Scan(&exec.ID, &scheduledTask, &exec.TaskID, &exec.Status, &exec.IdempotencyToken, &executedTime)
+	switch {
+	case err == sql.ErrNoRows:
+		return entities.Execution{}, http.WrapError(err, http.ErrNotFound.WithMessage("execution not resumed"))
+	case err != nil:
+		return entities.Execution{}, fmt.Errorf("getting execution resume: %w", err)
+	}
+	exec.ScheduledTask = int(scheduledTask.Int64)
+	if executedTime.Time != nil {
+		exec.ExecutedTime = *executedTime.Time
 	}
-	exec.ExecutedTime = parsedTime
 
 	return exec, nil
 }
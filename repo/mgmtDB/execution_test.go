@@ -27,7 +27,9 @@ func TestSaveExecution_ErrorExec(t *testing.T) {
 		ExecutedTime:  time.Time{},
 	}
 
-	mock.ExpectExec("^INSERT INTO execution \\(scheduled_task_id, status, executed_time\\) VALUES \\(\\?, \\?, \\?\\);$\n").WillReturnError(errors.New("exec mocked error"))
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT INTO execution \\(workspace_id, scheduled_task_id, task_id, status, idempotency_token, executed_time, resumed_from\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?\\);$").WillReturnError(errors.New("exec mocked error"))
+	mock.ExpectRollback()
 
 	_, err = repo.SaveExecution(ctx, exec)
 
@@ -52,7 +54,9 @@ func TestSaveExecution_MismatchAffectedRows(t *testing.T) {
 		ExecutedTime:  time.Time{},
 	}
 
-	mock.ExpectExec("^INSERT INTO execution \\(scheduled_task_id, status, executed_time\\) VALUES \\(\\?, \\?, \\?\\);$\n").WillReturnResult(sqlmock.NewResult(1, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT INTO execution \\(workspace_id, scheduled_task_id, task_id, status, idempotency_token, executed_time, resumed_from\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?\\);$").WillReturnResult(sqlmock.NewResult(1, 0))
+	mock.ExpectRollback()
 
 	_, err = repo.SaveExecution(ctx, exec)
 
@@ -78,11 +82,14 @@ func TestSaveExecution(t *testing.T) {
 	}
 	expectedExec := exec
 
-	mock.ExpectExec("^INSERT INTO execution \\(scheduled_task_id, status, executed_time\\) VALUES \\(\\?, \\?, \\?\\);$\n").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT INTO execution \\(workspace_id, scheduled_task_id, task_id, status, idempotency_token, executed_time, resumed_from\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?\\);$").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	exec, err = repo.SaveExecution(ctx, exec)
 
 	expectedExec.ID = 1
+	expectedExec.WorkspaceID = entities.DefaultWorkspaceID
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedExec, exec)
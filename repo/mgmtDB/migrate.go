@@ -0,0 +1,338 @@
+package mgmtDB
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+const (
+	insertMigrationQr = "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"
+	deleteMigrationQr = "DELETE FROM schema_migrations WHERE version = ?"
+	getMigrationsQr   = "SELECT version, applied_at FROM schema_migrations"
+)
+
+// migrationsLockTimeout bounds the wait for another instance to finish migrating, retrying every
+// migrationsLockRetryInterval. They are variables so the tests can shorten them
+var (
+	migrationsLockTimeout       = time.Minute
+	migrationsLockRetryInterval = time.Second
+)
+
+// ErrPendingMigrations is returned when the database schema is behind the migrations of this version
+var ErrPendingMigrations = errors.New("the database has pending migrations, run: tasker migrate up")
+
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	// AppliedAt is nil for pending migrations
+	AppliedAt *time.Time
+}
+
+// Migrator applies the numbered up/down scripts of migrations/<dialect>, recording them on schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+func loadMigrations(dialect Dialect) ([]Migration, error) {
+	dir := path.Join("migrations", dialect.name)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s migrations: %w", dialect.name, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", entry.Name(), err)
+		}
+
+		migration, found := byVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names on its up and down files", version)
+		}
+		if match[3] == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns the applied ones
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, st := range status {
+			if st.AppliedAt != nil {
+				continue
+			}
+			if err := m.apply(ctx, conn, st.Migration, st.up, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, m.dialect.rebind(insertMigrationQr), st.Version, st.Name, time.Now().UTC())
+				return err
+			}); err != nil {
+				return err
+			}
+			applied = append(applied, st.Migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns the reverted ones
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(status) - 1; i >= 0 && len(reverted) < steps; i-- {
+			st := status[i]
+			if st.AppliedAt == nil {
+				continue
+			}
+			if err := m.apply(ctx, conn, st.Migration, st.down, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, m.dialect.rebind(deleteMigrationQr), st.Version)
+				return err
+			}); err != nil {
+				return err
+			}
+			reverted = append(reverted, st.Migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns every migration with its applied date
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting connection: %w", err)
+	}
+	defer conn.Close()
+
+	if err := m.createMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	return m.status(ctx, conn)
+}
+
+// CheckUpToDate fails with ErrPendingMigrations if any migration was not applied
+func (m *Migrator) CheckUpToDate(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, st := range status {
+		if st.AppliedAt == nil {
+			return fmt.Errorf("%w (version %d %s)", ErrPendingMigrations, st.Version, st.Name)
+		}
+	}
+	return nil
+}
+
+// withLock runs f on a single connection holding the dialect migrations lock, so concurrent instances don't race
+func (m *Migrator) withLock(ctx context.Context, f func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("getting connection: %w", err)
+	}
+	defer conn.Close()
+
+	if m.dialect.lockQr != "" {
+		if err := m.lock(ctx, conn); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), m.dialect.unlockQr)
+	}
+
+	if err := m.createMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+// lock takes the dialect migrations lock, retrying until migrationsLockTimeout while another instance holds it
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) error {
+	deadline := time.Now().Add(migrationsLockTimeout)
+	for {
+		//GET_LOCK returns 1, 0 on timeout or NULL on error, pg_try_advisory_lock returns true or false right away
+		var locked sql.NullBool
+		if err := conn.QueryRowContext(ctx, m.dialect.lockQr).Scan(&locked); err != nil {
+			return fmt.Errorf("acquiring migrations lock: %w", err)
+		}
+		if locked.Valid && locked.Bool {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New("acquiring migrations lock: timeout, another instance is migrating")
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("acquiring migrations lock: %w", ctx.Err())
+		case <-time.After(migrationsLockRetryInterval):
+		}
+	}
+}
+
+func (m *Migrator) createMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS schema_migrations (version INT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at %s NOT NULL)", m.dialect.timestampType)
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, getMigrationsQr)
+	if err != nil {
+		return nil, fmt.Errorf("getting applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]*time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt dbTime
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("scanning applied migration: %w", err)
+		}
+		applied[version] = appliedAt.Time
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("getting applied migrations: %w", err)
+	}
+
+	status := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i] = MigrationStatus{Migration: migration, AppliedAt: applied[migration.Version]}
+	}
+	return status, nil
+}
+
+// apply runs the script and records it inside a transaction. MySQL commits DDL implicitly, on the other dialects a
+// failed or racing migration is fully rolled back
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, script string, record func(tx *sql.Tx) error) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting migration %d transaction: %w", migration.Version, err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, stmt := range splitStatements(script) {
+		if _, err = tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("running migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	if err = record(tx); err != nil {
+		return fmt.Errorf("recording migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("committing migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// splitStatements splits a script on the semicolons that are outside quotes and comments, dropping the comments
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote rune
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			i++
+			continue
+		case c == ';':
+			flush()
+			continue
+		}
+		current.WriteRune(c)
+	}
+	flush()
+	return statements
+}
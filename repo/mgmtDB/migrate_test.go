@@ -0,0 +1,82 @@
+package mgmtDB
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- header comment; with a semicolon
+CREATE TABLE a (note VARCHAR(10) DEFAULT 'x;y');
+/* block; comment */
+INSERT INTO a VALUES ("--not a comment");
+`
+	assert.Equal(t, []string{
+		"CREATE TABLE a (note VARCHAR(10) DEFAULT 'x;y')",
+		`INSERT INTO a VALUES ("--not a comment")`,
+	}, splitStatements(script))
+	assert.Empty(t, splitStatements("-- nothing to do on this dialect\n"))
+}
+
+func TestLoadMigrations(t *testing.T) {
+	for _, dialect := range []Dialect{MySQL, SQLite, PostgreSQL} {
+		migrations, err := loadMigrations(dialect)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations, dialect.Name())
+		for i, migration := range migrations {
+			assert.Equal(t, i+1, migration.Version, dialect.Name())
+			assert.NotEmpty(t, migration.down, "%s %d has no down script", dialect.Name(), migration.Version)
+		}
+	}
+}
+
+func TestMigrator_SQLite(t *testing.T) {
+	db, err := sql.Open(SQLite.DriverName(), filepath.Join(t.TempDir(), "tasker.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	migrator, err := NewMigrator(db, SQLite)
+	assert.NoError(t, err)
+	total := len(migrator.migrations)
+
+	err = migrator.CheckUpToDate(ctx)
+	assert.True(t, errors.Is(err, ErrPendingMigrations))
+
+	applied, err := migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, applied, total)
+	assert.NoError(t, migrator.CheckUpToDate(ctx))
+
+	applied, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := migrator.Down(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.Equal(t, total, reverted[0].Version)
+
+	status, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, status[0].AppliedAt)
+	assert.Nil(t, status[total-1].AppliedAt)
+
+	reverted, err = migrator.Down(ctx, total)
+	assert.NoError(t, err)
+	assert.Len(t, reverted, total-1)
+
+	var tables int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'task'").Scan(&tables))
+	assert.Zero(t, tables)
+
+	applied, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, applied, total)
+}
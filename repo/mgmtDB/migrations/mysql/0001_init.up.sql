@@ -40,3 +40,8 @@ CREATE TABLE IF NOT EXISTS execution (
     FOREIGN KEY (scheduled_task_id) REFERENCES scheduled_task(id),
     FOREIGN KEY (task_id) REFERENCES task(id)
     );
+
+CREATE TABLE IF NOT EXISTS secret (
+                                      name VARCHAR(255) PRIMARY KEY,
+                                      value VARBINARY(4096) NOT NULL
+    );
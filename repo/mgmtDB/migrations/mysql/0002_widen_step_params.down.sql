@@ -0,0 +1,2 @@
+-- Params longer than 255 characters are truncated
+ALTER TABLE step MODIFY params VARCHAR(255);
@@ -0,0 +1,2 @@
+-- Step params are a JSON document, 255 characters were not enough for headers or bodies
+ALTER TABLE step MODIFY params TEXT;
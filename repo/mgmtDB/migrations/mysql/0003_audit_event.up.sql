@@ -0,0 +1,15 @@
+-- Who changed tasks, schedules and secrets, with the entity state before and after the change
+CREATE TABLE IF NOT EXISTS audit_event (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    occurred_at DATETIME(6) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    entity_type VARCHAR(64) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    before_state TEXT,
+    after_state TEXT,
+    diff TEXT,
+    INDEX idx_audit_entity (entity_type, entity_id),
+    INDEX idx_audit_actor (actor),
+    INDEX idx_audit_occurred_at (occurred_at)
+);
@@ -0,0 +1,10 @@
+-- API keys are only stored as the SHA-256 hash of the key, the prefix identifies them on listings
+CREATE TABLE IF NOT EXISTS api_key (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes VARCHAR(1024) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    revoked_at DATETIME(6)
+);
@@ -0,0 +1,15 @@
+ALTER TABLE secret DROP FOREIGN KEY fk_secret_workspace;
+DELETE FROM secret WHERE workspace_id <> 1;
+ALTER TABLE secret DROP PRIMARY KEY, DROP COLUMN workspace_id, ADD PRIMARY KEY (name);
+
+ALTER TABLE audit_event DROP INDEX idx_audit_workspace, DROP COLUMN workspace_id;
+ALTER TABLE api_key DROP FOREIGN KEY fk_api_key_workspace;
+ALTER TABLE api_key DROP INDEX fk_api_key_workspace, DROP COLUMN workspace_id;
+ALTER TABLE execution DROP FOREIGN KEY fk_execution_workspace;
+ALTER TABLE execution DROP INDEX fk_execution_workspace, DROP COLUMN workspace_id;
+ALTER TABLE scheduled_task DROP FOREIGN KEY fk_scheduled_task_workspace;
+ALTER TABLE scheduled_task DROP INDEX fk_scheduled_task_workspace, DROP COLUMN workspace_id;
+ALTER TABLE task DROP FOREIGN KEY fk_task_workspace;
+ALTER TABLE task DROP INDEX fk_task_workspace, DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspace;
@@ -0,0 +1,25 @@
+-- Workspaces isolate tenants, the rows created before them belong to the default workspace
+CREATE TABLE IF NOT EXISTS workspace (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at DATETIME(6) NOT NULL
+);
+
+INSERT INTO workspace (id, name, created_at) VALUES (1, 'default', UTC_TIMESTAMP(6));
+
+ALTER TABLE task ADD COLUMN workspace_id INT NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_task_workspace FOREIGN KEY (workspace_id) REFERENCES workspace(id);
+ALTER TABLE scheduled_task ADD COLUMN workspace_id INT NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_scheduled_task_workspace FOREIGN KEY (workspace_id) REFERENCES workspace(id);
+ALTER TABLE execution ADD COLUMN workspace_id INT NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_execution_workspace FOREIGN KEY (workspace_id) REFERENCES workspace(id);
+ALTER TABLE api_key ADD COLUMN workspace_id INT NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_api_key_workspace FOREIGN KEY (workspace_id) REFERENCES workspace(id);
+ALTER TABLE audit_event ADD COLUMN workspace_id INT NOT NULL DEFAULT 1,
+    ADD INDEX idx_audit_workspace (workspace_id);
+
+-- The same secret name can be defined on every workspace
+ALTER TABLE secret ADD COLUMN workspace_id INT NOT NULL DEFAULT 1 FIRST,
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (workspace_id, name),
+    ADD CONSTRAINT fk_secret_workspace FOREIGN KEY (workspace_id) REFERENCES workspace(id);
@@ -0,0 +1,7 @@
+-- MySQL dropped the implicit foreign key indexes when the composite ones were created, the foreign keys need one back
+CREATE INDEX idx_execution_task_id ON execution (task_id);
+DROP INDEX idx_execution_task ON execution;
+CREATE INDEX idx_scheduled_task_task_id ON scheduled_task (task_id);
+DROP INDEX idx_scheduled_task_task ON scheduled_task;
+CREATE INDEX idx_step_task_id ON step (task_id);
+DROP INDEX idx_step_task_type ON step;
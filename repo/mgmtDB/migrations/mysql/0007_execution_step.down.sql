@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS execution_step;
+
+ALTER TABLE execution DROP FOREIGN KEY fk_execution_resumed_from;
+ALTER TABLE execution DROP INDEX fk_execution_resumed_from, DROP COLUMN resumed_from;
@@ -0,0 +1,15 @@
+-- The trace of the execution steps, a failed execution is resumed from its failed step reusing the outputs before it
+ALTER TABLE execution ADD COLUMN resumed_from INT,
+    ADD CONSTRAINT fk_execution_resumed_from FOREIGN KEY (resumed_from) REFERENCES execution(id);
+
+CREATE TABLE IF NOT EXISTS execution_step (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    execution_id INT NOT NULL,
+    position INT NOT NULL,
+    failure_step BOOLEAN NOT NULL,
+    step_type VARCHAR(255) NOT NULL,
+    mode VARCHAR(32) NOT NULL,
+    output MEDIUMTEXT NOT NULL,
+    error TEXT NOT NULL,
+    FOREIGN KEY (execution_id) REFERENCES execution(id)
+);
@@ -0,0 +1 @@
+DROP INDEX idx_execution_resumed_from ON execution;
@@ -0,0 +1,2 @@
+-- An execution is resumed once, the resumes that fail again are resumed in turn
+CREATE UNIQUE INDEX idx_execution_resumed_from ON execution (resumed_from);
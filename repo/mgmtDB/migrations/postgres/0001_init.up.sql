@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS task (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS step (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES task(id),
+    step_type VARCHAR(255) NOT NULL,
+    params VARCHAR(255),
+    failure_step INT REFERENCES step(id),
+    position INT
+);
+
+CREATE INDEX IF NOT EXISTS idx_position ON step (position);
+
+CREATE TABLE IF NOT EXISTS scheduled_task (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    cron VARCHAR(255) NOT NULL,
+    retries INT NOT NULL,
+    task_id INT NOT NULL REFERENCES task(id),
+    enabled BOOLEAN NOT NULL,
+    last_run TIMESTAMP,
+    first_run TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS execution (
+    id SERIAL PRIMARY KEY,
+    scheduled_task_id INT REFERENCES scheduled_task(id),
+    task_id INT REFERENCES task(id),
+    try_number INT,
+    status VARCHAR(255) NOT NULL,
+    idempotency_token CHAR(36),
+    requested_time TIMESTAMP,
+    executed_time TIMESTAMP,
+    last_status_change_time TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS secret (
+    name VARCHAR(255) PRIMARY KEY,
+    value BYTEA NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Fails if any params is longer than 255 characters
+ALTER TABLE step ALTER COLUMN params TYPE VARCHAR(255);
@@ -0,0 +1,16 @@
+-- Who changed tasks, schedules and secrets, with the entity state before and after the change
+CREATE TABLE IF NOT EXISTS audit_event (
+    id SERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    entity_type VARCHAR(64) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    before_state TEXT,
+    after_state TEXT,
+    diff TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_event (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_event (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_occurred_at ON audit_event (occurred_at);
@@ -0,0 +1,16 @@
+DELETE FROM secret WHERE workspace_id <> 1;
+ALTER TABLE secret DROP CONSTRAINT secret_pkey;
+ALTER TABLE secret DROP COLUMN workspace_id;
+ALTER TABLE secret ADD PRIMARY KEY (name);
+
+DROP INDEX IF EXISTS idx_audit_workspace;
+DROP INDEX IF EXISTS idx_execution_workspace;
+DROP INDEX IF EXISTS idx_scheduled_task_workspace;
+DROP INDEX IF EXISTS idx_task_workspace;
+ALTER TABLE audit_event DROP COLUMN workspace_id;
+ALTER TABLE api_key DROP COLUMN workspace_id;
+ALTER TABLE execution DROP COLUMN workspace_id;
+ALTER TABLE scheduled_task DROP COLUMN workspace_id;
+ALTER TABLE task DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspace;
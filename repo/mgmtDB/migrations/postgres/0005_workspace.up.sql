@@ -0,0 +1,24 @@
+-- Workspaces isolate tenants, the rows created before them belong to the default workspace
+CREATE TABLE IF NOT EXISTS workspace (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO workspace (id, name, created_at) VALUES (1, 'default', NOW() AT TIME ZONE 'UTC');
+SELECT setval('workspace_id_seq', (SELECT MAX(id) FROM workspace));
+
+ALTER TABLE task ADD COLUMN workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspace(id);
+ALTER TABLE scheduled_task ADD COLUMN workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspace(id);
+ALTER TABLE execution ADD COLUMN workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspace(id);
+ALTER TABLE api_key ADD COLUMN workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspace(id);
+ALTER TABLE audit_event ADD COLUMN workspace_id INT NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS idx_task_workspace ON task (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_scheduled_task_workspace ON scheduled_task (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_execution_workspace ON execution (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_audit_workspace ON audit_event (workspace_id);
+
+-- The same secret name can be defined on every workspace
+ALTER TABLE secret ADD COLUMN workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspace(id);
+ALTER TABLE secret DROP CONSTRAINT secret_pkey;
+ALTER TABLE secret ADD PRIMARY KEY (workspace_id, name);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_execution_task;
+DROP INDEX IF EXISTS idx_scheduled_task_task;
+DROP INDEX IF EXISTS idx_step_task_type;
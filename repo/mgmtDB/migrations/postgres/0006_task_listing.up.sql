@@ -0,0 +1,4 @@
+-- The task listing filters by step type and schedule and reads the latest execution of each task
+CREATE INDEX IF NOT EXISTS idx_step_task_type ON step (task_id, step_type);
+CREATE INDEX IF NOT EXISTS idx_scheduled_task_task ON scheduled_task (task_id, enabled);
+CREATE INDEX IF NOT EXISTS idx_execution_task ON execution (task_id, id);
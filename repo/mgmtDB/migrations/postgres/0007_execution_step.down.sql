@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_execution_step_execution;
+DROP TABLE IF EXISTS execution_step;
+
+ALTER TABLE execution DROP COLUMN resumed_from;
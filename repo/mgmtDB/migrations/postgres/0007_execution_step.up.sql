@@ -0,0 +1,15 @@
+-- The trace of the execution steps, a failed execution is resumed from its failed step reusing the outputs before it
+ALTER TABLE execution ADD COLUMN resumed_from INT REFERENCES execution(id);
+
+CREATE TABLE IF NOT EXISTS execution_step (
+    id SERIAL PRIMARY KEY,
+    execution_id INT NOT NULL REFERENCES execution(id),
+    position INT NOT NULL,
+    failure_step BOOLEAN NOT NULL,
+    step_type VARCHAR(255) NOT NULL,
+    mode VARCHAR(32) NOT NULL,
+    output TEXT NOT NULL,
+    error TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_execution_step_execution ON execution_step (execution_id);
@@ -0,0 +1,3 @@
+-- The hash of the step params the trace ran with, a resume only reuses the outputs of the steps that didn't change.
+-- The traces recorded before it have no hash, so they can't be reused
+ALTER TABLE execution_step ADD COLUMN params_hash CHAR(64) NOT NULL DEFAULT '';
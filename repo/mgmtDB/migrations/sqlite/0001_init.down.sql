@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS secret;
+DROP TABLE IF EXISTS execution;
+DROP TABLE IF EXISTS scheduled_task;
+DROP TABLE IF EXISTS step;
+DROP TABLE IF EXISTS task;
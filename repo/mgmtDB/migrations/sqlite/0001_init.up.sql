@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS task (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS step (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    step_type VARCHAR(255) NOT NULL,
+    params VARCHAR(255),
+    failure_step INTEGER,
+    position INTEGER,
+    FOREIGN KEY (task_id) REFERENCES task(id),
+    FOREIGN KEY (failure_step) REFERENCES step(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_position ON step (position);
+
+CREATE TABLE IF NOT EXISTS scheduled_task (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    cron VARCHAR(255) NOT NULL,
+    retries INTEGER NOT NULL,
+    task_id INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    last_run DATETIME,
+    first_run DATETIME,
+    FOREIGN KEY (task_id) REFERENCES task(id)
+);
+
+CREATE TABLE IF NOT EXISTS execution (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scheduled_task_id INTEGER,
+    task_id INTEGER,
+    try_number INTEGER,
+    status VARCHAR(255) NOT NULL,
+    idempotency_token CHAR(36),
+    requested_time DATETIME,
+    executed_time DATETIME,
+    last_status_change_time DATETIME,
+    FOREIGN KEY (scheduled_task_id) REFERENCES scheduled_task(id),
+    FOREIGN KEY (task_id) REFERENCES task(id)
+);
+
+CREATE TABLE IF NOT EXISTS secret (
+    name VARCHAR(255) PRIMARY KEY,
+    value BLOB NOT NULL
+);
@@ -0,0 +1 @@
+-- SQLite doesn't enforce VARCHAR lengths, params already accept any size
@@ -0,0 +1,19 @@
+CREATE TABLE secret_old (
+    name VARCHAR(255) PRIMARY KEY,
+    value BLOB NOT NULL
+);
+INSERT INTO secret_old (name, value) SELECT name, value FROM secret WHERE workspace_id = 1;
+DROP TABLE secret;
+ALTER TABLE secret_old RENAME TO secret;
+
+DROP INDEX IF EXISTS idx_audit_workspace;
+DROP INDEX IF EXISTS idx_execution_workspace;
+DROP INDEX IF EXISTS idx_scheduled_task_workspace;
+DROP INDEX IF EXISTS idx_task_workspace;
+ALTER TABLE audit_event DROP COLUMN workspace_id;
+ALTER TABLE api_key DROP COLUMN workspace_id;
+ALTER TABLE execution DROP COLUMN workspace_id;
+ALTER TABLE scheduled_task DROP COLUMN workspace_id;
+ALTER TABLE task DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspace;
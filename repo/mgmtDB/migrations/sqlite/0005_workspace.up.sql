@@ -0,0 +1,30 @@
+-- Workspaces isolate tenants, the rows created before them belong to the default workspace
+CREATE TABLE IF NOT EXISTS workspace (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL
+);
+
+INSERT INTO workspace (id, name, created_at) VALUES (1, 'default', CURRENT_TIMESTAMP);
+
+-- SQLite only allows adding a column with a foreign key when its default is NULL, the service checks the workspace
+ALTER TABLE task ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE scheduled_task ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE execution ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE api_key ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE audit_event ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS idx_task_workspace ON task (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_scheduled_task_workspace ON scheduled_task (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_execution_workspace ON execution (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_audit_workspace ON audit_event (workspace_id);
+
+-- The same secret name can be defined on every workspace, SQLite can not alter a primary key so the table is rebuilt
+CREATE TABLE secret_new (
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspace(id),
+    name VARCHAR(255) NOT NULL,
+    value BLOB NOT NULL,
+    PRIMARY KEY (workspace_id, name)
+);
+INSERT INTO secret_new (workspace_id, name, value) SELECT 1, name, value FROM secret;
+DROP TABLE secret;
+ALTER TABLE secret_new RENAME TO secret;
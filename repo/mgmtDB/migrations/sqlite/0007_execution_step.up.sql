@@ -0,0 +1,17 @@
+-- The trace of the execution steps, a failed execution is resumed from its failed step reusing the outputs before it.
+-- SQLite can't drop a column with a foreign key, the service checks the resumed execution
+ALTER TABLE execution ADD COLUMN resumed_from INTEGER;
+
+CREATE TABLE IF NOT EXISTS execution_step (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    execution_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    failure_step BOOLEAN NOT NULL,
+    step_type VARCHAR(255) NOT NULL,
+    mode VARCHAR(32) NOT NULL,
+    output TEXT NOT NULL,
+    error TEXT NOT NULL,
+    FOREIGN KEY (execution_id) REFERENCES execution(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_execution_step_execution ON execution_step (execution_id);
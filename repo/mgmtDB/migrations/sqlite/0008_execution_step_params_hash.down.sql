@@ -0,0 +1 @@
+ALTER TABLE execution_step DROP COLUMN params_hash;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_execution_resumed_from;
@@ -3,6 +3,8 @@ package mgmtDB
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/tasker/entities"
@@ -14,6 +16,7 @@ type DataBase interface {
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	PingContext(ctx context.Context) error
 }
 
 type DataBaseTransactionAware interface {
@@ -21,29 +24,94 @@ type DataBaseTransactionAware interface {
 	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	InsertContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareInsertContext(ctx context.Context, query string) (Stmt, error)
 	Begin(ctx context.Context) (context.Context, error)
 	Commit(ctx context.Context) error
 	Rollback(ctx context.Context) error
+	PingContext(ctx context.Context) error
 }
 
+// Stmt is a prepared statement, *sql.Stmt satisfies it
+type Stmt interface {
+	Exec(args ...any) (sql.Result, error)
+	Close() error
+}
+
+// Repository reads and writes the tasks, schedules, executions, secrets, audit events and API keys of the workspace set
+// on the context with service.ContextWithWorkspace, the default workspace when none is set
 type Repository interface {
 	SaveTask(ctx context.Context, task entities.Task) (entities.Task, error)
 	GetTask(ctx context.Context, taskID int) (entities.Task, error)
+	GetTasks(ctx context.Context, filter entities.TaskFilter) ([]entities.TaskSummary, error)
+	GetTaskByName(ctx context.Context, name string) (entities.Task, error)
+	UpdateTaskSteps(ctx context.Context, taskID int, steps []entities.Step) ([]entities.Step, error)
 	SaveExecution(ctx context.Context, exec entities.Execution) (entities.Execution, error)
 	GetExecutionIdempotency(ctx context.Context, idempToken string) (entities.Execution, error)
+	GetExecution(ctx context.Context, executionID int) (entities.Execution, error)
+	GetExecutionResume(ctx context.Context, executionID int) (entities.Execution, error)
 	SaveSchedule(ctx context.Context, sch entities.ScheduledTask) (entities.ScheduledTask, error)
 	GetEnabledSchedules(ctx context.Context) ([]entities.ScheduledTask, error)
+	GetTaskSchedules(ctx context.Context, taskID int) ([]entities.ScheduledTask, error)
+	UpdateSchedule(ctx context.Context, sch entities.ScheduledTask) error
 	SetScheduleLastRun(ctx context.Context, schID int, time time.Time) error
+	SaveSecret(ctx context.Context, name string, encryptedValue []byte) error
+	GetSecret(ctx context.Context, name string) ([]byte, error)
+	SaveAuditEvent(ctx context.Context, event entities.AuditEvent) error
+	GetAuditEvents(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error)
+	SaveAPIKey(ctx context.Context, key entities.APIKey, keyHash string) (entities.APIKey, error)
+	GetAPIKey(ctx context.Context, keyID int) (entities.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (entities.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]entities.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int, revokedAt time.Time) error
+	SaveWorkspace(ctx context.Context, workspace entities.Workspace) (entities.Workspace, error)
+	GetWorkspaces(ctx context.Context) ([]entities.Workspace, error)
+	GetWorkspaceByName(ctx context.Context, name string) (entities.Workspace, error)
+	// InTransaction runs fn with a context whose writes are committed when it returns nil and rolled back otherwise
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// Ping checks the database is reachable
+	Ping(ctx context.Context) error
 }
 
 type repository struct {
-	db DataBaseTransactionAware
+	db      DataBaseTransactionAware
+	dialect Dialect
 }
 
+// NewRepository creates the management storage on a MySQL database
 func NewRepository(db DataBase) Repository {
+	return NewRepositoryWithDialect(db, MySQL)
+}
+
+func NewRepositoryWithDialect(db DataBase, dialect Dialect) Repository {
 	return &repository{
 		db: dbTransactionAware{
-			db: db,
+			db:      db,
+			dialect: dialect,
 		},
+		dialect: dialect,
+	}
+}
+
+func (r repository) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	txCtx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
 	}
+
+	if err := fn(txCtx); err != nil {
+		if rollbackErr := r.db.Rollback(txCtx); rollbackErr != nil {
+			slog.ErrorContext(ctx, "rollbacking transaction", "error", rollbackErr)
+		}
+		return err
+	}
+
+	if err := r.db.Commit(txCtx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+func (r repository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
 }
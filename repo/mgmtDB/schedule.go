@@ -3,20 +3,21 @@ package mgmtDB
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/tasker/entities"
 )
 
 const (
-	InsertSchQr     = "INSERT INTO scheduled_task (name, cron, retries, task_id, enabled, last_run, first_run) VALUES (?, ?, ?, ?, ?, ?, ?);"
-	GetEnabledSchQr = "SELECT * FROM scheduled_task WHERE enabled = true"
-	SetLastRunSchQr = "UPDATE scheduled_task SET last_run = ? WHERE id = ?"
+	InsertSchQr     = "INSERT INTO scheduled_task (workspace_id, name, cron, retries, task_id, enabled, last_run, first_run) VALUES (?, ?, ?, ?, ?, ?, ?, ?);"
+	GetEnabledSchQr = "SELECT id, name, cron, retries, task_id, enabled, last_run, first_run FROM scheduled_task WHERE enabled = true AND workspace_id = ?"
+	SetLastRunSchQr = "UPDATE scheduled_task SET last_run = ? WHERE id = ? AND workspace_id = ?"
+	GetTaskSchQr    = "SELECT id, name, cron, retries, task_id, enabled, last_run, first_run FROM scheduled_task WHERE task_id = ? AND workspace_id = ? ORDER BY id"
+	UpdateSchQr     = "UPDATE scheduled_task SET cron = ?, retries = ?, enabled = ? WHERE id = ? AND workspace_id = ?"
 )
 
 func (r repository) SaveSchedule(ctx context.Context, sch entities.ScheduledTask) (entities.ScheduledTask, error) {
-	result, err := r.db.ExecContext(ctx, InsertSchQr, sch.Name, sch.Cron, sch.Retries, sch.Task.ID, sch.Enabled, sch.LastRun, sch.FirstRun)
+	result, err := r.db.InsertContext(ctx, InsertSchQr, workspaceID(ctx), sch.Name, sch.Cron, sch.Retries, sch.Task.ID, sch.Enabled, sch.LastRun, sch.FirstRun)
 	if err != nil {
 		return entities.ScheduledTask{}, fmt.Errorf("inserting schedule: %w", err)
 	}
@@ -35,25 +36,26 @@ func (r repository) SaveSchedule(ctx context.Context, sch entities.ScheduledTask
 	}
 
 	sch.ID = int(schID)
+	sch.WorkspaceID = workspaceID(ctx)
 	return sch, nil
 }
 
 func (r repository) GetEnabledSchedules(ctx context.Context) ([]entities.ScheduledTask, error) {
-	rows, err := r.db.QueryContext(ctx, GetEnabledSchQr)
+	rows, err := r.db.QueryContext(ctx, GetEnabledSchQr, workspaceID(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("getting steps from DB: %w", err)
 	}
 
 	var schs []entities.ScheduledTask
 	for rows.Next() {
-		sch := entities.ScheduledTask{}
-		var lastRunStr, firstRunStr *string
-		err = rows.Scan(&sch.ID, &sch.Name, &sch.Cron, &sch.Retries, &sch.Task.ID, &sch.Enabled, &lastRunStr, &firstRunStr)
+		sch := entities.ScheduledTask{WorkspaceID: workspaceID(ctx)}
+		var lastRun, firstRun dbTime
+		err = rows.Scan(&sch.ID, &sch.Name, &sch.Cron, &sch.Retries, &sch.Task.ID, &sch.Enabled, &lastRun, &firstRun)
 		if err != nil {
 			return nil, fmt.Errorf("scanning schedule: %w", err)
 		}
 
-		sch.FirstRun, sch.LastRun = parseDates(firstRunStr, lastRunStr)
+		sch.FirstRun, sch.LastRun = firstRun.Time, lastRun.Time
 
 		sch.Task, err = r.GetTask(ctx, sch.Task.ID)
 		if err != nil {
@@ -69,27 +71,44 @@ func (r repository) GetEnabledSchedules(ctx context.Context) ([]entities.Schedul
 	return schs, nil
 }
 
-func parseDates(firstRunStr, lastRunStr *string) (*time.Time, *time.Time) {
-	var firstRunPtr, lastRunPtr *time.Time
-	if firstRunStr != nil {
-		firstRun, err := time.Parse(time.DateTime, *firstRunStr)
-		if err != nil {
-			log.Printf("Error unmarshalling JSON: %s. unmarshalling first_run", err)
-		}
-		firstRunPtr = &firstRun
+// GetTaskSchedules reads the schedules of the task, enabled or not, without their task
+func (r repository) GetTaskSchedules(ctx context.Context, taskID int) ([]entities.ScheduledTask, error) {
+	rows, err := r.db.QueryContext(ctx, GetTaskSchQr, taskID, workspaceID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("getting task schedules from DB: %w", err)
 	}
-	if lastRunStr != nil {
-		lastRun, err := time.Parse(time.DateTime, *lastRunStr)
+	defer rows.Close()
+
+	var schs []entities.ScheduledTask
+	for rows.Next() {
+		sch := entities.ScheduledTask{WorkspaceID: workspaceID(ctx)}
+		var lastRun, firstRun dbTime
+		err = rows.Scan(&sch.ID, &sch.Name, &sch.Cron, &sch.Retries, &sch.Task.ID, &sch.Enabled, &lastRun, &firstRun)
 		if err != nil {
-			log.Printf("Error unmarshalling JSON: %s. unmarshalling last_run", err)
+			return nil, fmt.Errorf("scanning schedule: %w", err)
 		}
-		lastRunPtr = &lastRun
+
+		sch.FirstRun, sch.LastRun = firstRun.Time, lastRun.Time
+		schs = append(schs, sch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("getting task schedules from DB: %w", err)
+	}
+
+	return schs, nil
+}
+
+// UpdateSchedule sets the cron, retries and enabled of the schedule, its name, task and runs are kept. The affected
+// rows aren't checked, MySQL doesn't count the rows whose values don't change
+func (r repository) UpdateSchedule(ctx context.Context, sch entities.ScheduledTask) error {
+	if _, err := r.db.ExecContext(ctx, UpdateSchQr, sch.Cron, sch.Retries, sch.Enabled, sch.ID, workspaceID(ctx)); err != nil {
+		return fmt.Errorf("updating schedule: %w", err)
 	}
-	return firstRunPtr, lastRunPtr
+	return nil
 }
 
 func (r repository) SetScheduleLastRun(ctx context.Context, schID int, time time.Time) error {
-	result, err := r.db.ExecContext(ctx, SetLastRunSchQr, time, schID)
+	result, err := r.db.ExecContext(ctx, SetLastRunSchQr, time, schID, workspaceID(ctx))
 	if err != nil {
 		return fmt.Errorf("setting last run date: %w", err)
 	}
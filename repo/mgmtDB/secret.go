@@ -0,0 +1,36 @@
+package mgmtDB
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tasker/http"
+)
+
+const (
+	InsertSecretQr = "INSERT INTO secret (workspace_id, name, value) VALUES (?, ?, ?)"
+	GetSecretQr    = "SELECT value FROM secret WHERE workspace_id = ? AND name = ?"
+)
+
+// SaveSecret creates or replaces the secret, the value must arrive already encrypted
+func (r repository) SaveSecret(ctx context.Context, name string, encryptedValue []byte) error {
+	if _, err := r.db.ExecContext(ctx, r.dialect.upsert(InsertSecretQr, "workspace_id, name", "value"), workspaceID(ctx), name, encryptedValue); err != nil {
+		return fmt.Errorf("upserting secret: %w", err)
+	}
+
+	return nil
+}
+
+func (r repository) GetSecret(ctx context.Context, name string) ([]byte, error) {
+	var value []byte
+	err := r.db.QueryRowContext(ctx, GetSecretQr, workspaceID(ctx), name).Scan(&value)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, http.WrapError(err, http.ErrNotFound.WithMessage("secret not found"))
+	case err != nil:
+		return nil, fmt.Errorf("getting secret: %w", err)
+	}
+
+	return value, nil
+}
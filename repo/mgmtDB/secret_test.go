@@ -0,0 +1,65 @@
+package mgmtDB
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+	"github.com/tasker/service"
+)
+
+func TestSaveSecret(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRepository(db)
+
+	mock.ExpectExec("INSERT INTO secret").WithArgs(entities.DefaultWorkspaceID, "api_key", []byte("encrypted")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.SaveSecret(context.Background(), "api_key", []byte("encrypted"))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSecret_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRepository(db)
+
+	mock.ExpectQuery("SELECT value FROM secret").WithArgs(entities.DefaultWorkspaceID, "api_key").WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.GetSecret(context.Background(), "api_key")
+
+	assert.True(t, http.IsNotFoundErr(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSecret(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRepository(db)
+
+	mock.ExpectQuery("SELECT value FROM secret WHERE workspace_id = \\? AND name = \\?").WithArgs(2, "api_key").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("encrypted")))
+
+	value, err := repo.GetSecret(service.ContextWithWorkspace(context.Background(), 2), "api_key")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("encrypted"), value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
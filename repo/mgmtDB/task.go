@@ -5,19 +5,33 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 
 	"github.com/tasker/entities"
 	"github.com/tasker/http"
 )
 
 const (
-	InsertTaskQr = "INSERT INTO task (name) VALUES (?)"
+	InsertTaskQr = "INSERT INTO task (workspace_id, name) VALUES (?, ?)"
 	InsertStepQr = "INSERT INTO step (task_id, step_type, params, failure_step, position) VALUES (?, ?, ?, ?, ?)"
-	GetTaskQr    = "SELECT * FROM task WHERE id = ?"
+	GetTaskQr    = "SELECT id, name FROM task WHERE id = ? AND workspace_id = ?"
 	GetStepsQr   = "SELECT id, step_type, params, failure_step, position FROM step WHERE task_id = ? ORDER BY position"
+	// GetTaskIDsByNameQr reads up to two tasks, enough to know if the name is ambiguous
+	GetTaskIDsByNameQr = "SELECT id FROM task WHERE name = ? AND workspace_id = ? ORDER BY id LIMIT 2"
+	// UnlinkFailureStepsQr drops the links to the failure steps so the steps can be deleted in any order
+	UnlinkFailureStepsQr = "UPDATE step SET failure_step = NULL WHERE task_id = ?"
+	DeleteStepsQr        = "DELETE FROM step WHERE task_id = ?"
+	// GetTaskSummariesQr counts the steps and reads the latest execution with subqueries, so the steps aren't loaded
+	GetTaskSummariesQr = "SELECT t.id, t.name, " +
+		"(SELECT COUNT(*) FROM step s WHERE s.task_id = t.id AND s.position IS NOT NULL), " +
+		"COALESCE((SELECT e.status FROM execution e WHERE e.task_id = t.id ORDER BY e.id DESC LIMIT 1), '') " +
+		"FROM task t"
 )
 
+// likeEscaper escapes the LIKE wildcards of a search with !, the escape character every dialect accepts
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
 func (r repository) SaveTask(ctx context.Context, task entities.Task) (savedTask entities.Task, err error) {
 	ctx, err = r.db.Begin(ctx)
 	if err != nil {
@@ -26,12 +40,12 @@ func (r repository) SaveTask(ctx context.Context, task entities.Task) (savedTask
 	defer func() {
 		if err != nil {
 			if err := r.db.Rollback(ctx); err != nil {
-				log.Println("TRANSACTION ERROR: rollbacking save task tx")
+				slog.ErrorContext(ctx, "rollbacking save task transaction", "error", err)
 			}
 		}
 	}()
 
-	result, err := r.db.ExecContext(ctx, InsertTaskQr, task.Name)
+	result, err := r.db.InsertContext(ctx, InsertTaskQr, workspaceID(ctx), task.Name)
 	if err != nil {
 		return
 	}
@@ -59,6 +73,7 @@ func (r repository) SaveTask(ctx context.Context, task entities.Task) (savedTask
 	}
 
 	task.ID = int(taskID)
+	task.WorkspaceID = workspaceID(ctx)
 	task.Steps = steps
 	return task, nil
 }
@@ -66,7 +81,7 @@ func (r repository) SaveTask(ctx context.Context, task entities.Task) (savedTask
 // saveSteps saves each steps with their order field and failure steps
 func (r repository) saveSteps(ctx context.Context, steps []entities.Step, taskID int) ([]entities.Step, error) {
 	// Prepare the SQL statement
-	stmt, err := r.db.PrepareContext(ctx, InsertStepQr)
+	stmt, err := r.db.PrepareInsertContext(ctx, InsertStepQr)
 	if err != nil {
 		return []entities.Step{}, fmt.Errorf("preparing insert steps stmt: %w", err)
 	}
@@ -86,9 +101,9 @@ func (r repository) saveSteps(ctx context.Context, steps []entities.Step, taskID
 		// we insert it with a foreign key to a failure step depending on it existence
 		var result sql.Result = nil
 		if failureStep != nil {
-			result, err = stmt.Exec(taskID, step.Type, toJSON(step.Params), failureStep.ID, position)
+			result, err = stmt.Exec(taskID, step.Type, toJSON(ctx, step.Params), failureStep.ID, position)
 		} else {
-			result, err = stmt.Exec(taskID, step.Type, toJSON(step.Params), nil, position)
+			result, err = stmt.Exec(taskID, step.Type, toJSON(ctx, step.Params), nil, position)
 		}
 		if err != nil {
 			return []entities.Step{}, err
@@ -110,7 +125,7 @@ func (r repository) insertFailureStep(ctx context.Context, step entities.Step, t
 	step.FailureStep = nil //Only one failure step, nested failure steps are not allowed
 
 	//Failure steps has a position NULL to differentiate them from normal steps
-	result, err := r.db.ExecContext(ctx, InsertStepQr, taskID, step.Type, toJSON(step.Params), nil, nil)
+	result, err := r.db.InsertContext(ctx, InsertStepQr, taskID, step.Type, toJSON(ctx, step.Params), nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("inserting failure step: %w", err)
 	}
@@ -133,8 +148,8 @@ func (r repository) insertFailureStep(ctx context.Context, step entities.Step, t
 }
 
 func (r repository) GetTask(ctx context.Context, taskID int) (entities.Task, error) {
-	task := entities.Task{}
-	err := r.db.QueryRowContext(ctx, GetTaskQr, taskID).Scan(&task.ID, &task.Name)
+	task := entities.Task{WorkspaceID: workspaceID(ctx)}
+	err := r.db.QueryRowContext(ctx, GetTaskQr, taskID, task.WorkspaceID).Scan(&task.ID, &task.Name)
 	switch {
 	case err == sql.ErrNoRows:
 		return entities.Task{}, http.WrapError(err, http.ErrNotFound.WithMessage("task not found"))
@@ -155,6 +170,79 @@ func (r repository) GetTask(ctx context.Context, taskID int) (entities.Task, err
 	return task, nil
 }
 
+// GetTaskByName reads the task with the name, tasks aren't required to have unique names so it fails with a conflict
+// when more than one has it
+func (r repository) GetTaskByName(ctx context.Context, name string) (entities.Task, error) {
+	rows, err := r.db.QueryContext(ctx, GetTaskIDsByNameQr, name, workspaceID(ctx))
+	if err != nil {
+		return entities.Task{}, fmt.Errorf("getting task by name: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return entities.Task{}, fmt.Errorf("scanning task id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return entities.Task{}, fmt.Errorf("getting task by name: %w", err)
+	}
+
+	switch len(ids) {
+	case 0:
+		return entities.Task{}, http.ErrNotFound.WithMessage("task not found")
+	case 1:
+		return r.GetTask(ctx, ids[0])
+	default:
+		return entities.Task{}, http.ErrConflict.WithMessage(fmt.Sprintf("more than one task is named %s", name))
+	}
+}
+
+// UpdateTaskSteps replaces the steps of the task with the given ones, which get new IDs
+func (r repository) UpdateTaskSteps(ctx context.Context, taskID int, steps []entities.Step) (savedSteps []entities.Step, err error) {
+	ctx, err = r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("starting task steps update transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if err := r.db.Rollback(ctx); err != nil {
+				slog.ErrorContext(ctx, "rollbacking update task steps transaction", "error", err)
+			}
+		}
+	}()
+
+	// the task is read first so the steps of other workspaces can't be touched
+	var task entities.Task
+	err = r.db.QueryRowContext(ctx, GetTaskQr, taskID, workspaceID(ctx)).Scan(&task.ID, &task.Name)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, http.WrapError(err, http.ErrNotFound.WithMessage("task not found"))
+	case err != nil:
+		return nil, fmt.Errorf("getting task: %w", err)
+	}
+
+	if _, err = r.db.ExecContext(ctx, UnlinkFailureStepsQr, taskID); err != nil {
+		return nil, fmt.Errorf("unlinking failure steps: %w", err)
+	}
+	if _, err = r.db.ExecContext(ctx, DeleteStepsQr, taskID); err != nil {
+		return nil, fmt.Errorf("deleting steps: %w", err)
+	}
+
+	savedSteps, err = r.saveSteps(ctx, steps, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.db.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return savedSteps, nil
+}
+
 func (r repository) getSteps(ctx context.Context, taskID int) ([]entities.Step, error) {
 	rows, err := r.db.QueryContext(ctx, GetStepsQr, taskID)
 	if err != nil {
@@ -171,7 +259,7 @@ func (r repository) getSteps(ctx context.Context, taskID int) ([]entities.Step,
 		}
 
 		if err = json.Unmarshal(jsonParams, &DBStep.Params); err != nil {
-			log.Printf("Error unmarshalling JSON: %s. The steps params got corrupted on the DB", err)
+			slog.ErrorContext(ctx, "unmarshalling step params, the params got corrupted on the DB", "step_id", DBStep.ID, "error", err)
 		}
 
 		//check if it is a failure step of the task
@@ -203,10 +291,60 @@ func (r repository) getSteps(ctx context.Context, taskID int) ([]entities.Step,
 	return steps, nil
 }
 
-func toJSON(v any) string {
+// GetTasks returns the summaries of the tasks matching the filter, by ID
+func (r repository) GetTasks(ctx context.Context, filter entities.TaskFilter) ([]entities.TaskSummary, error) {
+	conditions := []string{"t.workspace_id = ?"}
+	args := []any{workspaceID(ctx)}
+	if filter.AfterID > 0 {
+		conditions = append(conditions, "t.id > ?")
+		args = append(args, filter.AfterID)
+	}
+	if filter.Name != "" {
+		conditions = append(conditions, "LOWER(t.name) LIKE ? ESCAPE '!'")
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(filter.Name))+"%")
+	}
+	if filter.StepType != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM step s WHERE s.task_id = t.id AND s.step_type = ?)")
+		args = append(args, filter.StepType)
+	}
+	if filter.Scheduled != nil {
+		scheduled := "EXISTS (SELECT 1 FROM scheduled_task st WHERE st.task_id = t.id AND st.enabled = true)"
+		if !*filter.Scheduled {
+			scheduled = "NOT " + scheduled
+		}
+		conditions = append(conditions, scheduled)
+	}
+
+	query := GetTaskSummariesQr + " WHERE " + strings.Join(conditions, " AND ") + " ORDER BY t.id"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("getting tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []entities.TaskSummary{}
+	for rows.Next() {
+		var task entities.TaskSummary
+		if err := rows.Scan(&task.ID, &task.Name, &task.StepCount, &task.LatestExecutionStatus); err != nil {
+			return nil, fmt.Errorf("scanning task summary: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+func toJSON(ctx context.Context, v any) string {
 	jsonData, err := json.Marshal(v)
 	if err != nil {
-		log.Println("Error marshalling JSON:", err)
+		slog.ErrorContext(ctx, "marshalling JSON", "error", err)
 		return ""
 	}
 	return string(jsonData)
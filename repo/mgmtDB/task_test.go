@@ -329,7 +329,7 @@ func TestGetTasks_ErrorGettingTask(t *testing.T) {
 	ctx := context.Background()
 	taskID := 1
 
-	mock.ExpectQuery("^SELECT\\s+\\*\\s+FROM\\s+task\\b\n").WithArgs(taskID).WillReturnError(errors.New("query error"))
+	mock.ExpectQuery("^SELECT id, name FROM task WHERE id = \\? AND workspace_id = \\?$").WithArgs(taskID, entities.DefaultWorkspaceID).WillReturnError(errors.New("query error"))
 
 	_, err = repo.GetTask(ctx, taskID)
 
@@ -350,7 +350,7 @@ func TestGetTasks_TaskNotFound(t *testing.T) {
 	ctx := context.Background()
 	taskID := 1
 
-	mock.ExpectQuery("^SELECT\\s+\\*\\s+FROM\\s+task\\b\n").WithArgs(taskID).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("^SELECT id, name FROM task WHERE id = \\? AND workspace_id = \\?$").WithArgs(taskID, entities.DefaultWorkspaceID).WillReturnError(sql.ErrNoRows)
 
 	_, err = repo.GetTask(ctx, taskID)
 
@@ -371,7 +371,7 @@ func TestGetTasks_ErrorGettingSteps(t *testing.T) {
 	ctx := context.Background()
 	taskID := 1
 
-	mock.ExpectQuery("^SELECT\\s+\\*\\s+FROM\\s+task\\b\n").WithArgs(taskID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
+	mock.ExpectQuery("^SELECT id, name FROM task WHERE id = \\? AND workspace_id = \\?$").WithArgs(taskID, entities.DefaultWorkspaceID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
 	mock.ExpectQuery("SELECT id, step_type, params, failure_step, position FROM step").WithArgs(taskID).WillReturnError(errors.New("query error"))
 
 	_, err = repo.GetTask(ctx, taskID)
@@ -393,7 +393,7 @@ func TestGetTasks_ErrorScanningSteps(t *testing.T) {
 	ctx := context.Background()
 	taskID := 1
 
-	mock.ExpectQuery("^SELECT\\s+\\*\\s+FROM\\s+task\\b\n").WithArgs(taskID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
+	mock.ExpectQuery("^SELECT id, name FROM task WHERE id = \\? AND workspace_id = \\?$").WithArgs(taskID, entities.DefaultWorkspaceID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
 	mock.ExpectQuery("SELECT id, step_type, params, failure_step, position FROM step").WithArgs(taskID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 	_, err = repo.GetTask(ctx, taskID)
@@ -415,7 +415,7 @@ func TestGetTasks_ErrorFindingFailureStep(t *testing.T) {
 	ctx := context.Background()
 	taskID := 1
 
-	mock.ExpectQuery("^SELECT\\s+\\*\\s+FROM\\s+task\\b\n").WithArgs(taskID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
+	mock.ExpectQuery("^SELECT id, name FROM task WHERE id = \\? AND workspace_id = \\?$").WithArgs(taskID, entities.DefaultWorkspaceID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
 	mock.ExpectQuery("SELECT id, step_type, params, failure_step, position FROM step").WithArgs(taskID).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "params", "failure_step", "position"}).AddRow(555, "API", "{.", nil, nil).AddRow(1, "fake_type", "{.", 333, 1))
 
 	_, err = repo.GetTask(ctx, taskID)
@@ -437,13 +437,13 @@ func TestGetTasks_InvalidTask(t *testing.T) {
 	ctx := context.Background()
 	taskID := 1
 
-	mock.ExpectQuery("^SELECT\\s+\\*\\s+FROM\\s+task\\b\n").WithArgs(taskID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
+	mock.ExpectQuery("^SELECT id, name FROM task WHERE id = \\? AND workspace_id = \\?$").WithArgs(taskID, entities.DefaultWorkspaceID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
 	mock.ExpectQuery("SELECT id, step_type, params, failure_step, position FROM step").WithArgs(taskID).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "params", "failure_step", "position"}).AddRow(5, "API", "{.", nil, nil).AddRow(1, "fake_type", "{.", 5, 1))
 
 	_, err = repo.GetTask(ctx, taskID)
 
 	assert.Error(t, err)
-	assert.Equal(t, "validating read task: step must have a valid step type", err.Error())
+	assert.Equal(t, "validating read task: steps[0].type must be a valid step type; steps[0].params must not be empty; steps[0].failure_step.type must be a valid step type; steps[0].failure_step.params must not be empty", err.Error())
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -459,14 +459,15 @@ func TestGetTasks_Success(t *testing.T) {
 	ctx := context.Background()
 	taskID := 1
 
-	mock.ExpectQuery("^SELECT\\s+\\*\\s+FROM\\s+task\\b\n").WithArgs(taskID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
+	mock.ExpectQuery("^SELECT id, name FROM task WHERE id = \\? AND workspace_id = \\?$").WithArgs(taskID, entities.DefaultWorkspaceID).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Task"))
 	mock.ExpectQuery("SELECT id, step_type, params, failure_step, position FROM step").WithArgs(taskID).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "params", "failure_step", "position"}).AddRow(5, "api_call", `{"a":"b"}`, nil, nil).AddRow(1, "api_call", `{"a":"b"}`, 5, 1))
 
 	task, err := repo.GetTask(ctx, taskID)
 
 	expectedTask := entities.Task{
-		ID:   1,
-		Name: "Test Task",
+		ID:          1,
+		WorkspaceID: entities.DefaultWorkspaceID,
+		Name:        "Test Task",
 		Steps: []entities.Step{
 			{
 				ID:     1,
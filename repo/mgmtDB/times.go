@@ -0,0 +1,41 @@
+package mgmtDB
+
+import (
+	"fmt"
+	"time"
+)
+
+// dbTimeLayouts are the text formats the drivers that don't parse dates return them with
+var dbTimeLayouts = []string{time.DateTime, "2006-01-02 15:04:05.999999999-07:00", time.RFC3339Nano}
+
+// dbTime scans a nullable date column from any dialect, MySQL returns them as text while SQLite and PostgreSQL
+// return time.Time
+type dbTime struct {
+	Time *time.Time
+}
+
+func (t *dbTime) Scan(value any) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		t.Time = nil
+		return nil
+	case time.Time:
+		t.Time = &v
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("unsupported date type %T", value)
+	}
+
+	for _, layout := range dbTimeLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			t.Time = &parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported date format %q", text)
+}
@@ -4,16 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 )
 
 var txKey = "tx_key"
 
-// Basic db wrapper to handle transaction with the context
+// Basic db wrapper to handle transaction with the context and the dialect placeholders
 type dbTransactionAware struct {
-	db DataBase
+	db      DataBase
+	dialect Dialect
 }
 
 func (d dbTransactionAware) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	query = d.dialect.rebind(query)
 	if tx := getTx(ctx); tx != nil {
 		//return tx.ExecContext(ctx, query, args)
 		return tx.ExecContext(ctx, query, args...)
@@ -22,6 +25,7 @@ func (d dbTransactionAware) ExecContext(ctx context.Context, query string, args
 }
 
 func (d dbTransactionAware) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	query = d.dialect.rebind(query)
 	if tx := getTx(ctx); tx != nil {
 		return tx.PrepareContext(ctx, query)
 	}
@@ -29,6 +33,7 @@ func (d dbTransactionAware) PrepareContext(ctx context.Context, query string) (*
 }
 
 func (d dbTransactionAware) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	query = d.dialect.rebind(query)
 	if tx := getTx(ctx); tx != nil {
 		return tx.QueryRowContext(ctx, query, args...)
 	}
@@ -36,13 +41,46 @@ func (d dbTransactionAware) QueryRowContext(ctx context.Context, query string, a
 }
 
 func (d dbTransactionAware) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	query = d.dialect.rebind(query)
 	if tx := getTx(ctx); tx != nil {
 		return tx.QueryContext(ctx, query, args...)
 	}
 	return d.db.QueryContext(ctx, query, args...)
 }
 
+// InsertContext runs an insert whose result LastInsertId works on every dialect
+func (d dbTransactionAware) InsertContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if !d.dialect.returningID {
+		return d.ExecContext(ctx, query, args...)
+	}
+
+	var id int64
+	if err := d.QueryRowContext(ctx, returningIDQuery(query), args...).Scan(&id); err != nil {
+		return nil, err
+	}
+	return insertResult{id: id}, nil
+}
+
+// PrepareInsertContext prepares an insert whose results LastInsertId works on every dialect
+func (d dbTransactionAware) PrepareInsertContext(ctx context.Context, query string) (Stmt, error) {
+	if !d.dialect.returningID {
+		return d.PrepareContext(ctx, query)
+	}
+
+	stmt, err := d.PrepareContext(ctx, returningIDQuery(query))
+	if err != nil {
+		return nil, err
+	}
+	return returningIDStmt{stmt: stmt}, nil
+}
+
+// Begin starts a transaction on the returned context. When the context already has one it's joined instead, and the
+// Commit and Rollback of the inner Begin are left to the outer one
 func (d dbTransactionAware) Begin(ctx context.Context) (context.Context, error) {
+	if tx := getTx(ctx); tx != nil {
+		return context.WithValue(ctx, txKey, joinedTx{tx: tx}), nil
+	}
+
 	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
 		return ctx, err
@@ -52,6 +90,9 @@ func (d dbTransactionAware) Begin(ctx context.Context) (context.Context, error)
 }
 
 func (d dbTransactionAware) Commit(ctx context.Context) error {
+	if _, joined := ctx.Value(txKey).(joinedTx); joined {
+		return nil
+	}
 	tx := getTx(ctx)
 	if tx == nil {
 		return errors.New("transaction not found, failed to commit")
@@ -61,6 +102,9 @@ func (d dbTransactionAware) Commit(ctx context.Context) error {
 }
 
 func (d dbTransactionAware) Rollback(ctx context.Context) error {
+	if _, joined := ctx.Value(txKey).(joinedTx); joined {
+		return nil
+	}
 	tx := getTx(ctx)
 	if tx == nil {
 		return errors.New("transaction not found, failed to rollback")
@@ -69,11 +113,55 @@ func (d dbTransactionAware) Rollback(ctx context.Context) error {
 	return tx.Rollback()
 }
 
+// joinedTx is a transaction begun inside another one, the outer one commits or rolls it back
+type joinedTx struct {
+	tx *sql.Tx
+}
+
 func getTx(ctx context.Context) *sql.Tx {
-	txValue := ctx.Value(txKey)
-	if txValue == nil {
+	switch tx := ctx.Value(txKey).(type) {
+	case *sql.Tx:
+		return tx
+	case joinedTx:
+		return tx.tx
+	default:
 		return nil
 	}
-	tx, _ := txValue.(*sql.Tx) //if the assertion fails it will set tx to nil
-	return tx
+}
+
+func returningIDQuery(query string) string {
+	return strings.TrimSuffix(strings.TrimSpace(query), ";") + " RETURNING id"
+}
+
+// insertResult is the result of the inserts made with a RETURNING clause, they always insert a single row
+type insertResult struct {
+	id int64
+}
+
+func (r insertResult) LastInsertId() (int64, error) {
+	return r.id, nil
+}
+
+func (r insertResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+type returningIDStmt struct {
+	stmt *sql.Stmt
+}
+
+func (s returningIDStmt) Exec(args ...any) (sql.Result, error) {
+	var id int64
+	if err := s.stmt.QueryRow(args...).Scan(&id); err != nil {
+		return nil, err
+	}
+	return insertResult{id: id}, nil
+}
+
+func (s returningIDStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (d dbTransactionAware) PingContext(ctx context.Context) error {
+	return d.db.PingContext(ctx)
 }
@@ -0,0 +1,85 @@
+package mgmtDB
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+	"github.com/tasker/service"
+)
+
+const (
+	InsertWorkspaceQr = "INSERT INTO workspace (name, created_at) VALUES (?, ?)"
+	GetWorkspacesQr   = "SELECT id, name, created_at FROM workspace"
+)
+
+// workspaceID is the workspace every query of the context reads and writes on
+func workspaceID(ctx context.Context) int {
+	id, _ := service.WorkspaceFromContext(ctx)
+	return id
+}
+
+func (r repository) SaveWorkspace(ctx context.Context, workspace entities.Workspace) (entities.Workspace, error) {
+	workspace.CreatedAt = workspace.CreatedAt.UTC()
+	result, err := r.db.InsertContext(ctx, InsertWorkspaceQr, workspace.Name, workspace.CreatedAt)
+	if err != nil {
+		return entities.Workspace{}, fmt.Errorf("inserting workspace: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return entities.Workspace{}, err
+	}
+
+	workspace.ID = int(id)
+	return workspace, nil
+}
+
+func (r repository) GetWorkspaces(ctx context.Context) ([]entities.Workspace, error) {
+	rows, err := r.db.QueryContext(ctx, GetWorkspacesQr+" ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("getting workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	workspaces := []entities.Workspace{}
+	for rows.Next() {
+		workspace, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning workspace: %w", err)
+		}
+		workspaces = append(workspaces, workspace)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading workspaces: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+func (r repository) GetWorkspaceByName(ctx context.Context, name string) (entities.Workspace, error) {
+	workspace, err := scanWorkspace(r.db.QueryRowContext(ctx, GetWorkspacesQr+" WHERE name = ?", name))
+	switch {
+	case err == sql.ErrNoRows:
+		return entities.Workspace{}, http.WrapError(err, http.ErrNotFound.WithMessage("workspace not found"))
+	case err != nil:
+		return entities.Workspace{}, fmt.Errorf("getting workspace: %w", err)
+	}
+
+	return workspace, nil
+}
+
+func scanWorkspace(row rowScanner) (entities.Workspace, error) {
+	var workspace entities.Workspace
+	var createdAt dbTime
+	if err := row.Scan(&workspace.ID, &workspace.Name, &createdAt); err != nil {
+		return entities.Workspace{}, err
+	}
+
+	if createdAt.Time != nil {
+		workspace.CreatedAt = createdAt.Time.UTC()
+	}
+	return workspace, nil
+}
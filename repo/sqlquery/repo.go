@@ -0,0 +1,32 @@
+package sqlquery
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Repository interface {
+	Query(ctx context.Context, dataSource, query string, args []any, readOnly bool) ([]map[string]any, error)
+	Exec(ctx context.Context, dataSource, query string, args []any) (int64, error)
+}
+
+type DataBase interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// DataSource is a named database the sql query steps can run statements against
+type DataSource struct {
+	DB DataBase
+	// ReadOnly data sources only accept queries, always run inside read only transactions
+	ReadOnly bool
+}
+
+type repository struct {
+	dataSources map[string]DataSource
+}
+
+func NewRepository(dataSources map[string]DataSource) Repository {
+	return &repository{dataSources: dataSources}
+}
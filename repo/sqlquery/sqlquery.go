@@ -0,0 +1,105 @@
+package sqlquery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tasker/http"
+)
+
+const maxRows = 1000
+
+func (r repository) Query(ctx context.Context, dataSource, query string, args []any, readOnly bool) ([]map[string]any, error) {
+	source, err := r.getDataSource(dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	if !readOnly && !source.ReadOnly {
+		rows, err := source.DB.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("querying: %w", err)
+		}
+		return scanRows(rows)
+	}
+
+	// Read only queries run inside a read only transaction that is always rolled back
+	tx, err := source.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("starting read only transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+	return scanRows(rows)
+}
+
+func (r repository) Exec(ctx context.Context, dataSource, query string, args []any) (int64, error) {
+	source, err := r.getDataSource(dataSource)
+	if err != nil {
+		return 0, err
+	}
+	if source.ReadOnly {
+		return 0, fmt.Errorf("data source %s is read only", dataSource)
+	}
+
+	result, err := source.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("executing: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r repository) getDataSource(name string) (DataSource, error) {
+	source, found := r.dataSources[name]
+	if !found {
+		return DataSource{}, http.WrapError(fmt.Errorf("data source %s is not configured", name), http.ErrNotFound.WithMessage("data source not found"))
+	}
+	return source, nil
+}
+
+// scanRows reads every row as a column name to value map, text columns are returned as strings
+func scanRows(rows *sql.Rows) ([]map[string]any, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("getting columns: %w", err)
+	}
+
+	result := []map[string]any{}
+	for rows.Next() {
+		if len(result) == maxRows {
+			return nil, fmt.Errorf("query returned more than %d rows", maxRows)
+		}
+
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, isBytes := values[i].([]byte); isBytes {
+				row[column] = string(b)
+				continue
+			}
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package sqlquery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/http"
+)
+
+func TestQuery_UnknownDataSource(t *testing.T) {
+	repo := NewRepository(map[string]DataSource{})
+
+	_, err := repo.Query(context.Background(), "reporting", "SELECT 1", nil, false)
+
+	assert.True(t, http.IsNotFoundErr(err))
+}
+
+func TestQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRepository(map[string]DataSource{"reporting": {DB: db}})
+
+	mock.ExpectQuery("SELECT id, name FROM pending").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, []byte("first")).AddRow(2, "second"))
+
+	rows, err := repo.Query(context.Background(), "reporting", "SELECT id, name FROM pending WHERE status = ?", []any{1}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"id": int64(1), "name": "first"}, {"id": int64(2), "name": "second"}}, rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQuery_ReadOnlyRunsInsideRolledBackTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRepository(map[string]DataSource{"reporting": {DB: db, ReadOnly: true}})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectRollback()
+
+	rows, err := repo.Query(context.Background(), "reporting", "SELECT count(*) AS count FROM pending", nil, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"count": int64(3)}}, rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExec_ReadOnlyDataSource(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRepository(map[string]DataSource{"reporting": {DB: db, ReadOnly: true}})
+
+	_, err = repo.Exec(context.Background(), "reporting", "DELETE FROM pending", nil)
+
+	assert.EqualError(t, err, "data source reporting is read only")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExec(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRepository(map[string]DataSource{"reporting": {DB: db}})
+
+	mock.ExpectExec("INSERT INTO report").WithArgs("ok").WillReturnResult(sqlmock.NewResult(7, 1))
+
+	affected, err := repo.Exec(context.Background(), "reporting", "INSERT INTO report (status) VALUES (?)", []any{"ok"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
@@ -56,7 +56,7 @@ func (a stepRunner) RunStep(ctx context.Context, params map[string]string) (stri
 	headersJSON, found := params[headersParam]
 	if found {
 		if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
-			log.Printf("Error unmarshalling headers: %s. Executing api call without provided headers", err)
+			slog.WarnContext(ctx, "unmarshalling headers, executing api call without provided headers", "error", err)
 		}
 	}
 
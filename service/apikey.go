@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+)
+
+const (
+	// apiKeyPrefix tells the tasker keys apart from other credentials, e.g. on secret scanners
+	apiKeyPrefix = "tsk_"
+	// apiKeyShownPrefix is how many characters of the key are kept to identify it
+	apiKeyShownPrefix = len(apiKeyPrefix) + 8
+)
+
+type apiKeyKey struct{}
+
+// ContextWithAPIKey sets the API key that authenticated the request
+func ContextWithAPIKey(ctx context.Context, key entities.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFromContext returns the API key that authenticated the request, found is false without authentication
+func APIKeyFromContext(ctx context.Context) (entities.APIKey, bool) {
+	key, found := ctx.Value(apiKeyKey{}).(entities.APIKey)
+	return key, found
+}
+
+// CreateAPIKey generates and saves a key, the returned secret is the key itself and it can't be retrieved again. A key
+// can only create keys with the scopes it holds, so it can't escalate its own access
+func (s service) CreateAPIKey(ctx context.Context, key entities.APIKey) (entities.APIKey, string, error) {
+	if caller, found := APIKeyFromContext(ctx); found {
+		for _, scope := range key.Scopes {
+			if !caller.HasScope(scope) {
+				return entities.APIKey{}, "", http.ErrForbidden.WithMessage(fmt.Sprintf("api key lacks the %s scope, it can't grant it", scope))
+			}
+		}
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return entities.APIKey{}, "", fmt.Errorf("generating api key: %w", err)
+	}
+	secret := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	key.Prefix = secret[:apiKeyShownPrefix]
+	key.CreatedAt = time.Now().UTC()
+	key.RevokedAt = nil
+	key, err := s.storage.SaveAPIKey(ctx, key, hashAPIKey(secret))
+	if err != nil {
+		return entities.APIKey{}, "", fmt.Errorf("saving api key: %w", err)
+	}
+	s.audit(ctx, entities.AuditEvent{Action: entities.CreateAuditAction, EntityType: entities.APIKeyAuditEntity, EntityID: strconv.Itoa(key.ID)}, nil, key)
+
+	return key, secret, nil
+}
+
+func (s service) GetAPIKeys(ctx context.Context) ([]entities.APIKey, error) {
+	keys, err := s.storage.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey rejects the key from now on, revoked keys are kept for the audit log
+func (s service) RevokeAPIKey(ctx context.Context, keyID int) error {
+	key, err := s.storage.GetAPIKey(ctx, keyID)
+	if err != nil {
+		return fmt.Errorf("getting api key: %w", err)
+	}
+
+	revokedAt := time.Now().UTC()
+	if err := s.storage.RevokeAPIKey(ctx, keyID, revokedAt); err != nil {
+		return fmt.Errorf("revoking api key: %w", err)
+	}
+	revoked := key
+	revoked.RevokedAt = &revokedAt
+	s.audit(ctx, entities.AuditEvent{Action: entities.UpdateAuditAction, EntityType: entities.APIKeyAuditEntity, EntityID: strconv.Itoa(keyID)}, key, revoked)
+
+	return nil
+}
+
+// Authenticate returns the active API key whose secret is given
+func (s service) Authenticate(ctx context.Context, secret string) (entities.APIKey, error) {
+	if secret == "" {
+		return entities.APIKey{}, http.ErrUnauthorized.WithMessage("missing api key")
+	}
+
+	key, err := s.storage.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	switch {
+	case http.IsNotFoundErr(err):
+		return entities.APIKey{}, http.WrapError(errors.New("unknown api key"), http.ErrUnauthorized.WithMessage("invalid api key"))
+	case err != nil:
+		return entities.APIKey{}, fmt.Errorf("getting api key: %w", err)
+	case key.RevokedAt != nil:
+		return entities.APIKey{}, http.WrapError(fmt.Errorf("api key %d is revoked", key.ID), http.ErrUnauthorized.WithMessage("invalid api key"))
+	}
+
+	return key, nil
+}
+
+// hashAPIKey hashes the keys with SHA-256, they are random enough not to need a slow hash
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+)
+
+func Test_service_CreateAPIKey(t *testing.T) {
+	auditLog := &recordingAuditLog{}
+	mockStorage := MockStorage{}
+	srv := NewService(&mockStorage, emptyStepRunners, WithAuditLog(auditLog))
+
+	var savedHash string
+	mockStorage.On("SaveAPIKey", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		savedHash = args.String(2)
+	}).Return(entities.APIKey{ID: 4, Name: "ci"}, nil)
+
+	key, secret, err := srv.CreateAPIKey(context.Background(), entities.APIKey{Name: "ci", Scopes: []entities.Scope{entities.ExecutionsRunScope}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, key.ID)
+	assert.True(t, strings.HasPrefix(secret, apiKeyPrefix))
+	//Only the hash is stored, with the key prefix to identify it
+	assert.Equal(t, hashAPIKey(secret), savedHash)
+	savedKey := mockStorage.Calls[0].Arguments.Get(1).(entities.APIKey)
+	assert.Equal(t, secret[:apiKeyShownPrefix], savedKey.Prefix)
+	assert.NotContains(t, string(auditLog.events[0].After), secret)
+}
+
+func Test_service_Authenticate(t *testing.T) {
+	revokedAt := time.Now()
+	activeKey := entities.APIKey{ID: 1, Name: "ci", Scopes: []entities.Scope{entities.TasksReadScope}}
+	mockStorage := MockStorage{}
+	mockStorage.On("GetAPIKeyByHash", mock.Anything, hashAPIKey("tsk_active")).Return(activeKey, nil)
+	mockStorage.On("GetAPIKeyByHash", mock.Anything, hashAPIKey("tsk_revoked")).Return(entities.APIKey{ID: 2, RevokedAt: &revokedAt}, nil)
+	mockStorage.On("GetAPIKeyByHash", mock.Anything, hashAPIKey("tsk_unknown")).Return(entities.APIKey{}, http.ErrNotFound)
+	srv := NewService(&mockStorage, emptyStepRunners)
+
+	key, err := srv.Authenticate(context.Background(), "tsk_active")
+	assert.NoError(t, err)
+	assert.Equal(t, activeKey, key)
+	assert.Equal(t, "ci", ActorFromContext(ContextWithAPIKey(ContextWithActor(context.Background(), "spoofed"), key)))
+
+	for _, secret := range []string{"", "tsk_revoked", "tsk_unknown"} {
+		_, err := srv.Authenticate(context.Background(), secret)
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError, secret) {
+			status, _ := httpError.StatusAndMsg()
+			assert.Equal(t, 401, status, secret)
+		}
+	}
+}
+
+func Test_service_RevokeAPIKey_Audited(t *testing.T) {
+	auditLog := &recordingAuditLog{}
+	mockStorage := MockStorage{}
+	mockStorage.On("GetAPIKey", mock.Anything, 4).Return(entities.APIKey{ID: 4, Name: "ci"}, nil)
+	mockStorage.On("RevokeAPIKey", mock.Anything, 4, mock.Anything).Return(nil)
+	srv := NewService(&mockStorage, emptyStepRunners, WithAuditLog(auditLog))
+
+	err := srv.RevokeAPIKey(ContextWithActor(context.Background(), "admin"), 4)
+
+	assert.NoError(t, err)
+	if assert.Len(t, auditLog.events, 1) {
+		assert.Equal(t, entities.UpdateAuditAction, auditLog.events[0].Action)
+		assert.Equal(t, "admin", auditLog.events[0].Actor)
+		assert.Contains(t, string(auditLog.events[0].Diff), "revoked_at")
+	}
+	mockStorage.AssertExpectations(t)
+}
+
+func Test_service_CreateAPIKey_CallerScopes(t *testing.T) {
+	caller := entities.APIKey{ID: 1, Scopes: []entities.Scope{entities.APIKeysAdminScope, entities.ExecutionsRunScope}}
+	ctx := ContextWithAPIKey(context.Background(), caller)
+
+	t.Run("scopes of the caller", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("SaveAPIKey", mock.Anything, mock.Anything, mock.Anything).Return(entities.APIKey{ID: 4, Name: "ci"}, nil)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, _, err := srv.CreateAPIKey(ctx, entities.APIKey{Name: "ci", Scopes: []entities.Scope{entities.ExecutionsRunScope}})
+
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("scope the caller lacks", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, _, err := srv.CreateAPIKey(ctx, entities.APIKey{Name: "ci", Scopes: []entities.Scope{entities.ExecutionsRunScope, entities.TasksWriteScope}})
+
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError) {
+			assert.Equal(t, "forbidden", httpError.Code())
+		}
+		mockStorage.AssertNotCalled(t, "SaveAPIKey", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/tasker/entities"
+)
+
+// AnonymousActor is the actor of the changes made without one on the context
+const AnonymousActor = "anonymous"
+
+var errAuditDisabled = errors.New("audit log is not configured")
+
+// AuditLog stores who changed tasks, schedules and secrets
+type AuditLog interface {
+	SaveAuditEvent(ctx context.Context, event entities.AuditEvent) error
+	GetAuditEvents(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error)
+}
+
+// WithAuditLog records an audit event for every change, without it changes aren't audited
+func WithAuditLog(a AuditLog) Option {
+	return func(s *service) {
+		s.auditLog = a
+	}
+}
+
+type actorKey struct{}
+
+// ContextWithActor sets who is making the changes
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns who is making the changes: the name of the API key that authenticated the request, else
+// the actor set on the context or AnonymousActor
+func ActorFromContext(ctx context.Context) string {
+	if key, found := APIKeyFromContext(ctx); found {
+		return key.Name
+	}
+	if actor, _ := ctx.Value(actorKey{}).(string); actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+func (s service) GetAuditEvents(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error) {
+	if s.auditLog == nil {
+		return nil, errAuditDisabled
+	}
+
+	events, err := s.auditLog.GetAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("getting audit events: %w", err)
+	}
+
+	return events, nil
+}
+
+// audit records the change described by event, before is nil on creations and after on deletions. The change is
+// already saved, so failing to record it is logged instead of failing the operation
+func (s service) audit(ctx context.Context, event entities.AuditEvent, before, after any) {
+	if s.auditLog == nil {
+		return
+	}
+
+	event.Time = time.Now().UTC()
+	event.Actor = ActorFromContext(ctx)
+	var err error
+	if event.Before, err = marshalAuditState(before); err == nil {
+		if event.After, err = marshalAuditState(after); err == nil {
+			event.Diff, err = auditDiff(event.Before, event.After)
+		}
+	}
+	if err == nil {
+		err = s.auditLog.SaveAuditEvent(ctx, event)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "recording audit event", "action", event.Action, "entity", event.EntityType, "entity_id", event.EntityID, "error", err)
+	}
+}
+
+// scheduleAuditState is the schedule as the API receives it, only the task ID is audited
+func scheduleAuditState(sch entities.ScheduledTask) map[string]any {
+	return map[string]any{
+		"id":      sch.ID,
+		"name":    sch.Name,
+		"cron":    sch.Cron,
+		"retries": sch.Retries,
+		"task_id": sch.Task.ID,
+		"enabled": sch.Enabled,
+	}
+}
+
+// secretAuditState never holds the secret value, neither encrypted
+func secretAuditState(name string) map[string]any {
+	return map[string]any{"name": name}
+}
+
+func marshalAuditState(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+type auditChange struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// auditDiff returns the top level fields that differ between the before and after JSON objects
+func auditDiff(before, after json.RawMessage) (json.RawMessage, error) {
+	beforeFields, afterFields := map[string]json.RawMessage{}, map[string]json.RawMessage{}
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &beforeFields); err != nil {
+			return nil, fmt.Errorf("decoding before state: %w", err)
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &afterFields); err != nil {
+			return nil, fmt.Errorf("decoding after state: %w", err)
+		}
+	}
+
+	fields := make([]string, 0, len(beforeFields)+len(afterFields))
+	for field := range beforeFields {
+		fields = append(fields, field)
+	}
+	for field := range afterFields {
+		if _, found := beforeFields[field]; !found {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diff := map[string]auditChange{}
+	for _, field := range fields {
+		b, a := beforeFields[field], afterFields[field]
+		if !bytes.Equal(b, a) {
+			diff[field] = auditChange{Before: b, After: a}
+		}
+	}
+	return json.Marshal(diff)
+}
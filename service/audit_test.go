@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/entities"
+	"github.com/tasker/service/secrets"
+)
+
+// recordingAuditLog keeps the saved events in memory
+type recordingAuditLog struct {
+	events []entities.AuditEvent
+	err    error
+}
+
+func (l *recordingAuditLog) SaveAuditEvent(ctx context.Context, event entities.AuditEvent) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.events = append(l.events, event)
+	return nil
+}
+
+func (l *recordingAuditLog) GetAuditEvents(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error) {
+	return l.events, l.err
+}
+
+func Test_service_CreateTask_Audited(t *testing.T) {
+	auditLog := &recordingAuditLog{}
+	mockStorage := MockStorage{}
+	srv := NewService(&mockStorage, emptyStepRunners, WithAuditLog(auditLog))
+
+	task := entities.Task{Name: "test", Steps: []entities.Step{{Type: "test"}}}
+	taskWithID := task
+	taskWithID.ID = 7
+	taskWithID.WorkspaceID = entities.DefaultWorkspaceID
+	mockStorage.On("SaveTask", mock.Anything, task).Return(taskWithID, nil)
+
+	_, err := srv.CreateTask(ContextWithActor(context.Background(), "alice"), task)
+
+	assert.NoError(t, err)
+	if assert.Len(t, auditLog.events, 1) {
+		event := auditLog.events[0]
+		assert.Equal(t, "alice", event.Actor)
+		assert.Equal(t, entities.CreateAuditAction, event.Action)
+		assert.Equal(t, entities.TaskAuditEntity, event.EntityType)
+		assert.Equal(t, "7", event.EntityID)
+		assert.Empty(t, event.Before)
+		assert.JSONEq(t, `{"id":7,"workspace_id":1,"name":"test","steps":[{"id":0,"type":"test","params":null,"failure_step":null}]}`, string(event.After))
+		assert.JSONEq(t, `{"id":{"after":7},"workspace_id":{"after":1},"name":{"after":"test"},"steps":{"after":[{"id":0,"type":"test","params":null,"failure_step":null}]}}`, string(event.Diff))
+		assert.False(t, event.Time.IsZero())
+	}
+}
+
+func Test_service_CreateSchedule_AuditFailureDoesNotFail(t *testing.T) {
+	auditLog := &recordingAuditLog{err: errors.New("audit down")}
+	mockStorage := MockStorage{}
+	srv := NewService(&mockStorage, emptyStepRunners, WithAuditLog(auditLog))
+
+	sch := entities.ScheduledTask{Name: "every minute", Cron: "* * * * *", Task: entities.Task{ID: 7}}
+	mockStorage.On("GetTask", mock.Anything, 7).Return(entities.Task{ID: 7}, nil)
+	mockStorage.On("SaveSchedule", mock.Anything, sch).Return(entities.ScheduledTask{ID: 3, Task: entities.Task{ID: 7}}, nil)
+
+	saved, err := srv.CreateSchedule(context.Background(), sch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, saved.ID)
+}
+
+func Test_service_CreateSecret_AuditedAsUpdate(t *testing.T) {
+	cipher, err := secrets.NewAESGCM(testSecretsKey)
+	assert.NoError(t, err)
+
+	auditLog := &recordingAuditLog{}
+	mockStorage := MockStorage{}
+	mockStorage.On("GetSecret", mock.Anything, "api_key").Return([]byte("previous"), nil)
+	mockStorage.On("SaveSecret", mock.Anything, "api_key", mock.Anything).Return(nil)
+	srv := NewService(&mockStorage, emptyStepRunners, WithCipher(cipher), WithAuditLog(auditLog))
+
+	_, err = srv.CreateSecret(context.Background(), entities.Secret{Name: "api_key", Value: "value"})
+
+	assert.NoError(t, err)
+	if assert.Len(t, auditLog.events, 1) {
+		event := auditLog.events[0]
+		assert.Equal(t, AnonymousActor, event.Actor)
+		assert.Equal(t, entities.UpdateAuditAction, event.Action)
+		assert.Equal(t, "api_key", event.EntityID)
+		//The value is never audited
+		assert.JSONEq(t, `{"name":"api_key"}`, string(event.Before))
+		assert.JSONEq(t, `{"name":"api_key"}`, string(event.After))
+		assert.JSONEq(t, `{}`, string(event.Diff))
+	}
+	mockStorage.AssertExpectations(t)
+}
+
+func Test_service_GetAuditEvents_Disabled(t *testing.T) {
+	srv := NewService(&MockStorage{}, emptyStepRunners)
+
+	_, err := srv.GetAuditEvents(context.Background(), entities.AuditFilter{})
+
+	assert.ErrorIs(t, err, errAuditDisabled)
+}
+
+func Test_auditDiff(t *testing.T) {
+	diff, err := auditDiff([]byte(`{"name":"a","cron":"* * * * *","enabled":true}`), []byte(`{"name":"a","cron":"0 * * * *","retries":2}`))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"cron": {"before": "* * * * *", "after": "0 * * * *"},
+		"enabled": {"before": true},
+		"retries": {"after": 2}
+	}`, string(diff))
+}
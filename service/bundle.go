@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+)
+
+// ExportBundle describes every task of the workspace with its schedules, sorted by name so exports can be diffed
+func (s service) ExportBundle(ctx context.Context) (entities.Bundle, error) {
+	summaries, err := s.storage.GetTasks(ctx, entities.TaskFilter{})
+	if err != nil {
+		return entities.Bundle{}, fmt.Errorf("getting tasks: %w", err)
+	}
+
+	bundle := entities.Bundle{Version: entities.BundleVersion, Tasks: []entities.BundleTask{}}
+	names := map[string]bool{}
+	for _, summary := range summaries {
+		if names[summary.Name] {
+			return entities.Bundle{}, http.ErrConflict.WithMessage(fmt.Sprintf("more than one task is named %s, bundles key the tasks by name", summary.Name))
+		}
+		names[summary.Name] = true
+
+		task, err := s.storage.GetTask(ctx, summary.ID)
+		if err != nil {
+			return entities.Bundle{}, fmt.Errorf("getting task %d: %w", summary.ID, err)
+		}
+		schedules, err := s.storage.GetTaskSchedules(ctx, summary.ID)
+		if err != nil {
+			return entities.Bundle{}, fmt.Errorf("getting schedules of task %d: %w", summary.ID, err)
+		}
+
+		bundleTask := entities.NewBundleTask(task, schedules)
+		sort.SliceStable(bundleTask.Schedules, func(i, j int) bool {
+			return bundleTask.Schedules[i].Name < bundleTask.Schedules[j].Name
+		})
+		bundle.Tasks = append(bundle.Tasks, bundleTask)
+	}
+	sort.Slice(bundle.Tasks, func(i, j int) bool {
+		return bundle.Tasks[i].Name < bundle.Tasks[j].Name
+	})
+
+	return bundle, nil
+}
+
+// ImportBundle creates the bundle tasks and schedules that don't exist by name and updates the ones that differ, the
+// schedules missing from the bundle are kept. Everything is applied on one transaction, or only planned on a dry run
+func (s service) ImportBundle(ctx context.Context, bundle entities.Bundle, dryRun bool) (entities.ImportResult, error) {
+	if err := bundle.IsValid(); err != nil {
+		return entities.ImportResult{}, err
+	}
+
+	result := entities.ImportResult{DryRun: dryRun, Changes: []entities.ImportChange{}}
+	importTasks := func(ctx context.Context) error {
+		for _, bundleTask := range bundle.Tasks {
+			changes, err := s.importTask(ctx, bundleTask, !dryRun)
+			if err != nil {
+				return fmt.Errorf("importing task %s: %w", bundleTask.Name, err)
+			}
+			result.Changes = append(result.Changes, changes...)
+		}
+		return nil
+	}
+
+	if dryRun {
+		if err := importTasks(ctx); err != nil {
+			return entities.ImportResult{}, err
+		}
+		return result, nil
+	}
+	if err := s.storage.InTransaction(ctx, importTasks); err != nil {
+		return entities.ImportResult{}, err
+	}
+	return result, nil
+}
+
+// importTask plans the changes of the task and its schedules, and applies them when apply is set
+func (s service) importTask(ctx context.Context, bundleTask entities.BundleTask, apply bool) ([]entities.ImportChange, error) {
+	task, err := s.storage.GetTaskByName(ctx, bundleTask.Name)
+	switch {
+	case http.IsNotFoundErr(err):
+		return s.createBundleTask(ctx, bundleTask, apply)
+	case err != nil:
+		return nil, err
+	}
+
+	change := entities.ImportChange{Action: entities.UnchangedImportAction, EntityType: entities.TaskAuditEntity, Task: task.Name, ID: task.ID}
+	before := entities.NewBundleTask(task, nil)
+	if change.Diff, err = importDiff(bundleTaskState(before), bundleTaskState(bundleTask)); err != nil {
+		return nil, err
+	}
+	if change.Diff != nil {
+		change.Action = entities.UpdateImportAction
+		if apply {
+			updated := task
+			if updated.Steps, err = s.storage.UpdateTaskSteps(ctx, task.ID, bundleTask.Task().Steps); err != nil {
+				return nil, fmt.Errorf("updating steps: %w", err)
+			}
+			s.audit(ctx, entities.AuditEvent{Action: entities.UpdateAuditAction, EntityType: entities.TaskAuditEntity, EntityID: strconv.Itoa(task.ID)}, task, updated)
+		}
+	}
+	changes := []entities.ImportChange{change}
+
+	schedules, err := s.storage.GetTaskSchedules(ctx, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting schedules: %w", err)
+	}
+	byName, ambiguous := map[string]entities.ScheduledTask{}, map[string]bool{}
+	for _, sch := range schedules {
+		if _, found := byName[sch.Name]; found {
+			ambiguous[sch.Name] = true
+		}
+		byName[sch.Name] = sch
+	}
+
+	for _, bundleSch := range bundleTask.Schedules {
+		sch, found := byName[bundleSch.Name]
+		switch {
+		case ambiguous[bundleSch.Name]:
+			return nil, http.ErrConflict.WithMessage(fmt.Sprintf("more than one schedule of task %s is named %s", task.Name, bundleSch.Name))
+		case !found:
+			schChange, err := s.createBundleSchedule(ctx, task.ID, task.Name, bundleSch, apply)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, schChange)
+			continue
+		}
+
+		schChange := entities.ImportChange{Action: entities.UnchangedImportAction, EntityType: entities.ScheduleAuditEntity, Task: task.Name, Schedule: sch.Name, ID: sch.ID}
+		if schChange.Diff, err = importDiff(entities.NewBundleSchedule(sch), bundleSch); err != nil {
+			return nil, err
+		}
+		if schChange.Diff != nil {
+			schChange.Action = entities.UpdateImportAction
+			if apply {
+				updated := sch
+				updated.Cron, updated.Retries, updated.Enabled = bundleSch.Cron, bundleSch.Retries, bundleSch.Enabled
+				if err := s.storage.UpdateSchedule(ctx, updated); err != nil {
+					return nil, fmt.Errorf("updating schedule %s: %w", sch.Name, err)
+				}
+				s.audit(ctx, entities.AuditEvent{Action: entities.UpdateAuditAction, EntityType: entities.ScheduleAuditEntity, EntityID: strconv.Itoa(sch.ID)}, scheduleAuditState(sch), scheduleAuditState(updated))
+			}
+		}
+		changes = append(changes, schChange)
+	}
+
+	return changes, nil
+}
+
+func (s service) createBundleTask(ctx context.Context, bundleTask entities.BundleTask, apply bool) ([]entities.ImportChange, error) {
+	change := entities.ImportChange{Action: entities.CreateImportAction, EntityType: entities.TaskAuditEntity, Task: bundleTask.Name}
+	var err error
+	if change.Diff, err = importDiff(nil, bundleTaskState(bundleTask)); err != nil {
+		return nil, err
+	}
+
+	var task entities.Task
+	if apply {
+		if task, err = s.storage.SaveTask(ctx, bundleTask.Task()); err != nil {
+			return nil, fmt.Errorf("saving task: %w", err)
+		}
+		change.ID = task.ID
+		s.audit(ctx, entities.AuditEvent{Action: entities.CreateAuditAction, EntityType: entities.TaskAuditEntity, EntityID: strconv.Itoa(task.ID)}, nil, task)
+	}
+	changes := []entities.ImportChange{change}
+
+	for _, bundleSch := range bundleTask.Schedules {
+		schChange, err := s.createBundleSchedule(ctx, task.ID, bundleTask.Name, bundleSch, apply)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, schChange)
+	}
+	return changes, nil
+}
+
+func (s service) createBundleSchedule(ctx context.Context, taskID int, taskName string, bundleSch entities.BundleSchedule, apply bool) (entities.ImportChange, error) {
+	change := entities.ImportChange{Action: entities.CreateImportAction, EntityType: entities.ScheduleAuditEntity, Task: taskName, Schedule: bundleSch.Name}
+	var err error
+	if change.Diff, err = importDiff(nil, bundleSch); err != nil {
+		return entities.ImportChange{}, err
+	}
+
+	if apply {
+		sch, err := s.storage.SaveSchedule(ctx, bundleSch.ScheduledTask(taskID))
+		if err != nil {
+			return entities.ImportChange{}, fmt.Errorf("saving schedule %s: %w", bundleSch.Name, err)
+		}
+		change.ID = sch.ID
+		s.audit(ctx, entities.AuditEvent{Action: entities.CreateAuditAction, EntityType: entities.ScheduleAuditEntity, EntityID: strconv.Itoa(sch.ID)}, nil, scheduleAuditState(sch))
+	}
+	return change, nil
+}
+
+// bundleTaskState is the bundle task without its schedules, which are diffed on their own
+func bundleTaskState(task entities.BundleTask) map[string]any {
+	return map[string]any{"name": task.Name, "steps": task.Steps}
+}
+
+// importDiff diffs the states like the audit events do, nil when nothing changes
+func importDiff(before, after any) (json.RawMessage, error) {
+	beforeJSON, err := marshalAuditState(before)
+	if err != nil {
+		return nil, err
+	}
+	afterJSON, err := marshalAuditState(after)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := auditDiff(beforeJSON, afterJSON)
+	if err != nil {
+		return nil, err
+	}
+	if string(diff) == "{}" {
+		return nil, nil
+	}
+	return diff, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+)
+
+func Test_service_ExportBundle(t *testing.T) {
+	mockStorage := MockStorage{}
+	srv := NewService(&mockStorage, emptyStepRunners)
+
+	email := entities.Step{ID: 3, Type: entities.EmailStepType, Params: map[string]string{"to": "a@b.c"}}
+	mockStorage.On("GetTasks", mock.Anything, entities.TaskFilter{}).Return([]entities.TaskSummary{{ID: 1, Name: "report"}, {ID: 2, Name: "backup"}}, nil).Once()
+	mockStorage.On("GetTask", mock.Anything, 1).Return(entities.Task{ID: 1, Name: "report", Steps: []entities.Step{email}}, nil)
+	mockStorage.On("GetTask", mock.Anything, 2).Return(entities.Task{ID: 2, Name: "backup", Steps: []entities.Step{email}}, nil)
+	mockStorage.On("GetTaskSchedules", mock.Anything, 1).Return([]entities.ScheduledTask{
+		{ID: 5, Name: "nightly", Cron: "0 3 * * *", Enabled: true},
+		{ID: 6, Name: "hourly", Cron: "0 * * * *", Retries: 1},
+	}, nil)
+	mockStorage.On("GetTaskSchedules", mock.Anything, 2).Return([]entities.ScheduledTask(nil), nil)
+
+	bundle, err := srv.ExportBundle(context.Background())
+	assert.NoError(t, err)
+	bundleEmail := entities.BundleStep{Type: entities.EmailStepType, Params: map[string]string{"to": "a@b.c"}}
+	assert.Equal(t, entities.Bundle{Version: entities.BundleVersion, Tasks: []entities.BundleTask{
+		{Name: "backup", Steps: []entities.BundleStep{bundleEmail}},
+		{Name: "report", Steps: []entities.BundleStep{bundleEmail}, Schedules: []entities.BundleSchedule{
+			{Name: "hourly", Cron: "0 * * * *", Retries: 1},
+			{Name: "nightly", Cron: "0 3 * * *", Enabled: true},
+		}},
+	}}, bundle)
+
+	//Tasks sharing a name can't be told apart on the bundle
+	mockStorage.On("GetTasks", mock.Anything, entities.TaskFilter{}).Return([]entities.TaskSummary{{ID: 1, Name: "report"}, {ID: 3, Name: "report"}}, nil).Once()
+	_, err = srv.ExportBundle(context.Background())
+	var httpError http.Error
+	if assert.ErrorAs(t, err, &httpError) {
+		assert.Equal(t, "conflict", httpError.Code())
+	}
+}
+
+func Test_service_ImportBundle(t *testing.T) {
+	email := entities.BundleStep{Type: entities.EmailStepType, Params: map[string]string{"to": "a@b.c"}}
+	apiCall := entities.BundleStep{Type: entities.APICallStepType, Params: map[string]string{"url": "http://localhost"}}
+	bundle := entities.Bundle{Version: entities.BundleVersion, Tasks: []entities.BundleTask{
+		{Name: "report", Steps: []entities.BundleStep{apiCall}, Schedules: []entities.BundleSchedule{
+			{Name: "nightly", Cron: "0 3 * * *", Enabled: true},
+			{Name: "hourly", Cron: "0 * * * *", Retries: 1},
+		}},
+		{Name: "backup", Steps: []entities.BundleStep{email}, Schedules: []entities.BundleSchedule{{Name: "daily", Cron: "0 0 * * *"}}},
+		{Name: "cleanup", Steps: []entities.BundleStep{email}, Schedules: []entities.BundleSchedule{{Name: "weekly", Cron: "0 0 * * 0", Retries: 2}}},
+	}}
+	report := entities.Task{ID: 1, Name: "report", Steps: []entities.Step{{ID: 3, Type: entities.EmailStepType, Params: map[string]string{"to": "a@b.c"}}}}
+	cleanup := entities.Task{ID: 2, Name: "cleanup", Steps: []entities.Step{{ID: 4, Type: entities.EmailStepType, Params: map[string]string{"to": "a@b.c"}}}}
+
+	setup := func() *MockStorage {
+		mockStorage := &MockStorage{}
+		mockStorage.On("GetTaskByName", mock.Anything, "report").Return(report, nil)
+		mockStorage.On("GetTaskByName", mock.Anything, "backup").Return(entities.Task{}, http.ErrNotFound.WithMessage("task not found"))
+		mockStorage.On("GetTaskByName", mock.Anything, "cleanup").Return(cleanup, nil)
+		mockStorage.On("GetTaskSchedules", mock.Anything, 1).Return([]entities.ScheduledTask{{ID: 5, Name: "nightly", Cron: "0 3 * * *", Enabled: true, Task: entities.Task{ID: 1}}}, nil)
+		mockStorage.On("GetTaskSchedules", mock.Anything, 2).Return([]entities.ScheduledTask{{ID: 6, Name: "weekly", Cron: "0 0 * * 1", Task: entities.Task{ID: 2}}}, nil)
+		return mockStorage
+	}
+	wantChanges := func(createdIDs ...int) []entities.ImportChange {
+		id := func(i int) int {
+			if len(createdIDs) == 0 {
+				return 0
+			}
+			return createdIDs[i]
+		}
+		return []entities.ImportChange{
+			{Action: entities.UpdateImportAction, EntityType: entities.TaskAuditEntity, Task: "report", ID: 1,
+				Diff: json.RawMessage(`{"steps":{"before":[{"type":"email","params":{"to":"a@b.c"}}],"after":[{"type":"api_call","params":{"url":"http://localhost"}}]}}`)},
+			{Action: entities.UnchangedImportAction, EntityType: entities.ScheduleAuditEntity, Task: "report", Schedule: "nightly", ID: 5},
+			{Action: entities.CreateImportAction, EntityType: entities.ScheduleAuditEntity, Task: "report", Schedule: "hourly", ID: id(0),
+				Diff: json.RawMessage(`{"cron":{"after":"0 * * * *"},"enabled":{"after":false},"name":{"after":"hourly"},"retries":{"after":1}}`)},
+			{Action: entities.CreateImportAction, EntityType: entities.TaskAuditEntity, Task: "backup", ID: id(1),
+				Diff: json.RawMessage(`{"name":{"after":"backup"},"steps":{"after":[{"type":"email","params":{"to":"a@b.c"}}]}}`)},
+			{Action: entities.CreateImportAction, EntityType: entities.ScheduleAuditEntity, Task: "backup", Schedule: "daily", ID: id(2),
+				Diff: json.RawMessage(`{"cron":{"after":"0 0 * * *"},"enabled":{"after":false},"name":{"after":"daily"},"retries":{"after":0}}`)},
+			{Action: entities.UnchangedImportAction, EntityType: entities.TaskAuditEntity, Task: "cleanup", ID: 2},
+			{Action: entities.UpdateImportAction, EntityType: entities.ScheduleAuditEntity, Task: "cleanup", Schedule: "weekly", ID: 6,
+				Diff: json.RawMessage(`{"cron":{"before":"0 0 * * 1","after":"0 0 * * 0"},"retries":{"before":0,"after":2}}`)},
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		mockStorage := setup()
+		srv := NewService(mockStorage, emptyStepRunners)
+
+		result, err := srv.ImportBundle(context.Background(), bundle, true)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.ImportResult{DryRun: true, Changes: wantChanges()}, result)
+		mockStorage.AssertNotCalled(t, "InTransaction", mock.Anything)
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		mockStorage := setup()
+		srv := NewService(mockStorage, emptyStepRunners)
+		mockStorage.On("InTransaction", mock.Anything).Return()
+		mockStorage.On("UpdateTaskSteps", mock.Anything, 1, []entities.Step{{Type: entities.APICallStepType, Params: map[string]string{"url": "http://localhost"}}}).
+			Return([]entities.Step{{ID: 7, Type: entities.APICallStepType, Params: map[string]string{"url": "http://localhost"}}}, nil)
+		mockStorage.On("SaveSchedule", mock.Anything, entities.ScheduledTask{Name: "hourly", Cron: "0 * * * *", Retries: 1, Task: entities.Task{ID: 1}}).
+			Return(entities.ScheduledTask{ID: 8}, nil)
+		mockStorage.On("SaveTask", mock.Anything, entities.Task{Name: "backup", Steps: []entities.Step{{Type: entities.EmailStepType, Params: map[string]string{"to": "a@b.c"}}}}).
+			Return(entities.Task{ID: 9, Name: "backup"}, nil)
+		mockStorage.On("SaveSchedule", mock.Anything, entities.ScheduledTask{Name: "daily", Cron: "0 0 * * *", Task: entities.Task{ID: 9}}).
+			Return(entities.ScheduledTask{ID: 10}, nil)
+		mockStorage.On("UpdateSchedule", mock.Anything, entities.ScheduledTask{ID: 6, Name: "weekly", Cron: "0 0 * * 0", Retries: 2, Task: entities.Task{ID: 2}}).Return(nil)
+
+		result, err := srv.ImportBundle(context.Background(), bundle, false)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.ImportResult{Changes: wantChanges(8, 9, 10)}, result)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("failed import", func(t *testing.T) {
+		mockStorage := setup()
+		srv := NewService(mockStorage, emptyStepRunners)
+		mockStorage.On("InTransaction", mock.Anything).Return()
+		mockStorage.On("UpdateTaskSteps", mock.Anything, 1, mock.Anything).Return([]entities.Step(nil), errors.New("mocked-err"))
+
+		_, err := srv.ImportBundle(context.Background(), bundle, false)
+		assert.ErrorContains(t, err, "importing task report: updating steps: mocked-err")
+	})
+
+	t.Run("invalid bundle", func(t *testing.T) {
+		srv := NewService(&MockStorage{}, emptyStepRunners)
+
+		_, err := srv.ImportBundle(context.Background(), entities.Bundle{Version: 2}, true)
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError) {
+			assert.Equal(t, "validation_failed", httpError.Code())
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tasker/service"
+)
+
+const (
+	executableParam = "executable_cmd"
+	argsParam       = "args_cmd"
+	envParam        = "env_cmd"
+	stdinParam      = "stdin_cmd"
+	timeoutParam    = "timeout_cmd"
+)
+
+const defaultTimeout = time.Second * 30
+
+type Command struct {
+	Executable string
+	Args       []string
+	Env        map[string]string
+	Stdin      string
+}
+
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+type Repository interface {
+	// Run executes the command if it is whitelisted, a non-zero exit code is not an error
+	Run(ctx context.Context, cmd Command) (Result, error)
+}
+
+type stepRunner struct {
+	repo Repository
+}
+
+func NewStepRunner(repo Repository) stepRunner {
+	return stepRunner{repo: repo}
+}
+
+func (a stepRunner) RunStep(ctx context.Context, params map[string]string) (string, error) {
+	// Get executable from params
+	cmd := Command{}
+	var found bool
+	cmd.Executable, found = params[executableParam]
+	if !found || cmd.Executable == "" {
+		return "", fmt.Errorf("no executable param found for command step")
+	}
+
+	if argsJSON, found := params[argsParam]; found {
+		if err := json.Unmarshal([]byte(argsJSON), &cmd.Args); err != nil {
+			return "", fmt.Errorf("invalid args param found for command step, must be a JSON array of strings: %w", err)
+		}
+	}
+	for i, arg := range cmd.Args {
+		if arg == service.UseLastStepResultKey {
+			if cmd.Args[i], found = params[service.LastStepResultKey]; !found {
+				return "", fmt.Errorf("requested to use last step result as arg %d but there was no last step result", i)
+			}
+		}
+	}
+
+	if envJSON, found := params[envParam]; found {
+		if err := json.Unmarshal([]byte(envJSON), &cmd.Env); err != nil {
+			return "", fmt.Errorf("invalid env param found for command step, must be a JSON object of strings: %w", err)
+		}
+	}
+
+	cmd.Stdin = params[stdinParam]
+	if cmd.Stdin == service.UseLastStepResultKey {
+		cmd.Stdin, found = params[service.LastStepResultKey]
+		if !found {
+			return "", fmt.Errorf("requested to use last step result as stdin but there was no last step result")
+		}
+	}
+
+	timeout := defaultTimeout
+	if timeoutStr, found := params[timeoutParam]; found {
+		var err error
+		if timeout, err = time.ParseDuration(timeoutStr); err != nil {
+			return "", fmt.Errorf("invalid timeout param found for command step: %w", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := a.repo.Run(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("running command %s: %w", cmd.Executable, err)
+	}
+
+	//Check exit code
+	if result.ExitCode != 0 {
+		return result.Stdout, fmt.Errorf("command %s failed with exit code %d: %s", cmd.Executable, result.ExitCode, result.Stderr)
+	}
+
+	return result.Stdout, nil
+}
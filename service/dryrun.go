@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+	"github.com/tasker/service/secrets"
+	"github.com/tasker/tracing"
+)
+
+type dryRunKey struct{}
+
+// isDryRun tells the steps run by a dry run apart, they don't count on the step metrics
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// ReadOnlyStepRunner is a StepRunner that tells the steps that change nothing, the dry runs can run them for real
+type ReadOnlyStepRunner interface {
+	StepRunner
+	ReadOnly(params map[string]string) bool
+}
+
+// DryRunTask executes the saved task, or the unsaved one of the dry run when taskID is 0, the way ExecuteTask does but
+// without saving the execution nor running the steps that could change anything. The stubbed steps return their stub,
+// the read only ones run for real if asked to and the rest are skipped
+func (s service) DryRunTask(ctx context.Context, taskID int, dryRun entities.DryRun) (entities.DryRunResult, error) {
+	ctx, span := s.tracer.Start(ctx, "dry run task", tracing.InternalSpanKind)
+	defer span.End()
+	span.SetAttribute("task.id", taskID)
+
+	var task entities.Task
+	switch {
+	case taskID != 0 && dryRun.Task != nil:
+		return entities.DryRunResult{}, http.ValidationError([]http.FieldError{{Field: "task", Detail: "must not be set when dry running a saved task"}})
+	case taskID != 0:
+		var err error
+		if task, err = s.storage.GetTask(ctx, taskID); err != nil {
+			span.SetError(err)
+			return entities.DryRunResult{}, fmt.Errorf("getting task to dry run: %w", err)
+		}
+	case dryRun.Task == nil:
+		return entities.DryRunResult{}, http.ValidationError([]http.FieldError{{Field: "task", Detail: "is required to dry run an unsaved task"}})
+	default:
+		task = *dryRun.Task
+	}
+	if err := dryRun.IsValid(task); err != nil {
+		return entities.DryRunResult{}, err
+	}
+
+	stubs := map[entities.StepStub]entities.StepStub{}
+	for _, stub := range dryRun.Stubs {
+		stubs[entities.StepStub{Step: stub.Step, FailureStep: stub.FailureStep}] = stub
+	}
+
+	//The steps run for real redact the secrets they resolve like on the executions
+	ctx = secrets.ContextWithRedactor(ctx, secrets.NewRedactor())
+	workspaceID, _ := WorkspaceFromContext(ctx)
+	ctx = ContextWithExecutionInfo(ctx, ExecutionInfo{WorkspaceID: workspaceID, TaskID: task.ID})
+	ctx = context.WithValue(ctx, dryRunKey{}, true)
+
+	exec := entities.Execution{Status: entities.SuccessExecutionStatus}
+	traces := s.runSteps(ctx, &exec, task, func(ctx context.Context, trace entities.StepTrace, step entities.Step) (entities.StepTrace, error) {
+		if stub, found := stubs[entities.StepStub{Step: trace.Step, FailureStep: trace.FailureStep}]; found {
+			trace.Mode, trace.Output, trace.Error = entities.StubbedStepTraceMode, stub.Output, stub.Error
+			if stub.Error != "" {
+				return trace, errors.New(stub.Error)
+			}
+			return trace, nil
+		}
+		if runner, ok := s.stepRunners[step.Type].(ReadOnlyStepRunner); ok && dryRun.RunReadOnly && runner.ReadOnly(step.Params) {
+			return s.runTracedStep(ctx, trace, step)
+		}
+		trace.Mode = entities.SkippedStepTraceMode
+		return trace, nil
+	})
+	span.SetAttribute("execution.status", string(exec.Status))
+
+	return entities.DryRunResult{Status: exec.Status, Steps: traces}, nil
+}
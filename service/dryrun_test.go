@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+	"github.com/tasker/service/secrets"
+)
+
+// mockReadOnlyStepRunner runs the steps with the read_only param as read only
+type mockReadOnlyStepRunner struct {
+	MockStepRunner
+}
+
+func (m *mockReadOnlyStepRunner) ReadOnly(params map[string]string) bool {
+	return params["read_only"] == "true"
+}
+
+// stepCountingMetrics counts the finished steps
+type stepCountingMetrics struct {
+	noopMetrics
+	steps int
+}
+
+func (m *stepCountingMetrics) StepFinished(entities.StepType, bool, time.Duration) {
+	m.steps++
+}
+
+func Test_service_DryRunTask(t *testing.T) {
+	task := entities.Task{ID: 1, Name: "report", Steps: []entities.Step{
+		{Type: entities.APICallStepType, Params: map[string]string{"url_api": "http://localhost"}},
+		{Type: entities.StorageReadStepType, Params: map[string]string{"storage_key": "k", "read_only": "true"}},
+		{Type: entities.EmailStepType, Params: map[string]string{"to_email": "a@b.c"}},
+		{Type: entities.CommandStepType, Params: map[string]string{"command": "false"}, FailureStep: &entities.Step{
+			Type: entities.EmailStepType, Params: map[string]string{"to_email": "oncall@b.c"},
+		}},
+	}}
+	copyTask := func() entities.Task {
+		copied := task
+		copied.Steps = make([]entities.Step, len(task.Steps))
+		for i, step := range task.Steps {
+			copied.Steps[i] = step
+			copied.Steps[i].Params = map[string]string{}
+			for key, value := range step.Params {
+				copied.Steps[i].Params[key] = value
+			}
+		}
+		failureStep := *task.Steps[3].FailureStep
+		copied.Steps[3].FailureStep = &failureStep
+		return copied
+	}
+	stubs := []entities.StepStub{
+		{Step: 0, Output: `{"id":7}`},
+		{Step: 3, Error: "exit status 1"},
+		{Step: 3, FailureStep: true, Output: "notified"},
+	}
+
+	t.Run("saved task", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetTask", mock.Anything, 1).Return(copyTask(), nil)
+		storageReader := mockReadOnlyStepRunner{}
+		storageReader.On("RunStep", mock.Anything, map[string]string{"storage_key": "k", "read_only": "true", LastStepResultKey: `{"id":7}`}).Return("cached", nil)
+		metrics := &stepCountingMetrics{}
+		//Every other runner panics if it's called
+		srv := NewService(&mockStorage, map[entities.StepType]StepRunner{
+			entities.APICallStepType:      StepRunner(nil),
+			entities.StorageReadStepType:  &storageReader,
+			entities.StorageWriteStepType: StepRunner(nil),
+			entities.SQLQueryStepType:     StepRunner(nil),
+			entities.CommandStepType:      StepRunner(nil),
+			entities.EmailStepType:        StepRunner(nil),
+			entities.StorageOpStepType:    StepRunner(nil),
+		}, WithMetrics(metrics))
+
+		result, err := srv.DryRunTask(context.Background(), 1, entities.DryRun{Stubs: stubs, RunReadOnly: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entities.DryRunResult{Status: entities.HandledFailureExecutionStatus, Steps: []entities.StepTrace{
+			{Step: 0, Type: entities.APICallStepType, Mode: entities.StubbedStepTraceMode, Params: map[string]string{"url_api": "http://localhost"}, Output: `{"id":7}`},
+			{Step: 1, Type: entities.StorageReadStepType, Mode: entities.RunStepTraceMode, Params: map[string]string{"storage_key": "k", "read_only": "true", LastStepResultKey: `{"id":7}`}, Output: "cached"},
+			{Step: 2, Type: entities.EmailStepType, Mode: entities.SkippedStepTraceMode, Params: map[string]string{"to_email": "a@b.c", LastStepResultKey: "cached"}},
+			{Step: 3, Type: entities.CommandStepType, Mode: entities.StubbedStepTraceMode, Params: map[string]string{"command": "false", LastStepResultKey: ""}, Error: "exit status 1"},
+			{Step: 3, FailureStep: true, Type: entities.EmailStepType, Mode: entities.StubbedStepTraceMode, Params: map[string]string{"to_email": "oncall@b.c", LastStepResultKey: ""}, Output: "notified"},
+		}}, result)
+		mockStorage.AssertExpectations(t)
+		storageReader.AssertExpectations(t)
+		//The step run for real isn't counted as a production step
+		assert.Equal(t, 0, metrics.steps)
+	})
+
+	t.Run("unsaved task", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		srv := NewService(&mockStorage, emptyStepRunners)
+		unsaved := copyTask()
+		unsaved.ID = 0
+
+		//Without run_read_only the read only steps are skipped too, skipped steps succeed
+		result, err := srv.DryRunTask(context.Background(), 0, entities.DryRun{Task: &unsaved, Stubs: stubs[:2]})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entities.HandledFailureExecutionStatus, result.Status)
+		modes := make([]entities.StepTrace, len(result.Steps))
+		for i, trace := range result.Steps {
+			modes[i] = entities.StepTrace{Step: trace.Step, FailureStep: trace.FailureStep, Mode: trace.Mode}
+		}
+		assert.Equal(t, []entities.StepTrace{
+			{Step: 0, Mode: entities.StubbedStepTraceMode},
+			{Step: 1, Mode: entities.SkippedStepTraceMode},
+			{Step: 2, Mode: entities.SkippedStepTraceMode},
+			{Step: 3, Mode: entities.StubbedStepTraceMode},
+			{Step: 3, FailureStep: true, Mode: entities.SkippedStepTraceMode},
+		}, modes)
+		mockStorage.AssertNotCalled(t, "SaveExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("redacts the secrets", func(t *testing.T) {
+		cipher, err := secrets.NewAESGCM(testSecretsKey)
+		assert.NoError(t, err)
+		encrypted, err := cipher.Encrypt([]byte("s3cr3t"))
+		assert.NoError(t, err)
+		mockStorage := MockStorage{}
+		mockStorage.On("GetSecret", mock.Anything, "api_key").Return(encrypted, nil)
+		storageReader := mockReadOnlyStepRunner{}
+		storageReader.On("RunStep", mock.Anything, map[string]string{"storage_key": "s3cr3t", "read_only": "true"}).Return("value of s3cr3t", nil)
+		srv := NewService(&mockStorage, map[entities.StepType]StepRunner{
+			entities.APICallStepType:      StepRunner(nil),
+			entities.StorageReadStepType:  &storageReader,
+			entities.StorageWriteStepType: StepRunner(nil),
+			entities.SQLQueryStepType:     StepRunner(nil),
+			entities.CommandStepType:      StepRunner(nil),
+			entities.EmailStepType:        StepRunner(nil),
+			entities.StorageOpStepType:    StepRunner(nil),
+		}, WithCipher(cipher))
+		unsaved := entities.Task{Name: "echo", Steps: []entities.Step{
+			{Type: entities.StorageReadStepType, Params: map[string]string{"storage_key": "secret://api_key", "read_only": "true"}},
+			{Type: entities.EmailStepType, Params: map[string]string{"to_email": "a@b.c"}},
+		}}
+
+		result, err := srv.DryRunTask(context.Background(), 0, entities.DryRun{Task: &unsaved, RunReadOnly: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []entities.StepTrace{
+			{Step: 0, Type: entities.StorageReadStepType, Mode: entities.RunStepTraceMode, Params: map[string]string{"storage_key": "secret://api_key", "read_only": "true"}, Output: "value of [REDACTED]"},
+			{Step: 1, Type: entities.EmailStepType, Mode: entities.SkippedStepTraceMode, Params: map[string]string{"to_email": "a@b.c", LastStepResultKey: "value of [REDACTED]"}},
+		}, result.Steps)
+		storageReader.AssertExpectations(t)
+	})
+
+	t.Run("invalid dry runs", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetTask", mock.Anything, 1).Return(copyTask(), nil)
+		mockStorage.On("GetTask", mock.Anything, 2).Return(entities.Task{}, http.ErrNotFound.WithMessage("task not found"))
+		srv := NewService(&mockStorage, emptyStepRunners)
+		unsaved := copyTask()
+
+		tests := map[string]struct {
+			taskID     int
+			dryRun     entities.DryRun
+			wantFields []http.FieldError
+		}{
+			"saved and unsaved task": {taskID: 1, dryRun: entities.DryRun{Task: &unsaved}, wantFields: []http.FieldError{{Field: "task", Detail: "must not be set when dry running a saved task"}}},
+			"no task":                {wantFields: []http.FieldError{{Field: "task", Detail: "is required to dry run an unsaved task"}}},
+			"invalid unsaved task":   {dryRun: entities.DryRun{Task: &entities.Task{Name: "t"}}, wantFields: []http.FieldError{{Field: "task.steps", Detail: "must not be empty"}}},
+			"invalid stubs": {taskID: 1, dryRun: entities.DryRun{Stubs: []entities.StepStub{{Step: 4}, {Step: 0, FailureStep: true}, {Step: 3}, {Step: 3, Output: "again"}}}, wantFields: []http.FieldError{
+				{Field: "stubs[0].step", Detail: "must be a step position, between 0 and 3"},
+				{Field: "stubs[1].failure_step", Detail: "must be false, step 0 has no failure step"},
+				{Field: "stubs[3].step", Detail: "must be stubbed once, stubs[2] stubs it too"},
+			}},
+		}
+		for name, test := range tests {
+			_, err := srv.DryRunTask(context.Background(), test.taskID, test.dryRun)
+			var httpError http.Error
+			if assert.True(t, errors.As(err, &httpError), name) {
+				assert.Equal(t, test.wantFields, httpError.FieldErrors(), name)
+			}
+		}
+
+		_, err := srv.DryRunTask(context.Background(), 2, entities.DryRun{})
+		assert.True(t, http.IsNotFoundErr(err))
+	})
+}
@@ -0,0 +1,134 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/tasker/service"
+)
+
+const (
+	toParam      = "to_email"
+	ccParam      = "cc_email"
+	subjectParam = "subject_email"
+	bodyParam    = "body_email"
+	htmlParam    = "html_email"
+)
+
+// lastStepJSONKey exposes the last step result parsed as JSON to the templates, e.g. {{.last_step_json.status}}
+const lastStepJSONKey = "last_step_json"
+
+type Message struct {
+	To      []string
+	Cc      []string
+	Subject string
+	Body    string
+	HTML    bool
+}
+
+type Repository interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type stepRunner struct {
+	repo Repository
+}
+
+func NewStepRunner(repo Repository) stepRunner {
+	return stepRunner{repo: repo}
+}
+
+func (a stepRunner) RunStep(ctx context.Context, params map[string]string) (string, error) {
+	msg := Message{
+		To: splitAddresses(params[toParam]),
+		Cc: splitAddresses(params[ccParam]),
+	}
+	if len(msg.To) == 0 {
+		return "", fmt.Errorf("no to param found for email step")
+	}
+
+	if htmlStr, found := params[htmlParam]; found {
+		var err error
+		if msg.HTML, err = strconv.ParseBool(htmlStr); err != nil {
+			return "", fmt.Errorf("invalid html param found for email step: %w", err)
+		}
+	}
+
+	data := templateData(params)
+	var err error
+	if msg.Subject, err = renderText(params[subjectParam], data); err != nil {
+		return "", fmt.Errorf("rendering email subject: %w", err)
+	}
+	// Subjects are headers, line breaks would allow injecting new ones
+	msg.Subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Subject)
+
+	if msg.HTML {
+		msg.Body, err = renderHTML(params[bodyParam], data)
+	} else {
+		msg.Body, err = renderText(params[bodyParam], data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("rendering email body: %w", err)
+	}
+
+	if err := a.repo.Send(ctx, msg); err != nil {
+		return "", fmt.Errorf("sending email: %w", err)
+	}
+
+	return params[service.LastStepResultKey], nil
+}
+
+// templateData exposes every step param to the templates, plus the last step result parsed as JSON when possible
+func templateData(params map[string]string) map[string]any {
+	data := make(map[string]any, len(params)+1)
+	for k, v := range params {
+		data[k] = v
+	}
+
+	var lastStepJSON any
+	if err := json.Unmarshal([]byte(params[service.LastStepResultKey]), &lastStepJSON); err == nil {
+		data[lastStepJSONKey] = lastStepJSON
+	}
+	return data
+}
+
+func renderText(text string, data map[string]any) (string, error) {
+	tmpl, err := template.New("email").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderHTML escapes the values coming from previous steps so they can't inject markup
+func renderHTML(text string, data map[string]any) (string, error) {
+	tmpl, err := htmltemplate.New("email").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func splitAddresses(addresses string) []string {
+	var result []string
+	for _, address := range strings.Split(addresses, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			result = append(result, address)
+		}
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExecutionInfo identifies the execution running on a context
+type ExecutionInfo struct {
+	WorkspaceID int
+	TaskID      int
+	ScheduleID  int
+	// Token is the idempotency token, unique per execution and known before the execution gets an ID
+	Token string
+}
+
+type executionInfoKey struct{}
+
+func ContextWithExecutionInfo(ctx context.Context, info ExecutionInfo) context.Context {
+	return context.WithValue(ctx, executionInfoKey{}, info)
+}
+
+// ExecutionInfoFromContext returns the running execution, found is false outside executions
+func ExecutionInfoFromContext(ctx context.Context) (ExecutionInfo, bool) {
+	info, found := ctx.Value(executionInfoKey{}).(ExecutionInfo)
+	return info, found
+}
+
+type stepIndexKey struct{}
+
+// ContextWithStepIndex sets the position on the task of the running step, failure steps share the index of their step
+func ContextWithStepIndex(ctx context.Context, index int) context.Context {
+	return context.WithValue(ctx, stepIndexKey{}, index)
+}
+
+// StepIndexFromContext returns the index of the running step, found is false outside steps
+func StepIndexFromContext(ctx context.Context) (int, bool) {
+	index, found := ctx.Value(stepIndexKey{}).(int)
+	return index, found
+}
+
+// StorageScope defines the namespace of the execution storage keys a step uses
+type StorageScope string
+
+const (
+	// GlobalStorageScope keys are shared by every task
+	GlobalStorageScope StorageScope = "global"
+	// TaskStorageScope keys are shared by every execution of the same task
+	TaskStorageScope StorageScope = "task"
+	// ScheduleStorageScope keys are shared by every execution of the same schedule
+	ScheduleStorageScope StorageScope = "schedule"
+	// ExecutionStorageScope keys only live while the execution runs
+	ExecutionStorageScope StorageScope = "execution"
+)
+
+const StorageScopeParam = "storage_scope"
+
+type storageScopeKey struct{}
+
+// ContextWithStorageScopeParam sets on the context the storage scope requested by the step params, global by default
+func ContextWithStorageScopeParam(ctx context.Context, params map[string]string) (context.Context, error) {
+	scope := StorageScope(params[StorageScopeParam])
+	switch scope {
+	case "":
+		scope = GlobalStorageScope
+	case GlobalStorageScope, TaskStorageScope, ScheduleStorageScope, ExecutionStorageScope:
+	default:
+		return ctx, fmt.Errorf("invalid storage scope param %q, must be global, task, schedule or execution", scope)
+	}
+
+	return context.WithValue(ctx, storageScopeKey{}, scope), nil
+}
+
+func StorageScopeFromContext(ctx context.Context) StorageScope {
+	scope, found := ctx.Value(storageScopeKey{}).(StorageScope)
+	if !found {
+		return GlobalStorageScope
+	}
+	return scope
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+)
+
+const defaultHealthCheckTimeout = 2 * time.Second
+
+// Pinger is a dependency the readiness check pings
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type dependency struct {
+	name   string
+	pinger Pinger
+}
+
+// WithDependency adds a dependency to the readiness check, checked in the order they are added
+func WithDependency(name string, p Pinger) Option {
+	return func(s *service) {
+		s.dependencies = append(s.dependencies, dependency{name: name, pinger: p})
+	}
+}
+
+// WithHealthCheckTimeout limits each dependency ping of the readiness check
+func WithHealthCheckTimeout(timeout time.Duration) Option {
+	return func(s *service) {
+		s.healthCheckTimeout = timeout
+	}
+}
+
+// Readiness pings every dependency concurrently and reports the scheduler state
+func (s service) Readiness(ctx context.Context) entities.Readiness {
+	readiness := entities.Readiness{
+		Ready:        true,
+		Dependencies: make([]entities.DependencyHealth, len(s.dependencies)),
+		Scheduler:    s.runs.schedulerHealth(),
+	}
+
+	var wg sync.WaitGroup
+	for i, dep := range s.dependencies {
+		wg.Add(1)
+		go func(i int, dep dependency) {
+			defer wg.Done()
+			readiness.Dependencies[i] = ping(ctx, dep, s.healthCheckTimeout)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	for _, dep := range readiness.Dependencies {
+		if dep.Status != entities.UpDependencyStatus {
+			readiness.Ready = false
+		}
+	}
+	if readiness.Scheduler.State == entities.StoppingSchedulerState {
+		readiness.Ready = false
+	}
+	return readiness
+}
+
+func ping(ctx context.Context, dep dependency, timeout time.Duration) entities.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.pinger.Ping(ctx)
+	health := entities.DependencyHealth{
+		Name:      dep.name,
+		Status:    entities.UpDependencyStatus,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		//The readiness check is public and the driver errors show hosts and DSNs, they are only logged but on debug
+		health.Status = entities.DownDependencyStatus
+		slog.WarnContext(ctx, "readiness check failed", "dependency", dep.name, "error", err)
+		if http.DebugMode {
+			health.Error = err.Error()
+		}
+	}
+	return health
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (p pingerFunc) Ping(ctx context.Context) error {
+	return p(ctx)
+}
+
+func Test_service_Readiness(t *testing.T) {
+	up := pingerFunc(func(ctx context.Context) error { return nil })
+	down := pingerFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+	hanging := pingerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	t.Run("every dependency up", func(t *testing.T) {
+		srv := NewService(&MockStorage{}, emptyStepRunners, WithDependency("mgmt_db", up), WithDependency("execution_storage", up))
+
+		readiness := srv.Readiness(context.Background())
+
+		assert.True(t, readiness.Ready)
+		assert.Len(t, readiness.Dependencies, 2)
+		assert.Equal(t, "mgmt_db", readiness.Dependencies[0].Name)
+		assert.Equal(t, entities.UpDependencyStatus, readiness.Dependencies[1].Status)
+		assert.Equal(t, entities.IdleSchedulerState, readiness.Scheduler.State)
+	})
+
+	t.Run("dependency down or timing out", func(t *testing.T) {
+		srv := NewService(&MockStorage{}, emptyStepRunners,
+			WithDependency("mgmt_db", down), WithDependency("execution_storage", hanging), WithHealthCheckTimeout(10*time.Millisecond))
+
+		readiness := srv.Readiness(context.Background())
+
+		assert.False(t, readiness.Ready)
+		assert.Equal(t, entities.DownDependencyStatus, readiness.Dependencies[0].Status)
+		assert.Empty(t, readiness.Dependencies[0].Error)
+		assert.Equal(t, entities.DownDependencyStatus, readiness.Dependencies[1].Status)
+		assert.GreaterOrEqual(t, readiness.Dependencies[1].LatencyMS, float64(10))
+	})
+
+	t.Run("error detail on debug", func(t *testing.T) {
+		http.DebugMode = true
+		defer func() { http.DebugMode = false }()
+		srv := NewService(&MockStorage{}, emptyStepRunners, WithDependency("mgmt_db", down))
+
+		readiness := srv.Readiness(context.Background())
+
+		assert.Equal(t, "connection refused", readiness.Dependencies[0].Error)
+	})
+
+	t.Run("shutting down", func(t *testing.T) {
+		srv := NewService(&MockStorage{}, emptyStepRunners, WithDependency("mgmt_db", up))
+		assert.NoError(t, srv.Shutdown(context.Background()))
+
+		readiness := srv.Readiness(context.Background())
+
+		assert.False(t, readiness.Ready)
+		assert.Equal(t, entities.StoppingSchedulerState, readiness.Scheduler.State)
+	})
+}
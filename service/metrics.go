@@ -0,0 +1,30 @@
+package service
+
+import (
+	"time"
+
+	"github.com/tasker/entities"
+)
+
+// Metrics records the executions, steps and scheduler activity
+type Metrics interface {
+	ExecutionFinished(exec entities.Execution, duration time.Duration)
+	StepFinished(stepType entities.StepType, failed bool, duration time.Duration)
+	// ScheduleFired is called when a cron fires, with the delay since the planned time
+	ScheduleFired(sch entities.ScheduledTask, lag time.Duration)
+	ScheduleRetried(sch entities.ScheduledTask)
+}
+
+// WithMetrics records the service activity, without it nothing is recorded
+func WithMetrics(m Metrics) Option {
+	return func(s *service) {
+		s.metrics = m
+	}
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ExecutionFinished(entities.Execution, time.Duration) {}
+func (noopMetrics) StepFinished(entities.StepType, bool, time.Duration) {}
+func (noopMetrics) ScheduleFired(entities.ScheduledTask, time.Duration) {}
+func (noopMetrics) ScheduleRetried(entities.ScheduledTask)              {}
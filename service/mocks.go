@@ -23,6 +23,21 @@ func (m *MockStorage) GetTask(ctx context.Context, taskID int) (entities.Task, e
 	return args.Get(0).(entities.Task), args.Error(1)
 }
 
+func (m *MockStorage) GetTasks(ctx context.Context, filter entities.TaskFilter) ([]entities.TaskSummary, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entities.TaskSummary), args.Error(1)
+}
+
+func (m *MockStorage) GetTaskByName(ctx context.Context, name string) (entities.Task, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(entities.Task), args.Error(1)
+}
+
+func (m *MockStorage) UpdateTaskSteps(ctx context.Context, taskID int, steps []entities.Step) ([]entities.Step, error) {
+	args := m.Called(ctx, taskID, steps)
+	return args.Get(0).([]entities.Step), args.Error(1)
+}
+
 func (m *MockStorage) SaveExecution(ctx context.Context, exec entities.Execution) (entities.Execution, error) {
 	exec.ExecutedTime = time.Time{} //override executed time with zero time to fulfill tests
 	args := m.Called(ctx, exec)
@@ -34,6 +49,16 @@ func (m *MockStorage) GetExecutionIdempotency(ctx context.Context, idempToken st
 	return args.Get(0).(entities.Execution), args.Error(1)
 }
 
+func (m *MockStorage) GetExecution(ctx context.Context, executionID int) (entities.Execution, error) {
+	args := m.Called(ctx, executionID)
+	return args.Get(0).(entities.Execution), args.Error(1)
+}
+
+func (m *MockStorage) GetExecutionResume(ctx context.Context, executionID int) (entities.Execution, error) {
+	args := m.Called(ctx, executionID)
+	return args.Get(0).(entities.Execution), args.Error(1)
+}
+
 func (m *MockStorage) SaveSchedule(ctx context.Context, sch entities.ScheduledTask) (entities.ScheduledTask, error) {
 	args := m.Called(ctx, sch)
 	return args.Get(0).(entities.ScheduledTask), args.Error(1)
@@ -44,17 +69,83 @@ func (m *MockStorage) GetEnabledSchedules(ctx context.Context) ([]entities.Sched
 	return args.Get(0).([]entities.ScheduledTask), args.Error(1)
 }
 
+func (m *MockStorage) GetTaskSchedules(ctx context.Context, taskID int) ([]entities.ScheduledTask, error) {
+	args := m.Called(ctx, taskID)
+	return args.Get(0).([]entities.ScheduledTask), args.Error(1)
+}
+
+func (m *MockStorage) UpdateSchedule(ctx context.Context, sch entities.ScheduledTask) error {
+	args := m.Called(ctx, sch)
+	return args.Error(0)
+}
+
 func (m *MockStorage) SetScheduleLastRun(ctx context.Context, schID int, time time.Time) error {
 	args := m.Called(ctx, schID, time)
 	return args.Error(0)
 }
 
-// MockStorage is a mock implementation of the StepRunner interface
+func (m *MockStorage) SaveSecret(ctx context.Context, name string, encryptedValue []byte) error {
+	args := m.Called(ctx, name, encryptedValue)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetSecret(ctx context.Context, name string) ([]byte, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+// MockStepRunner is a mock implementation of the StepRunner interface
 type MockStepRunner struct {
 	mock.Mock
 }
 
-func (m MockStepRunner) RunStep(ctx context.Context, params map[string]string) (string, error) {
+func (m *MockStepRunner) RunStep(ctx context.Context, params map[string]string) (string, error) {
 	args := m.Called(ctx, params)
 	return args.Get(0).(string), args.Error(1)
 }
+
+func (m *MockStorage) SaveAPIKey(ctx context.Context, key entities.APIKey, keyHash string) (entities.APIKey, error) {
+	args := m.Called(ctx, key, keyHash)
+	return args.Get(0).(entities.APIKey), args.Error(1)
+}
+
+func (m *MockStorage) GetAPIKey(ctx context.Context, keyID int) (entities.APIKey, error) {
+	args := m.Called(ctx, keyID)
+	return args.Get(0).(entities.APIKey), args.Error(1)
+}
+
+func (m *MockStorage) GetAPIKeyByHash(ctx context.Context, keyHash string) (entities.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	return args.Get(0).(entities.APIKey), args.Error(1)
+}
+
+func (m *MockStorage) GetAPIKeys(ctx context.Context) ([]entities.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entities.APIKey), args.Error(1)
+}
+
+func (m *MockStorage) RevokeAPIKey(ctx context.Context, keyID int, revokedAt time.Time) error {
+	args := m.Called(ctx, keyID, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockStorage) SaveWorkspace(ctx context.Context, workspace entities.Workspace) (entities.Workspace, error) {
+	args := m.Called(ctx, workspace)
+	return args.Get(0).(entities.Workspace), args.Error(1)
+}
+
+func (m *MockStorage) GetWorkspaces(ctx context.Context) ([]entities.Workspace, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entities.Workspace), args.Error(1)
+}
+
+func (m *MockStorage) GetWorkspaceByName(ctx context.Context, name string) (entities.Workspace, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(entities.Workspace), args.Error(1)
+}
+
+// InTransaction runs fn with the same context, the mocked calls made by fn aren't rolled back
+func (m *MockStorage) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.Called(ctx)
+	return fn(ctx)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+	"github.com/tasker/service/secrets"
+	"github.com/tasker/tracing"
+)
+
+// ResumeExecution reruns the failed execution from its failed step inside a span, like ExecuteTask. The steps before it
+// aren't run again, their outputs are reused from the trace of the failed execution
+func (s service) ResumeExecution(ctx context.Context, executionID int, idempToken string) (entities.Execution, error) {
+	ctx, span := s.tracer.Start(ctx, "resume execution", tracing.InternalSpanKind)
+	defer span.End()
+	span.SetAttribute("execution.resumed_from", executionID)
+	span.SetAttribute("execution.idempotency_token", idempToken)
+
+	exec, err := s.resumeExecution(ctx, executionID, idempToken)
+	if err != nil {
+		span.SetError(err)
+		return exec, err
+	}
+	span.SetAttribute("execution.id", exec.ID)
+	span.SetAttribute("execution.status", string(exec.Status))
+	if exec.Status != entities.SuccessExecutionStatus {
+		span.SetError(fmt.Errorf("execution finished with %s status", exec.Status))
+	}
+	return exec, nil
+}
+
+func (s service) resumeExecution(ctx context.Context, executionID int, idempToken string) (entities.Execution, error) {
+	exec, err := s.storage.GetExecutionIdempotency(ctx, idempToken)
+	switch {
+	case err != nil && !http.IsNotFoundErr(err):
+		return entities.Execution{}, fmt.Errorf("checking idempotency: %w", err)
+	case exec.ID != 0 && exec.ResumedFrom != executionID: //The tokens are shared with the executions of /execute
+		return entities.Execution{}, http.ErrConflict.WithMessage("the idempotency token was already used by an execution that doesn't resume this one")
+	case exec.ID != 0: //Already resumed, return result
+		return exec, nil
+	}
+
+	//Resuming again would repeat the side effects of the failed step and the ones after it, the resume is resumed instead
+	resume, err := s.storage.GetExecutionResume(ctx, executionID)
+	switch {
+	case err != nil && !http.IsNotFoundErr(err):
+		return entities.Execution{}, fmt.Errorf("checking execution resume: %w", err)
+	case resume.ID != 0:
+		return entities.Execution{}, http.ErrConflict.WithMessage(fmt.Sprintf("the execution was already resumed by execution %d", resume.ID))
+	}
+
+	original, err := s.storage.GetExecution(ctx, executionID)
+	if err != nil {
+		return entities.Execution{}, fmt.Errorf("getting execution to resume: %w", err)
+	}
+	failedStep, found := resumeStep(original)
+	if !found {
+		return entities.Execution{}, http.ErrConflict.WithMessage("execution has no failed step to resume from")
+	}
+
+	task, err := s.storage.GetTask(ctx, original.TaskID)
+	if err != nil {
+		return entities.Execution{}, fmt.Errorf("getting task to resume: %w", err)
+	}
+	if err := checkResumedTask(original, task, failedStep); err != nil {
+		return entities.Execution{}, err
+	}
+
+	outputs := map[int]string{}
+	for _, trace := range original.Steps {
+		if trace.Step < failedStep && !trace.FailureStep {
+			outputs[trace.Step] = trace.Output
+		}
+	}
+	//The traces are saved redacted, the failed step would get the placeholder instead of the secret value
+	if strings.Contains(outputs[failedStep-1], secrets.RedactedValue) {
+		return entities.Execution{}, http.ErrConflict.WithMessage(fmt.Sprintf("the output of step %d was redacted, it can't be reused", failedStep-1))
+	}
+	reuse := func(ctx context.Context, trace entities.StepTrace, step entities.Step) (entities.StepTrace, error) {
+		if output, found := outputs[trace.Step]; found && !trace.FailureStep {
+			trace.Mode, trace.Output = entities.ReusedStepTraceMode, output
+			return trace, nil
+		}
+		return s.runTracedStep(ctx, trace, step)
+	}
+
+	exec = entities.Execution{ScheduledTask: original.ScheduledTask, TaskID: original.TaskID, IdempotencyToken: idempToken, ResumedFrom: original.ID}
+	return s.runExecution(ctx, task, exec, reuse)
+}
+
+// resumeStep is the position of the step that failed the execution, its failure step doesn't count even if it failed too
+func resumeStep(exec entities.Execution) (int, bool) {
+	if exec.Status != entities.FailureExecutionStatus && exec.Status != entities.HandledFailureExecutionStatus {
+		return 0, false
+	}
+	for _, trace := range exec.Steps {
+		if trace.Error != "" && !trace.FailureStep {
+			return trace.Step, true
+		}
+	}
+	return 0, false
+}
+
+// checkResumedTask fails when the steps up to the failed one changed since the execution, their outputs wouldn't match
+func checkResumedTask(exec entities.Execution, task entities.Task, failedStep int) error {
+	if len(task.Steps) <= failedStep {
+		return http.ErrConflict.WithMessage("the task steps changed since the execution, it can't be resumed")
+	}
+	for _, trace := range exec.Steps {
+		if trace.Step > failedStep || trace.FailureStep {
+			continue
+		}
+		if task.Steps[trace.Step].Type != trace.Type {
+			return http.ErrConflict.WithMessage("the task steps changed since the execution, it can't be resumed")
+		}
+		//The failed step runs again with its current params, the outputs of the ones before must come from the same params
+		if trace.Step < failedStep && trace.ParamsHash != stepParamsHash(task.Steps[trace.Step]) {
+			return http.ErrConflict.WithMessage(fmt.Sprintf("the params of step %d changed since the execution, its output can't be reused", trace.Step))
+		}
+	}
+
+	//The execution scoped keys of the failed execution were cleaned when it ended, and the resumed one has its own
+	for i, step := range task.Steps[:failedStep] {
+		writesStorage := step.Type == entities.StorageWriteStepType || step.Type == entities.StorageOpStepType
+		if writesStorage && StorageScope(step.Params[StorageScopeParam]) == ExecutionStorageScope {
+			return http.ErrConflict.WithMessage(fmt.Sprintf("step %d writes execution scoped storage, the resumed steps wouldn't find it", i))
+		}
+	}
+	return nil
+}
+
+// stepParamsHash identifies the params defined on the step, without the result of the step before it
+func stepParamsHash(step entities.Step) string {
+	keys := make([]string, 0, len(step.Params))
+	for key := range step.Params {
+		if key != LastStepResultKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		//The lengths keep the pairs apart whatever they hold
+		fmt.Fprintf(hash, "%d:%s%d:%s", len(key), key, len(step.Params[key]), step.Params[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+)
+
+func Test_service_ResumeExecution(t *testing.T) {
+	newTask := func() entities.Task {
+		return entities.Task{ID: 1, Name: "report", Steps: []entities.Step{
+			{Type: entities.APICallStepType, Params: map[string]string{"url_api": "http://localhost"}},
+			{Type: entities.CommandStepType, Params: map[string]string{"command": "sync"}, FailureStep: &entities.Step{
+				Type: entities.EmailStepType, Params: map[string]string{"to_email": "oncall@b.c"},
+			}},
+			{Type: entities.EmailStepType, Params: map[string]string{"to_email": "a@b.c"}},
+		}}
+	}
+	failed := entities.Execution{ID: 7, TaskID: 1, ScheduledTask: 2, Status: entities.HandledFailureExecutionStatus, Steps: []entities.StepTrace{
+		{Step: 0, Type: entities.APICallStepType, Mode: entities.RunStepTraceMode, Output: `{"id":7}`, ParamsHash: stepParamsHash(newTask().Steps[0])},
+		{Step: 1, Type: entities.CommandStepType, Mode: entities.RunStepTraceMode, Error: "connection refused", ParamsHash: stepParamsHash(newTask().Steps[1])},
+		{Step: 1, FailureStep: true, Type: entities.EmailStepType, Mode: entities.RunStepTraceMode, Output: "notified", ParamsHash: stepParamsHash(*newTask().Steps[1].FailureStep)},
+	}}
+
+	t.Run("resumes from the failed step", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecutionResume", mock.Anything, 7).Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecution", mock.Anything, 7).Return(failed, nil)
+		mockStorage.On("GetTask", mock.Anything, 1).Return(newTask(), nil)
+		expectedExecution := entities.Execution{
+			WorkspaceID:      entities.DefaultWorkspaceID,
+			TaskID:           1,
+			ScheduledTask:    2,
+			IdempotencyToken: "resume-token",
+			Status:           entities.SuccessExecutionStatus,
+			ExecutedTime:     time.Time{},
+			ResumedFrom:      7,
+			Steps: []entities.StepTrace{
+				{Step: 0, Type: entities.APICallStepType, Mode: entities.ReusedStepTraceMode, Params: map[string]string{"url_api": "http://localhost"}, Output: `{"id":7}`, ParamsHash: failed.Steps[0].ParamsHash},
+				{Step: 1, Type: entities.CommandStepType, Mode: entities.RunStepTraceMode, Params: map[string]string{"command": "sync", LastStepResultKey: `{"id":7}`}, Output: "synced", ParamsHash: failed.Steps[1].ParamsHash},
+				{Step: 2, Type: entities.EmailStepType, Mode: entities.RunStepTraceMode, Params: map[string]string{"to_email": "a@b.c", LastStepResultKey: "synced"}, Output: "sent", ParamsHash: stepParamsHash(newTask().Steps[2])},
+			},
+		}
+		mockStorage.On("SaveExecution", mock.Anything, expectedExecution).Return(expectedExecution, nil)
+		command, email := MockStepRunner{}, MockStepRunner{}
+		command.On("RunStep", mock.Anything, map[string]string{"command": "sync", LastStepResultKey: `{"id":7}`}).Return("synced", nil)
+		email.On("RunStep", mock.Anything, map[string]string{"to_email": "a@b.c", LastStepResultKey: "synced"}).Return("sent", nil)
+		//Every other runner panics if it's called
+		srv := NewService(&mockStorage, map[entities.StepType]StepRunner{
+			entities.APICallStepType:      StepRunner(nil),
+			entities.StorageReadStepType:  StepRunner(nil),
+			entities.StorageWriteStepType: StepRunner(nil),
+			entities.SQLQueryStepType:     StepRunner(nil),
+			entities.CommandStepType:      &command,
+			entities.EmailStepType:        &email,
+			entities.StorageOpStepType:    StepRunner(nil),
+		})
+
+		execution, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedExecution, execution)
+		mockStorage.AssertExpectations(t)
+		command.AssertExpectations(t)
+		email.AssertExpectations(t)
+	})
+
+	t.Run("already resumed", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		resumed := entities.Execution{ID: 8, ResumedFrom: 7, Status: entities.SuccessExecutionStatus}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(resumed, nil)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		execution, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, resumed, execution)
+		mockStorage.AssertNotCalled(t, "GetExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("token of another execution", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		executed := entities.Execution{ID: 9, TaskID: 3, Status: entities.SuccessExecutionStatus}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(executed, nil)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError) {
+			assert.Equal(t, "conflict", httpError.Code())
+		}
+		mockStorage.AssertNotCalled(t, "GetExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("already resumed with another token", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "other-token").Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecutionResume", mock.Anything, 7).Return(entities.Execution{ID: 8, ResumedFrom: 7}, nil)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, err := srv.ResumeExecution(context.Background(), 7, "other-token")
+
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError) {
+			assert.Equal(t, "conflict", httpError.Code())
+			assert.Equal(t, "the execution was already resumed by execution 8", err.Error())
+		}
+		mockStorage.AssertNotCalled(t, "GetExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("missing execution", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecutionResume", mock.Anything, 7).Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecution", mock.Anything, 7).Return(entities.Execution{}, http.ErrNotFound)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		assert.True(t, http.IsNotFoundErr(err))
+	})
+
+	t.Run("successful execution", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecutionResume", mock.Anything, 7).Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecution", mock.Anything, 7).Return(entities.Execution{ID: 7, TaskID: 1, Status: entities.SuccessExecutionStatus, Steps: failed.Steps[:1]}, nil)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError) {
+			assert.Equal(t, "conflict", httpError.Code())
+		}
+		mockStorage.AssertNotCalled(t, "GetTask", mock.Anything, mock.Anything)
+	})
+
+	t.Run("task steps changed", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecutionResume", mock.Anything, 7).Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecution", mock.Anything, 7).Return(failed, nil)
+		changed := newTask()
+		changed.Steps[0].Type = entities.SQLQueryStepType
+		mockStorage.On("GetTask", mock.Anything, 1).Return(changed, nil)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError) {
+			assert.Equal(t, "conflict", httpError.Code())
+		}
+		mockStorage.AssertNotCalled(t, "SaveExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("task step params changed", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecutionResume", mock.Anything, 7).Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecution", mock.Anything, 7).Return(failed, nil)
+		changed := newTask()
+		changed.Steps[0].Params["url_api"] = "http://other"
+		mockStorage.On("GetTask", mock.Anything, 1).Return(changed, nil)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError) {
+			assert.Equal(t, "conflict", httpError.Code())
+			assert.Equal(t, "the params of step 0 changed since the execution, its output can't be reused", err.Error())
+		}
+		mockStorage.AssertNotCalled(t, "SaveExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("reused execution scoped write", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(entities.Execution{}, http.ErrNotFound)
+		scoped := failed
+		scoped.Steps = append([]entities.StepTrace{}, failed.Steps...)
+		scoped.Steps[0].Type = entities.StorageWriteStepType
+		mockStorage.On("GetExecutionResume", mock.Anything, 7).Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecution", mock.Anything, 7).Return(scoped, nil)
+		task := newTask()
+		task.Steps[0] = entities.Step{Type: entities.StorageWriteStepType, Params: map[string]string{"storage_key": "k", "storage_value": "v", StorageScopeParam: string(ExecutionStorageScope)}}
+		scoped.Steps[0].ParamsHash = stepParamsHash(task.Steps[0])
+		mockStorage.On("GetTask", mock.Anything, 1).Return(task, nil)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError) {
+			assert.Equal(t, "conflict", httpError.Code())
+			assert.Equal(t, "step 0 writes execution scoped storage, the resumed steps wouldn't find it", err.Error())
+		}
+		mockStorage.AssertNotCalled(t, "SaveExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("redacted output", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(entities.Execution{}, http.ErrNotFound)
+		redacted := failed
+		redacted.Steps = append([]entities.StepTrace{}, failed.Steps...)
+		redacted.Steps[0].Output = `{"token":"[REDACTED]"}`
+		mockStorage.On("GetExecutionResume", mock.Anything, 7).Return(entities.Execution{}, http.ErrNotFound)
+		mockStorage.On("GetExecution", mock.Anything, 7).Return(redacted, nil)
+		mockStorage.On("GetTask", mock.Anything, 1).Return(newTask(), nil)
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		var httpError http.Error
+		if assert.ErrorAs(t, err, &httpError) {
+			assert.Equal(t, "conflict", httpError.Code())
+		}
+		mockStorage.AssertNotCalled(t, "SaveExecution", mock.Anything, mock.Anything)
+	})
+
+	t.Run("idempotency error", func(t *testing.T) {
+		mockStorage := MockStorage{}
+		mockStorage.On("GetExecutionIdempotency", mock.Anything, "resume-token").Return(entities.Execution{}, errors.New("mocked-error"))
+		srv := NewService(&mockStorage, emptyStepRunners)
+
+		_, err := srv.ResumeExecution(context.Background(), 7, "resume-token")
+
+		assert.EqualError(t, err, "checking idempotency: mocked-error")
+	})
+}
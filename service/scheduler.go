@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,48 +21,73 @@ func (s service) CreateSchedule(ctx context.Context, sch entities.ScheduledTask)
 	}
 
 	//Save schedule
-	return s.storage.SaveSchedule(ctx, sch)
+	sch, err = s.storage.SaveSchedule(ctx, sch)
+	if err != nil {
+		return entities.ScheduledTask{}, err
+	}
+	s.audit(ctx, entities.AuditEvent{Action: entities.CreateAuditAction, EntityType: entities.ScheduleAuditEntity, EntityID: strconv.Itoa(sch.ID)}, nil, scheduleAuditState(sch))
+
+	return sch, nil
 }
 
 func (s service) ExecuteScheduledTasks(ctx context.Context) error {
-	//Get enabled schedules
+	//Get enabled schedules, only the ones of the context workspace, every workspace starts its own loop
 	schedules, err := s.storage.GetEnabledSchedules(ctx)
 	if err != nil {
 		return fmt.Errorf("getting enabled schedules: %w", err)
 	}
 
 	//Iterate over and create go routines to execute them according to the cron
-	c := cron.New()
+	c := cron.New(cron.WithLocation(s.location))
 	for _, sch := range schedules {
 		auxSch := sch
-		//AddFunc will execute the provided function on a new goroutine according to the cron
-		c.AddFunc(sch.Cron, func() { s.ExecuteScheduleTask(ctx, auxSch) })
+		var entryID cron.EntryID
+		//AddFunc will execute the provided function on a new goroutine according to the cron, Prev is the planned time
+		entryID, _ = c.AddFunc(sch.Cron, func() {
+			if planned := c.Entry(entryID).Prev; !planned.IsZero() {
+				s.metrics.ScheduleFired(auxSch, time.Since(planned))
+			}
+			s.ExecuteScheduleTask(ctx, auxSch)
+		})
 	}
 
 	//Start the cron checker
 	c.Start()
+	defer s.runs.schedulerStarted(len(schedules))()
 
-	//Wait for context done (request cancellation)
+	//Wait for context done (request cancellation) or the shutdown. The jobs run on the request context, so on shutdown
+	//the request is kept open until they finish, or the shutdown deadline saves them as interrupted
 	select {
 	case <-ctx.Done():
+		c.Stop()
 		return ctx.Err()
+	case <-s.runs.stopping:
+		<-c.Stop().Done()
+		return nil
 	}
 }
 
-const taskExecutionWaitTime = time.Second * 30
+const defaultTaskTimeout = time.Second * 30
 
 func (s service) ExecuteScheduleTask(ctx context.Context, sch entities.ScheduledTask) {
 	var err error
 	for i := 0; i < sch.Retries; i++ {
+		if i > 0 {
+			s.metrics.ScheduleRetried(sch)
+		}
 		//Set context with time out to prevent that the execution runs for undefined periods (while still creating other goroutines)
-		ctxWithTimeOut, _ := context.WithTimeout(ctx, taskExecutionWaitTime)
+		ctxWithTimeOut, cancel := context.WithTimeout(ctx, s.taskTimeout)
 		_, err = s.ExecuteTask(ctxWithTimeOut, sch.Task.ID, sch.ID, uuid.New().String())
+		cancel()
+		if errors.Is(err, ErrShuttingDown) {
+			return
+		}
 		if err == nil {
 			break
 		}
 	}
 
 	if err := s.storage.SetScheduleLastRun(ctx, sch.ID, time.Now()); err != nil {
-		log.Printf("Error setting scheduled_task last_run date for schedule %d: %s", sch.ID, err)
+		slog.ErrorContext(ctx, "setting scheduled_task last_run date", "schedule_id", sch.ID, "error", err)
 	}
 }
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tasker/entities"
+	"github.com/tasker/service/secrets"
+)
+
+var errSecretsDisabled = errors.New("secrets store is not configured")
+
+// CreateSecret encrypts and saves the secret, the returned secret never carries the value
+func (s service) CreateSecret(ctx context.Context, secret entities.Secret) (entities.Secret, error) {
+	if s.cipher == nil {
+		return entities.Secret{}, errSecretsDisabled
+	}
+
+	encrypted, err := s.cipher.Encrypt([]byte(secret.Value))
+	if err != nil {
+		return entities.Secret{}, fmt.Errorf("encrypting secret: %w", err)
+	}
+
+	//Saving replaces an existing secret, look it up first to audit it as an update
+	action, before := entities.CreateAuditAction, any(nil)
+	if s.auditLog != nil {
+		if _, err := s.storage.GetSecret(ctx, secret.Name); err == nil {
+			action, before = entities.UpdateAuditAction, secretAuditState(secret.Name)
+		}
+	}
+
+	if err := s.storage.SaveSecret(ctx, secret.Name, encrypted); err != nil {
+		return entities.Secret{}, fmt.Errorf("saving secret: %w", err)
+	}
+	s.audit(ctx, entities.AuditEvent{Action: action, EntityType: entities.SecretAuditEntity, EntityID: secret.Name}, before, secretAuditState(secret.Name))
+
+	return entities.Secret{Name: secret.Name}, nil
+}
+
+// resolveSecrets returns the params with every secret reference replaced by its value, registering the values on the
+// context redactor. If there are no references the same params are returned
+func (s service) resolveSecrets(ctx context.Context, params map[string]string) (map[string]string, error) {
+	resolved, copied := params, false
+	for key, value := range params {
+		name, isRef := secrets.ParseRef(value)
+		if !isRef {
+			continue
+		}
+
+		secretValue, err := s.getSecret(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("resolving secret %q of param %s: %w", name, key, err)
+		}
+		secrets.RedactorFromContext(ctx).Add(secretValue)
+
+		//Copy on first reference, the task params must keep the references
+		if !copied {
+			resolved = make(map[string]string, len(params))
+			for k, v := range params {
+				resolved[k] = v
+			}
+			copied = true
+		}
+		resolved[key] = secretValue
+	}
+
+	return resolved, nil
+}
+
+func (s service) getSecret(ctx context.Context, name string) (string, error) {
+	if s.cipher == nil {
+		return "", errSecretsDisabled
+	}
+
+	encrypted, err := s.storage.GetSecret(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("getting secret: %w", err)
+	}
+
+	value, err := s.cipher.Decrypt(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("decrypting secret: %w", err)
+	}
+
+	return string(value), nil
+}
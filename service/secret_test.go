@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/entities"
+	"github.com/tasker/service/secrets"
+)
+
+var testSecretsKey = []byte("0123456789abcdef0123456789abcdef")
+
+func Test_service_CreateSecret_Disabled(t *testing.T) {
+	mockStorage := MockStorage{}
+	srv := NewService(&mockStorage, emptyStepRunners)
+
+	_, err := srv.CreateSecret(context.Background(), entities.Secret{Name: "api_key", Value: "value"})
+
+	assert.ErrorIs(t, err, errSecretsDisabled)
+	mockStorage.AssertExpectations(t)
+}
+
+func Test_service_CreateSecret(t *testing.T) {
+	cipher, err := secrets.NewAESGCM(testSecretsKey)
+	assert.NoError(t, err)
+
+	mockStorage := MockStorage{}
+	mockStorage.On("SaveSecret", mock.Anything, "api_key", mock.MatchedBy(func(encrypted []byte) bool {
+		decrypted, err := cipher.Decrypt(encrypted)
+		return err == nil && string(decrypted) == "value"
+	})).Return(nil)
+	srv := NewService(&mockStorage, emptyStepRunners, WithCipher(cipher))
+
+	secret, err := srv.CreateSecret(context.Background(), entities.Secret{Name: "api_key", Value: "value"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.Secret{Name: "api_key"}, secret)
+	mockStorage.AssertExpectations(t)
+}
+
+func Test_service_runStep_ResolvesAndRedactsSecrets(t *testing.T) {
+	cipher, err := secrets.NewAESGCM(testSecretsKey)
+	assert.NoError(t, err)
+	encrypted, err := cipher.Encrypt([]byte("s3cr3t"))
+	assert.NoError(t, err)
+
+	mockStorage := MockStorage{}
+	mockStorage.On("GetSecret", mock.Anything, "api_key").Return(encrypted, nil)
+
+	mockStepRunner := MockStepRunner{}
+	mockStepRunner.On("RunStep", mock.Anything, map[string]string{"token": "s3cr3t", "url": "https://example.com"}).
+		Return("", errors.New("unauthorized token s3cr3t"))
+	stepRunners := map[entities.StepType]StepRunner{
+		entities.APICallStepType:      &mockStepRunner,
+		entities.StorageReadStepType:  StepRunner(nil),
+		entities.StorageWriteStepType: StepRunner(nil),
+		entities.SQLQueryStepType:     StepRunner(nil),
+		entities.CommandStepType:      StepRunner(nil),
+		entities.EmailStepType:        StepRunner(nil),
+		entities.StorageOpStepType:    StepRunner(nil),
+	}
+
+	srv := NewService(&mockStorage, stepRunners, WithCipher(cipher)).(service)
+	step := entities.Step{
+		Type:   entities.APICallStepType,
+		Params: map[string]string{"token": "secret://api_key", "url": "https://example.com"},
+	}
+
+	_, err = srv.runStep(context.Background(), step)
+
+	assert.EqualError(t, err, "unauthorized token [REDACTED]")
+	assert.Equal(t, "secret://api_key", step.Params["token"], "task params must keep the reference")
+	mockStorage.AssertExpectations(t)
+	mockStepRunner.AssertExpectations(t)
+}
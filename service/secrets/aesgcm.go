@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// AESGCM encrypts secret values with AES-GCM, prefixing every ciphertext with its random nonce
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCM creates a cipher from a 16, 24 or 32 bytes key
+func NewAESGCM(key []byte) (AESGCM, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return AESGCM{}, fmt.Errorf("creating aes cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return AESGCM{}, fmt.Errorf("creating gcm cipher: %w", err)
+	}
+
+	return AESGCM{aead: aead}, nil
+}
+
+// NewAESGCMFromBase64 creates a cipher from a base64 (std encoding) encoded key
+func NewAESGCMFromBase64(encodedKey string) (AESGCM, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return AESGCM{}, fmt.Errorf("decoding secrets key: %w", err)
+	}
+	return NewAESGCM(key)
+}
+
+func (c AESGCM) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (c AESGCM) Decrypt(ciphertext []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting secret: %w", err)
+	}
+	return plaintext, nil
+}
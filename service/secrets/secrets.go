@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RefPrefix marks a step param value as a reference to a stored secret, e.g. "secret://partner_api_key"
+const RefPrefix = "secret://"
+
+// RedactedValue replaces every known secret value on errors, logs and traces
+const RedactedValue = "[REDACTED]"
+
+// ParseRef returns the secret name referenced by the value, if the value is a secret reference
+func ParseRef(value string) (string, bool) {
+	if !strings.HasPrefix(value, RefPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(value, RefPrefix)
+	return name, name != ""
+}
+
+// Redactor keeps track of the secret values resolved during an execution and hides them from any text
+type Redactor struct {
+	mu     sync.RWMutex
+	values []string
+}
+
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// Add registers a resolved secret value to be redacted from now on
+func (r *Redactor) Add(value string) {
+	if r == nil || value == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.values {
+		if v == value {
+			return
+		}
+	}
+	r.values = append(r.values, value)
+	//Longest values first, so a secret that contains another one is fully redacted
+	sort.Slice(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+}
+
+// Redact replaces every registered secret value found on s
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, RedactedValue)
+	}
+	return s
+}
+
+// RedactError returns an error with the same chain as err but a redacted message
+func (r *Redactor) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := r.Redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return redactedError{msg: msg, err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e redactedError) Error() string {
+	return e.msg
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
+
+type redactorKey struct{}
+
+// ContextWithRedactor attaches the redactor to the context so every layer of an execution redacts the same values
+func ContextWithRedactor(ctx context.Context, r *Redactor) context.Context {
+	return context.WithValue(ctx, redactorKey{}, r)
+}
+
+// RedactorFromContext returns the redactor of the context, or nil if there is none. A nil *Redactor redacts nothing
+func RedactorFromContext(ctx context.Context) *Redactor {
+	r, _ := ctx.Value(redactorKey{}).(*Redactor)
+	return r
+}
@@ -3,32 +3,122 @@ package service
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/tasker/entities"
+	"github.com/tasker/tracing"
 )
 
 type Storage interface {
 	SaveTask(ctx context.Context, task entities.Task) (entities.Task, error)
 	GetTask(ctx context.Context, taskID int) (entities.Task, error)
+	GetTasks(ctx context.Context, filter entities.TaskFilter) ([]entities.TaskSummary, error)
+	GetTaskByName(ctx context.Context, name string) (entities.Task, error)
+	UpdateTaskSteps(ctx context.Context, taskID int, steps []entities.Step) ([]entities.Step, error)
 	SaveExecution(ctx context.Context, exec entities.Execution) (entities.Execution, error)
 	GetExecutionIdempotency(ctx context.Context, idempToken string) (entities.Execution, error)
+	GetExecution(ctx context.Context, executionID int) (entities.Execution, error)
+	GetExecutionResume(ctx context.Context, executionID int) (entities.Execution, error)
 	SaveSchedule(ctx context.Context, sch entities.ScheduledTask) (entities.ScheduledTask, error)
 	GetEnabledSchedules(ctx context.Context) ([]entities.ScheduledTask, error)
+	GetTaskSchedules(ctx context.Context, taskID int) ([]entities.ScheduledTask, error)
+	UpdateSchedule(ctx context.Context, sch entities.ScheduledTask) error
 	SetScheduleLastRun(ctx context.Context, schID int, time time.Time) error
+	SaveSecret(ctx context.Context, name string, encryptedValue []byte) error
+	GetSecret(ctx context.Context, name string) ([]byte, error)
+	SaveAPIKey(ctx context.Context, key entities.APIKey, keyHash string) (entities.APIKey, error)
+	GetAPIKey(ctx context.Context, keyID int) (entities.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (entities.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]entities.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int, revokedAt time.Time) error
+	SaveWorkspace(ctx context.Context, workspace entities.Workspace) (entities.Workspace, error)
+	GetWorkspaces(ctx context.Context) ([]entities.Workspace, error)
+	GetWorkspaceByName(ctx context.Context, name string) (entities.Workspace, error)
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Cipher encrypts the secret values before they reach the storage
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// ExecutionCleaner removes what an execution left behind once it ends
+type ExecutionCleaner interface {
+	CleanExecution(ctx context.Context, exec ExecutionInfo) error
 }
 
 type Service interface {
 	CreateTask(ctx context.Context, task entities.Task) (entities.Task, error)
 	GetTask(ctx context.Context, taskID int) (entities.Task, error)
+	GetTasks(ctx context.Context, filter entities.TaskFilter) (entities.TaskPage, error)
+	ExportBundle(ctx context.Context) (entities.Bundle, error)
+	ImportBundle(ctx context.Context, bundle entities.Bundle, dryRun bool) (entities.ImportResult, error)
 	ExecuteTask(ctx context.Context, taskID int, scheduleID int, idempToken string) (entities.Execution, error)
+	DryRunTask(ctx context.Context, taskID int, dryRun entities.DryRun) (entities.DryRunResult, error)
+	ResumeExecution(ctx context.Context, executionID int, idempToken string) (entities.Execution, error)
 	CreateSchedule(ctx context.Context, sch entities.ScheduledTask) (entities.ScheduledTask, error)
 	ExecuteScheduledTasks(ctx context.Context) error
+	CreateSecret(ctx context.Context, secret entities.Secret) (entities.Secret, error)
+	GetAuditEvents(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error)
+	CreateAPIKey(ctx context.Context, key entities.APIKey) (entities.APIKey, string, error)
+	GetAPIKeys(ctx context.Context) ([]entities.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int) error
+	Authenticate(ctx context.Context, secret string) (entities.APIKey, error)
+	CreateWorkspace(ctx context.Context, workspace entities.Workspace) (entities.Workspace, error)
+	GetWorkspaces(ctx context.Context) ([]entities.Workspace, error)
+	GetWorkspaceByName(ctx context.Context, name string) (entities.Workspace, error)
+	Shutdown(ctx context.Context) error
+	Readiness(ctx context.Context) entities.Readiness
 }
 
 type service struct {
 	storage     Storage
 	stepRunners map[entities.StepType]StepRunner
+	cipher      Cipher
+	cleaner     ExecutionCleaner
+	auditLog    AuditLog
+	taskTimeout time.Duration
+	location    *time.Location
+	runs        *runTracker
+
+	dependencies       []dependency
+	healthCheckTimeout time.Duration
+	metrics            Metrics
+	tracer             *tracing.Tracer
+}
+
+// Option configures the optional dependencies of the service
+type Option func(*service)
+
+// WithCipher enables the secrets store, without it secrets can't be saved nor resolved
+func WithCipher(c Cipher) Option {
+	return func(s *service) {
+		s.cipher = c
+	}
+}
+
+// WithExecutionCleaner cleans the execution scoped storage keys when each execution ends
+func WithExecutionCleaner(c ExecutionCleaner) Option {
+	return func(s *service) {
+		s.cleaner = c
+	}
+}
+
+// WithTracer exports a span per execution and step, without it spans are only used to propagate the trace context
+func WithTracer(t *tracing.Tracer) Option {
+	return func(s *service) {
+		s.tracer = t
+	}
+}
+
+// WithScheduler sets the timeout of each scheduled execution attempt and the timezone the crons are evaluated on
+func WithScheduler(taskTimeout time.Duration, location *time.Location) Option {
+	return func(s *service) {
+		s.taskTimeout = taskTimeout
+		s.location = location
+	}
 }
 
 func (s service) CreateTask(ctx context.Context, task entities.Task) (entities.Task, error) {
@@ -36,6 +126,7 @@ func (s service) CreateTask(ctx context.Context, task entities.Task) (entities.T
 	if err != nil {
 		return entities.Task{}, fmt.Errorf("saving task: %w", err)
 	}
+	s.audit(ctx, entities.AuditEvent{Action: entities.CreateAuditAction, EntityType: entities.TaskAuditEntity, EntityID: strconv.Itoa(task.ID)}, nil, task)
 
 	return task, nil
 }
@@ -49,9 +140,32 @@ func (s service) GetTask(ctx context.Context, taskID int) (entities.Task, error)
 	return task, nil
 }
 
-func NewService(str Storage, stepRunners map[entities.StepType]StepRunner) Service {
+// GetTasks returns a page of the tasks matching the filter, one more task is read to know if there is a next page
+func (s service) GetTasks(ctx context.Context, filter entities.TaskFilter) (entities.TaskPage, error) {
+	limit := filter.Limit
+	if limit > 0 {
+		filter.Limit++
+	}
+	tasks, err := s.storage.GetTasks(ctx, filter)
+	if err != nil {
+		return entities.TaskPage{}, fmt.Errorf("getting tasks: %w", err)
+	}
+
+	page := entities.TaskPage{Tasks: tasks}
+	if limit > 0 && len(tasks) > limit {
+		page.Tasks = tasks[:limit]
+		page.NextAfterID = page.Tasks[limit-1].ID
+	}
+	return page, nil
+}
+
+func NewService(str Storage, stepRunners map[entities.StepType]StepRunner, opts ...Option) Service {
 	if err := validStepRunners(stepRunners); err != nil {
 		panic(fmt.Errorf("error validateing step runners, cannot start system: %w", err))
 	}
-	return service{str, stepRunners}
+	srv := service{storage: str, stepRunners: stepRunners, taskTimeout: defaultTaskTimeout, location: time.UTC, runs: newRunTracker(), healthCheckTimeout: defaultHealthCheckTimeout, metrics: noopMetrics{}, tracer: tracing.NewTracer(nil, 0)}
+	for _, opt := range opts {
+		opt(&srv)
+	}
+	return srv
 }
@@ -14,6 +14,10 @@ var emptyStepRunners = map[entities.StepType]StepRunner{
 	entities.APICallStepType:      StepRunner(nil),
 	entities.StorageReadStepType:  StepRunner(nil),
 	entities.StorageWriteStepType: StepRunner(nil),
+	entities.SQLQueryStepType:     StepRunner(nil),
+	entities.CommandStepType:      StepRunner(nil),
+	entities.EmailStepType:        StepRunner(nil),
+	entities.StorageOpStepType:    StepRunner(nil),
 }
 
 func TestNewService_InvalidStepRunners(t *testing.T) {
@@ -117,3 +121,21 @@ func Test_service_GetTask_Success(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, expectedTask, retrievedTask)
 }
+
+func Test_service_GetTasks(t *testing.T) {
+	mockStorage := MockStorage{}
+	srv := NewService(&mockStorage, emptyStepRunners)
+
+	summaries := []entities.TaskSummary{{ID: 3, Name: "a"}, {ID: 5, Name: "b"}, {ID: 8, Name: "c"}}
+	mockStorage.On("GetTasks", mock.Anything, entities.TaskFilter{Name: "x", Limit: 3}).Return(summaries, nil)
+	mockStorage.On("GetTasks", mock.Anything, entities.TaskFilter{Name: "x", AfterID: 5, Limit: 3}).Return(summaries[2:], nil)
+
+	page, err := srv.GetTasks(context.Background(), entities.TaskFilter{Name: "x", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, entities.TaskPage{Tasks: summaries[:2], NextAfterID: 5}, page)
+
+	page, err = srv.GetTasks(context.Background(), entities.TaskFilter{Name: "x", AfterID: 5, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, entities.TaskPage{Tasks: summaries[2:]}, page)
+	mockStorage.AssertExpectations(t)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/tasker/entities"
+)
+
+// interruptedSaveTimeout limits saving the interrupted executions, the shutdown deadline is already over by then
+const interruptedSaveTimeout = 5 * time.Second
+
+var (
+	ErrShuttingDown = errors.New("the service is shutting down")
+	errInterrupted  = errors.New("the execution was interrupted by the shutdown")
+)
+
+// runTracker keeps the executions in progress so the shutdown can wait for them, and record the ones that don't
+// finish in time
+type runTracker struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	stopping chan struct{}
+	// running and interrupted are keyed by the run id given by start, the idempotency tokens may repeat between runs.
+	// The running executions hold the traces of the steps finished so far
+	lastRun     uint64
+	running     map[uint64]entities.Execution
+	interrupted map[uint64]bool
+	// schedules counts the crons of the scheduler loops running
+	schedules  int
+	schedulers int
+}
+
+func newRunTracker() *runTracker {
+	return &runTracker{
+		stopping:    make(chan struct{}),
+		running:     map[uint64]entities.Execution{},
+		interrupted: map[uint64]bool{},
+	}
+}
+
+// start registers an execution and returns its run id, failing once the shutdown began
+func (t *runTracker) start(exec entities.Execution) (uint64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.isStopping() {
+		return 0, ErrShuttingDown
+	}
+	t.wg.Add(1)
+	t.lastRun++
+	t.running[t.lastRun] = exec
+	return t.lastRun, nil
+}
+
+// stepFinished adds the trace of a finished step to the run, an interrupted run is saved with them
+func (t *runTracker) stepFinished(run uint64, trace entities.StepTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if exec, found := t.running[run]; found {
+		exec.Steps = append(exec.Steps[:len(exec.Steps):len(exec.Steps)], trace)
+		t.running[run] = exec
+	}
+}
+
+// finish unregisters a run, returning false if the shutdown already saved it as interrupted
+func (t *runTracker) finish(run uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	defer t.wg.Done()
+	delete(t.running, run)
+	if t.interrupted[run] {
+		delete(t.interrupted, run)
+		return false
+	}
+	return true
+}
+
+// interrupt takes the executions still running, so they are not saved again when they finish
+func (t *runTracker) interrupt() []entities.Execution {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var execs []entities.Execution
+	for run, exec := range t.running {
+		t.interrupted[run] = true
+		execs = append(execs, exec)
+	}
+	return execs
+}
+
+// schedulerStarted registers a scheduler loop with its crons, the returned func unregisters it
+func (t *runTracker) schedulerStarted(schedules int) func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.schedulers++
+	t.schedules += schedules
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.schedulers--
+		t.schedules -= schedules
+	}
+}
+
+func (t *runTracker) schedulerHealth() entities.SchedulerHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	health := entities.SchedulerHealth{
+		State:             entities.IdleSchedulerState,
+		ActiveSchedules:   t.schedules,
+		RunningExecutions: len(t.running),
+	}
+	switch {
+	case t.isStopping():
+		health.State = entities.StoppingSchedulerState
+	case t.schedulers > 0:
+		health.State = entities.RunningSchedulerState
+	}
+	return health
+}
+
+func (t *runTracker) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.isStopping() {
+		close(t.stopping)
+	}
+}
+
+func (t *runTracker) isStopping() bool {
+	select {
+	case <-t.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// Shutdown stops the scheduler from firing new runs, rejects new executions and waits for the running ones until ctx
+// is done. The executions still running then are saved with the interrupted status
+func (s service) Shutdown(ctx context.Context) error {
+	s.runs.stop()
+
+	drained := make(chan struct{})
+	go func() {
+		s.runs.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+	}
+
+	saveCtx, cancel := context.WithTimeout(context.Background(), interruptedSaveTimeout)
+	defer cancel()
+
+	var errs []error
+	execs := s.runs.interrupt()
+	for _, exec := range execs {
+		exec.Status = entities.InterruptedExecutionStatus
+		if _, err := s.storage.SaveExecution(ContextWithWorkspace(saveCtx, exec.WorkspaceID), exec); err != nil {
+			errs = append(errs, fmt.Errorf("saving interrupted execution %s: %w", exec.IdempotencyToken, err))
+			continue
+		}
+		s.metrics.ExecutionFinished(exec, time.Since(exec.ExecutedTime))
+		slog.Warn("execution interrupted by the shutdown", "task_id", exec.TaskID, "schedule_id", exec.ScheduledTask, "idempotency_token", exec.IdempotencyToken)
+	}
+	return errors.Join(errs...)
+}
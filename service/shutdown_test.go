@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/entities"
+	"github.com/tasker/http"
+)
+
+// blockingStepRunner signals when a step starts and holds it until released
+type blockingStepRunner struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b blockingStepRunner) RunStep(ctx context.Context, params map[string]string) (string, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return "step-result", nil
+}
+
+func startBlockedExecution(t *testing.T, mockStorage *MockStorage) (Service, blockingStepRunner, chan error) {
+	mockStorage.On("GetExecutionIdempotency", mock.Anything, "idemp-token").Return(entities.Execution{}, nil)
+	mockStorage.On("GetTask", mock.Anything, 1).Return(entities.Task{ID: 1, Steps: []entities.Step{{ID: 1, Type: "test"}}}, nil)
+
+	runner := blockingStepRunner{started: make(chan struct{}), release: make(chan struct{})}
+	stepRunners := map[entities.StepType]StepRunner{}
+	for stepType, stepRunner := range emptyStepRunners {
+		stepRunners[stepType] = stepRunner
+	}
+	stepRunners["test"] = runner
+	srv := NewService(mockStorage, stepRunners)
+
+	execErr := make(chan error, 1)
+	go func() {
+		_, err := srv.ExecuteTask(context.Background(), 1, 1, "idemp-token")
+		execErr <- err
+	}()
+	<-runner.started
+	return srv, runner, execErr
+}
+
+func withStatus(status string) any {
+	return mock.MatchedBy(func(exec entities.Execution) bool { return string(exec.Status) == status })
+}
+
+func Test_service_Shutdown_DrainsExecutions(t *testing.T) {
+	mockStorage := MockStorage{}
+	srv, runner, execErr := startBlockedExecution(t, &mockStorage)
+	mockStorage.On("SaveExecution", mock.Anything, withStatus(string(entities.SuccessExecutionStatus))).Return(entities.Execution{ID: 1}, nil)
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- srv.Shutdown(context.Background()) }()
+
+	//New executions are rejected while draining
+	time.Sleep(10 * time.Millisecond)
+	_, err := srv.ExecuteTask(context.Background(), 1, 1, "idemp-token")
+	assert.ErrorIs(t, err, ErrShuttingDown)
+
+	close(runner.release)
+	assert.NoError(t, <-execErr)
+	assert.NoError(t, <-shutdownErr)
+	mockStorage.AssertExpectations(t)
+}
+
+func Test_service_Shutdown_InterruptsAfterDeadline(t *testing.T) {
+	mockStorage := MockStorage{}
+	srv, runner, execErr := startBlockedExecution(t, &mockStorage)
+	mockStorage.On("SaveExecution", mock.Anything, withStatus(string(entities.InterruptedExecutionStatus))).Return(entities.Execution{ID: 1}, nil).Once()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, srv.Shutdown(ctx))
+
+	//The execution finishing later is not saved again
+	close(runner.release)
+	assert.ErrorIs(t, <-execErr, errInterrupted)
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNumberOfCalls(t, "SaveExecution", 1)
+}
+
+func Test_service_Shutdown_InterruptsEveryRunWithItsTraces(t *testing.T) {
+	mockStorage := MockStorage{}
+	mockStorage.On("GetExecutionIdempotency", mock.Anything, "").Return(entities.Execution{}, http.ErrNotFound)
+	for _, taskID := range []int{1, 2} {
+		steps := []entities.Step{{ID: 1, Type: "quick", Params: map[string]string{}}, {ID: 2, Type: "test", Params: map[string]string{}}}
+		mockStorage.On("GetTask", mock.Anything, taskID).Return(entities.Task{ID: taskID, Steps: steps}, nil)
+	}
+	mockStorage.On("SaveExecution", mock.Anything, withStatus(string(entities.InterruptedExecutionStatus))).Return(entities.Execution{}, nil).Twice()
+
+	quickRunner := MockStepRunner{}
+	quickRunner.On("RunStep", mock.Anything, mock.Anything).Return("quick-result", nil)
+	runner := blockingStepRunner{started: make(chan struct{}, 2), release: make(chan struct{})}
+	stepRunners := map[entities.StepType]StepRunner{}
+	for stepType, stepRunner := range emptyStepRunners {
+		stepRunners[stepType] = stepRunner
+	}
+	stepRunners["quick"], stepRunners["test"] = &quickRunner, runner
+	srv := NewService(&mockStorage, stepRunners)
+
+	//Both executions share the empty idempotency token
+	execErr := make(chan error, 2)
+	for _, taskID := range []int{1, 2} {
+		go func(taskID int) {
+			_, err := srv.ExecuteTask(context.Background(), taskID, 1, "")
+			execErr <- err
+		}(taskID)
+	}
+	<-runner.started
+	<-runner.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, srv.Shutdown(ctx))
+
+	close(runner.release)
+	assert.ErrorIs(t, <-execErr, errInterrupted)
+	assert.ErrorIs(t, <-execErr, errInterrupted)
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNumberOfCalls(t, "SaveExecution", 2)
+
+	//Each one is saved with the trace of the step finished before the shutdown
+	var savedTasks []int
+	for _, call := range mockStorage.Calls {
+		if call.Method != "SaveExecution" {
+			continue
+		}
+		exec := call.Arguments.Get(1).(entities.Execution)
+		savedTasks = append(savedTasks, exec.TaskID)
+		if assert.Len(t, exec.Steps, 1) {
+			assert.Equal(t, entities.StepType("quick"), exec.Steps[0].Type)
+			assert.Equal(t, "quick-result", exec.Steps[0].Output)
+		}
+	}
+	assert.ElementsMatch(t, []int{1, 2}, savedTasks)
+}
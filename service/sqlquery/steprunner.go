@@ -0,0 +1,179 @@
+package sqlquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tasker/service"
+)
+
+const (
+	dataSourceParam = "data_source_sql"
+	queryParam      = "query_sql"
+	argsParam       = "args_sql"
+	readOnlyParam   = "read_only_sql"
+	timeoutParam    = "timeout_sql"
+)
+
+const defaultTimeout = time.Second * 10
+
+type Repository interface {
+	// Query runs a statement that returns rows, readOnly runs it inside a read only transaction
+	Query(ctx context.Context, dataSource, query string, args []any, readOnly bool) ([]map[string]any, error)
+	// Exec runs a write statement and returns the number of affected rows
+	Exec(ctx context.Context, dataSource, query string, args []any) (int64, error)
+}
+
+type stepRunner struct {
+	repo Repository
+}
+
+func NewStepRunner(repo Repository) stepRunner {
+	return stepRunner{repo: repo}
+}
+
+func (a stepRunner) RunStep(ctx context.Context, params map[string]string) (string, error) {
+	// Get data source from params
+	dataSource, found := params[dataSourceParam]
+	if !found {
+		return "", fmt.Errorf("no data source param found for sql query step")
+	}
+
+	// Get query from params
+	query, found := params[queryParam]
+	if !found || strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("no query param found for sql query step")
+	}
+
+	args, err := parseArgs(params)
+	if err != nil {
+		return "", err
+	}
+
+	readOnly := false
+	if readOnlyStr, found := params[readOnlyParam]; found {
+		if readOnly, err = strconv.ParseBool(readOnlyStr); err != nil {
+			return "", fmt.Errorf("invalid read only param found for sql query step: %w", err)
+		}
+	}
+
+	timeout := defaultTimeout
+	if timeoutStr, found := params[timeoutParam]; found {
+		if timeout, err = time.ParseDuration(timeoutStr); err != nil {
+			return "", fmt.Errorf("invalid timeout param found for sql query step: %w", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if !returnsRows(query) {
+		if readOnly {
+			return "", fmt.Errorf("write statements are not allowed on read only sql query steps")
+		}
+
+		affected, err := a.repo.Exec(ctx, dataSource, query, args)
+		if err != nil {
+			return "", fmt.Errorf("executing statement on %s: %w", dataSource, err)
+		}
+		return fmt.Sprintf(`{"rows_affected": %d}`, affected), nil
+	}
+
+	rows, err := a.repo.Query(ctx, dataSource, query, args, readOnly)
+	if err != nil {
+		return "", fmt.Errorf("running query on %s: %w", dataSource, err)
+	}
+
+	rowsJSON, err := json.Marshal(rows)
+	if err != nil {
+		return "", fmt.Errorf("marshalling query rows: %w", err)
+	}
+	return string(rowsJSON), nil
+}
+
+// parseArgs reads the statement placeholders values from a JSON array, any arg equal to use_last_step_result is
+// replaced by the previous step result
+func parseArgs(params map[string]string) ([]any, error) {
+	argsJSON, found := params[argsParam]
+	if !found {
+		return nil, nil
+	}
+
+	var args []any
+	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
+		return nil, fmt.Errorf("invalid args param found for sql query step, must be a JSON array: %w", err)
+	}
+
+	for i, arg := range args {
+		if arg == service.UseLastStepResultKey {
+			lastResult, found := params[service.LastStepResultKey]
+			if !found {
+				return nil, fmt.Errorf("requested to use last step result as arg but there was no last step result")
+			}
+			args[i] = lastResult
+		}
+	}
+
+	return args, nil
+}
+
+// returnsRows checks the statement keyword to know if it is a query or a write
+func returnsRows(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch strings.ToUpper(strings.TrimLeft(fields[0], "(")) {
+	case "SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN":
+		return true
+	case "WITH":
+		return returnsRows(afterCTEs(query))
+	default:
+		return false
+	}
+}
+
+// afterCTEs returns the statement that follows the common table expressions of a WITH query, the first word out of
+// the parentheses and quotes that starts a statement
+func afterCTEs(query string) string {
+	depth, quote, previous := 0, rune(0), rune(0)
+	for i, char := range query {
+		wordStart := !isWordChar(previous)
+		previous = char
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case depth == 0 && wordStart && unicode.IsLetter(char):
+			rest := query[i:]
+			word := strings.FieldsFunc(rest, func(r rune) bool { return !isWordChar(r) })[0]
+			switch strings.ToUpper(word) {
+			case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE":
+				return rest
+			}
+		}
+	}
+	return ""
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// ReadOnly tells the queries run on a read only transaction, write statements are rejected on them
+func (a stepRunner) ReadOnly(params map[string]string) bool {
+	readOnly, err := strconv.ParseBool(params[readOnlyParam])
+	return err == nil && readOnly
+}
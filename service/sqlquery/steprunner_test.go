@@ -0,0 +1,27 @@
+package sqlquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReturnsRows(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT * FROM t":    true,
+		"  (select 1)":       true,
+		"EXPLAIN SELECT 1":   true,
+		"UPDATE t SET a = 1": false,
+		"":                   false,
+		"WITH recent AS (SELECT id FROM t) SELECT * FROM recent":                                   true,
+		"with recursive n(i) AS (SELECT 1 UNION SELECT i + 1 FROM n WHERE i < 5) select i from n":  true,
+		"WITH old AS (SELECT id FROM t WHERE done) DELETE FROM t WHERE id IN (SELECT id FROM old)": false,
+		"WITH a AS (SELECT 1), b AS (SELECT 2) UPDATE t SET a = (SELECT * FROM a)":                 false,
+		"WITH src AS (SELECT 'select' AS kind) INSERT INTO t SELECT kind FROM src":                 false,
+		`WITH "update" AS (SELECT 1) SELECT * FROM "update"`:                                       true,
+	}
+
+	for query, expected := range tests {
+		assert.Equal(t, expected, returnsRows(query), query)
+	}
+}
@@ -3,8 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/tasker/entities"
+	"github.com/tasker/service/secrets"
+	"github.com/tasker/tracing"
 )
 
 type StepRunner interface {
@@ -21,6 +24,31 @@ func validStepRunners(runners map[entities.StepType]StepRunner) error {
 	return nil
 }
 
-func (s service) runStep(ctx context.Context, step entities.Step) (string, error) {
-	return s.stepRunners[step.Type].RunStep(ctx, step.Params)
+// runStep resolves the secret references of the step params right before running it, the secret values are only
+// kept in memory and get redacted from the returned error
+func (s service) runStep(ctx context.Context, step entities.Step) (result string, err error) {
+	ctx, span := s.tracer.Start(ctx, "run step", tracing.InternalSpanKind)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+	span.SetAttribute("step.id", step.ID)
+	span.SetAttribute("step.type", string(step.Type))
+
+	if secrets.RedactorFromContext(ctx) == nil {
+		ctx = secrets.ContextWithRedactor(ctx, secrets.NewRedactor())
+	}
+	redactor := secrets.RedactorFromContext(ctx)
+
+	params, err := s.resolveSecrets(ctx, step.Params)
+	if err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	result, err = s.stepRunners[step.Type].RunStep(ctx, params)
+	if !isDryRun(ctx) {
+		s.metrics.StepFinished(step.Type, err != nil, time.Since(start))
+	}
+	return result, redactor.RedactError(err)
 }
@@ -0,0 +1,212 @@
+package storageop
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tasker/service"
+)
+
+const (
+	storageOpParam            = "storage_op"
+	storageKeyParam           = "storage_key"
+	storageValueParam         = "storage_value"
+	storageExpectedValueParam = "storage_expected_value"
+	storageFieldParam         = "storage_field"
+	storageIncrementParam     = "storage_increment"
+	storageTTLParam           = "storage_ttl"
+	storageListSideParam      = "storage_list_side"
+)
+
+const (
+	DeleteOp        = "delete"
+	ExistsOp        = "exists"
+	ExpireOp        = "expire"
+	IncrementOp     = "increment"
+	CompareAndSetOp = "compare_and_set"
+	ListPushOp      = "list_push"
+	ListPopOp       = "list_pop"
+	HashGetOp       = "hash_get"
+	HashSetOp       = "hash_set"
+)
+
+type Repository interface {
+	Delete(ctx context.Context, key string) (bool, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Increment(ctx context.Context, key string, by int64) (int64, error)
+	CompareAndSet(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error)
+	ListPush(ctx context.Context, key, value string, front bool) (int64, error)
+	ListPop(ctx context.Context, key string, front bool) (string, error)
+	HashGet(ctx context.Context, key, field string) (string, error)
+	HashSet(ctx context.Context, key, field, value string) error
+}
+
+type stepRunner struct {
+	repo Repository
+}
+
+func NewStepRunner(repo Repository) stepRunner {
+	return stepRunner{repo: repo}
+}
+
+func (a stepRunner) RunStep(ctx context.Context, params map[string]string) (string, error) {
+	op, found := params[storageOpParam]
+	if !found {
+		return "", fmt.Errorf("no op param found for storage op step")
+	}
+
+	key, err := getParam(params, storageKeyParam)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, err = service.ContextWithStorageScopeParam(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	switch op {
+	case DeleteOp:
+		deleted, err := a.repo.Delete(ctx, key)
+		return strconv.FormatBool(deleted), err
+
+	case ExistsOp:
+		exists, err := a.repo.Exists(ctx, key)
+		return strconv.FormatBool(exists), err
+
+	case ExpireOp:
+		//Expiring with a zero ttl deletes the key, so the ttl is mandatory
+		ttlStr, err := getParam(params, storageTTLParam)
+		if err != nil {
+			return "", err
+		}
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil || ttl <= 0 {
+			return "", fmt.Errorf("invalid ttl param found for storage op step, expire needs a positive duration: %s", ttlStr)
+		}
+		found, err := a.repo.Expire(ctx, key, ttl)
+		return strconv.FormatBool(found), err
+
+	case IncrementOp:
+		by := int64(1)
+		if byStr, found := params[storageIncrementParam]; found {
+			if by, err = strconv.ParseInt(byStr, 10, 64); err != nil {
+				return "", fmt.Errorf("invalid increment param found for storage op step: %w", err)
+			}
+		}
+		result, err := a.repo.Increment(ctx, key, by)
+		return strconv.FormatInt(result, 10), err
+
+	case CompareAndSetOp:
+		return a.compareAndSet(ctx, key, params)
+
+	case ListPushOp:
+		value, err := getParam(params, storageValueParam)
+		if err != nil {
+			return "", err
+		}
+		front, err := isFront(params, false)
+		if err != nil {
+			return "", err
+		}
+		length, err := a.repo.ListPush(ctx, key, value, front)
+		return strconv.FormatInt(length, 10), err
+
+	case ListPopOp:
+		//By default lists behave as queues, pushing to the back and popping from the front
+		front, err := isFront(params, true)
+		if err != nil {
+			return "", err
+		}
+		return a.repo.ListPop(ctx, key, front)
+
+	case HashGetOp:
+		field, err := getParam(params, storageFieldParam)
+		if err != nil {
+			return "", err
+		}
+		return a.repo.HashGet(ctx, key, field)
+
+	case HashSetOp:
+		field, err := getParam(params, storageFieldParam)
+		if err != nil {
+			return "", err
+		}
+		value, err := getParam(params, storageValueParam)
+		if err != nil {
+			return "", err
+		}
+		return value, a.repo.HashSet(ctx, key, field, value)
+
+	default:
+		return "", fmt.Errorf("invalid op param %q found for storage op step", op)
+	}
+}
+
+// compareAndSet fails the step when the value was not swapped, so a failure step can handle the conflict
+func (a stepRunner) compareAndSet(ctx context.Context, key string, params map[string]string) (string, error) {
+	value, err := getParam(params, storageValueParam)
+	if err != nil {
+		return "", err
+	}
+	expected := params[storageExpectedValueParam]
+	if expected == service.UseLastStepResultKey {
+		expected = params[service.LastStepResultKey]
+	}
+	ttl, err := getTTL(params)
+	if err != nil {
+		return "", err
+	}
+
+	swapped, err := a.repo.CompareAndSet(ctx, key, expected, value, ttl)
+	if err != nil {
+		return "", err
+	}
+	if !swapped {
+		return "", fmt.Errorf("compare and set of key %s failed, the value was not the expected one", key)
+	}
+	return value, nil
+}
+
+// getParam returns a mandatory param, resolving the use of the last step result
+func getParam(params map[string]string, name string) (string, error) {
+	value, found := params[name]
+	if !found {
+		return "", fmt.Errorf("no %s param found for storage op step", name)
+	}
+	if value == service.UseLastStepResultKey {
+		value, found = params[service.LastStepResultKey]
+		if !found {
+			return "", fmt.Errorf("requested to use last step result as %s but there was no last step result", name)
+		}
+	}
+	return value, nil
+}
+
+func getTTL(params map[string]string) (time.Duration, error) {
+	ttlStr, found := params[storageTTLParam]
+	if !found {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(ttlStr)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid ttl param found for storage op step: %s", ttlStr)
+	}
+	return ttl, nil
+}
+
+func isFront(params map[string]string, defaultFront bool) (bool, error) {
+	switch params[storageListSideParam] {
+	case "":
+		return defaultFront, nil
+	case "front":
+		return true, nil
+	case "back":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid list side param found for storage op step, must be front or back")
+	}
+}
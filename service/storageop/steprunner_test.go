@@ -0,0 +1,120 @@
+package storageop
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+}
+
+func (m *mockRepository) Delete(ctx context.Context, key string) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) Exists(ctx context.Context, key string) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, key, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) Increment(ctx context.Context, key string, by int64) (int64, error) {
+	args := m.Called(ctx, key, by)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepository) CompareAndSet(ctx context.Context, key, expected, value string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, key, expected, value, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) ListPush(ctx context.Context, key, value string, front bool) (int64, error) {
+	args := m.Called(ctx, key, value, front)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepository) ListPop(ctx context.Context, key string, front bool) (string, error) {
+	args := m.Called(ctx, key, front)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRepository) HashGet(ctx context.Context, key, field string) (string, error) {
+	args := m.Called(ctx, key, field)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRepository) HashSet(ctx context.Context, key, field, value string) error {
+	args := m.Called(ctx, key, field, value)
+	return args.Error(0)
+}
+
+func TestRunStep_InvalidOp(t *testing.T) {
+	runner := NewStepRunner(&mockRepository{})
+
+	_, err := runner.RunStep(context.Background(), map[string]string{storageOpParam: "rename", storageKeyParam: "counter"})
+
+	assert.EqualError(t, err, `invalid op param "rename" found for storage op step`)
+}
+
+func TestRunStep_Increment(t *testing.T) {
+	repo := &mockRepository{}
+	repo.On("Increment", mock.Anything, "counter", int64(1)).Return(int64(4), nil)
+	runner := NewStepRunner(repo)
+
+	result, err := runner.RunStep(context.Background(), map[string]string{storageOpParam: IncrementOp, storageKeyParam: "counter"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "4", result)
+	repo.AssertExpectations(t)
+}
+
+func TestRunStep_CompareAndSetMismatch(t *testing.T) {
+	repo := &mockRepository{}
+	repo.On("CompareAndSet", mock.Anything, "lock", "", "owner", time.Minute).Return(false, nil)
+	runner := NewStepRunner(repo)
+
+	_, err := runner.RunStep(context.Background(), map[string]string{
+		storageOpParam:    CompareAndSetOp,
+		storageKeyParam:   "lock",
+		storageValueParam: "owner",
+		storageTTLParam:   "1m",
+	})
+
+	assert.EqualError(t, err, "compare and set of key lock failed, the value was not the expected one")
+	repo.AssertExpectations(t)
+}
+
+func TestRunStep_ListPopDefaultsToFront(t *testing.T) {
+	repo := &mockRepository{}
+	repo.On("ListPop", mock.Anything, "queue", true).Return("first", nil)
+	runner := NewStepRunner(repo)
+
+	result, err := runner.RunStep(context.Background(), map[string]string{storageOpParam: ListPopOp, storageKeyParam: "queue"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "first", result)
+	repo.AssertExpectations(t)
+}
+
+func TestRunStep_ExpireNeedsPositiveTTL(t *testing.T) {
+	repo := &mockRepository{}
+	runner := NewStepRunner(repo)
+
+	_, err := runner.RunStep(context.Background(), map[string]string{storageOpParam: ExpireOp, storageKeyParam: "counter"})
+	assert.EqualError(t, err, "no storage_ttl param found for storage op step")
+
+	_, err = runner.RunStep(context.Background(), map[string]string{storageOpParam: ExpireOp, storageKeyParam: "counter", storageTTLParam: "0s"})
+	assert.EqualError(t, err, "invalid ttl param found for storage op step, expire needs a positive duration: 0s")
+
+	repo.AssertNotCalled(t, "Expire", mock.Anything, mock.Anything, mock.Anything)
+}
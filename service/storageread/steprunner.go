@@ -3,6 +3,8 @@ package storageread
 import (
 	"context"
 	"fmt"
+
+	"github.com/tasker/service"
 )
 
 const (
@@ -28,5 +30,15 @@ func (a stepRunner) RunStep(ctx context.Context, params map[string]string) (stri
 		return "", fmt.Errorf("no key param found for storage read step")
 	}
 
+	ctx, err := service.ContextWithStorageScopeParam(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
 	return a.repo.Get(ctx, key)
 }
+
+// ReadOnly is always true, reads don't change the storage
+func (a stepRunner) ReadOnly(params map[string]string) bool {
+	return true
+}
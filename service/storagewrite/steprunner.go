@@ -3,6 +3,7 @@ package storagewrite
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/tasker/service"
 )
@@ -10,10 +11,11 @@ import (
 const (
 	storageKeyParam   = "storage_key"
 	storageValueParam = "storage_value"
+	storageTTLParam   = "storage_ttl"
 )
 
 type Repository interface {
-	Set(ctx context.Context, key, value string) error
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
 }
 
 type stepRunner struct {
@@ -49,5 +51,19 @@ func (a stepRunner) RunStep(ctx context.Context, params map[string]string) (stri
 		}
 	}
 
-	return value, a.repo.Set(ctx, key, value)
+	// Get optional ttl from params, without it the key never expires
+	var ttl time.Duration
+	if ttlStr, found := params[storageTTLParam]; found {
+		var err error
+		if ttl, err = time.ParseDuration(ttlStr); err != nil || ttl < 0 {
+			return "", fmt.Errorf("invalid ttl param found for storage write step: %s", ttlStr)
+		}
+	}
+
+	ctx, err := service.ContextWithStorageScopeParam(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	return value, a.repo.Set(ctx, key, value, ttl)
 }
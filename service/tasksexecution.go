@@ -3,27 +3,51 @@ package service
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/tasker/entities"
 	"github.com/tasker/http"
+	"github.com/tasker/service/secrets"
+	"github.com/tasker/tracing"
 )
 
 const LastStepResultKey = "last_step_result"
 const UseLastStepResultKey = "use_last_step_result"
 
-// ExecuteTask
+// ExecuteTask runs the task inside a span, child of the span or incoming trace context on ctx
+func (s service) ExecuteTask(ctx context.Context, taskID int, scheduleID int, idempToken string) (entities.Execution, error) {
+	ctx, span := s.tracer.Start(ctx, "execute task", tracing.InternalSpanKind)
+	defer span.End()
+	span.SetAttribute("task.id", taskID)
+	span.SetAttribute("schedule.id", scheduleID)
+	span.SetAttribute("execution.idempotency_token", idempToken)
+
+	exec, err := s.executeTask(ctx, taskID, scheduleID, idempToken)
+	if err != nil {
+		span.SetError(err)
+		return exec, err
+	}
+	span.SetAttribute("execution.id", exec.ID)
+	span.SetAttribute("execution.status", string(exec.Status))
+	if exec.Status != entities.SuccessExecutionStatus {
+		span.SetError(fmt.Errorf("execution finished with %s status", exec.Status))
+	}
+	return exec, nil
+}
+
+// executeTask
 // TODO: We need to distinguish between system errors and execution errors:
 // -check when to throw each one
 // -when and what to save in DB
 // -rollback scenarios
-func (s service) ExecuteTask(ctx context.Context, taskID int, scheduleID int, idempToken string) (entities.Execution, error) {
+func (s service) executeTask(ctx context.Context, taskID int, scheduleID int, idempToken string) (entities.Execution, error) {
 	//Check idempotency
 	exec, err := s.storage.GetExecutionIdempotency(ctx, idempToken)
 	switch {
 	case err != nil && !http.IsNotFoundErr(err):
 		return entities.Execution{}, fmt.Errorf("checking idempotency: %w", err)
-	case exec != (entities.Execution{}): //Already executed, return result
+	case exec.ID != 0: //Already executed, return result
 		return exec, nil
 	}
 
@@ -33,15 +57,81 @@ func (s service) ExecuteTask(ctx context.Context, taskID int, scheduleID int, id
 		return entities.Execution{}, fmt.Errorf("getting task to execute: %w", err)
 	}
 
+	exec = entities.Execution{ScheduledTask: scheduleID, TaskID: taskID, IdempotencyToken: idempToken}
+	return s.runExecution(ctx, task, exec, s.runTracedStep)
+}
+
+// runExecution runs the task steps with run and saves the execution with their trace
+func (s service) runExecution(ctx context.Context, task entities.Task, exec entities.Execution, run stepRun) (entities.Execution, error) {
+	//Every step of the execution redacts the secrets resolved by the previous ones
+	ctx = secrets.ContextWithRedactor(ctx, secrets.NewRedactor())
+	workspaceID, _ := WorkspaceFromContext(ctx)
+	execInfo := ExecutionInfo{WorkspaceID: workspaceID, TaskID: exec.TaskID, ScheduleID: exec.ScheduledTask, Token: exec.IdempotencyToken}
+	ctx = ContextWithExecutionInfo(ctx, execInfo)
+
 	//Initialize execution values with success status
-	exec = entities.Execution{
-		Status:           entities.SuccessExecutionStatus,
-		ScheduledTask:    scheduleID,
-		TaskID:           taskID,
-		IdempotencyToken: idempToken,
-		ExecutedTime:     time.Now(),
+	exec.WorkspaceID = workspaceID
+	exec.Status = entities.SuccessExecutionStatus
+	exec.ExecutedTime = time.Now()
+
+	//Track the execution so the shutdown can drain it, or save it with the traces of the steps finished so far
+	runID, err := s.runs.start(exec)
+	if err != nil {
+		return entities.Execution{}, err
+	}
+	redactor := secrets.RedactorFromContext(ctx)
+	tracked := func(ctx context.Context, trace entities.StepTrace, step entities.Step) (entities.StepTrace, error) {
+		trace, err := run(ctx, trace, step)
+		recorded := trace
+		recorded.Params = make(map[string]string, len(trace.Params))
+		for key, value := range trace.Params {
+			recorded.Params[key] = value
+		}
+		s.runs.stepFinished(runID, redactTrace(redactor, recorded))
+		return trace, err
+	}
+
+	exec.Steps = s.runSteps(ctx, &exec, task, tracked)
+	for i, trace := range exec.Steps {
+		step := task.Steps[trace.Step]
+		if trace.FailureStep {
+			step = *step.FailureStep
+		}
+		exec.Steps[i].ParamsHash = stepParamsHash(step)
+	}
+
+	//Clean the execution scoped storage
+	if s.cleaner != nil {
+		if err := s.cleaner.CleanExecution(ctx, execInfo); err != nil {
+			slog.ErrorContext(ctx, "cleaning execution scoped storage", "error", err)
+		}
+	}
+
+	//The shutdown already saved it as interrupted if it didn't finish in time
+	if !s.runs.finish(runID) {
+		exec.Status = entities.InterruptedExecutionStatus
+		return exec, errInterrupted
 	}
 
+	s.metrics.ExecutionFinished(exec, time.Since(exec.ExecutedTime))
+
+	//Save execution on DB
+	exec, err = s.storage.SaveExecution(ctx, exec)
+	if err != nil {
+		return entities.Execution{}, fmt.Errorf("saving execution: %w", err)
+	}
+
+	return exec, nil
+}
+
+// stepRun runs the step described by the trace and returns the trace with the step output, the error fails the step
+type stepRun func(ctx context.Context, trace entities.StepTrace, step entities.Step) (entities.StepTrace, error)
+
+// runSteps runs the task steps in order with run, each one gets the result of the previous one. A failed step runs its
+// failure step, if any, and ends the execution with a handled failure or a failure status. It returns the trace of the
+// steps that ran, with the secrets resolved during the execution redacted
+func (s service) runSteps(ctx context.Context, exec *entities.Execution, task entities.Task, run stepRun) []entities.StepTrace {
+	var traces []entities.StepTrace
 	var stepResult string
 
 	//Iterate steps one by one
@@ -52,12 +142,16 @@ func (s service) ExecuteTask(ctx context.Context, taskID int, scheduleID int, id
 		}
 
 		//Run step
-		stepResult, err = s.runStep(ctx, step)
+		stepCtx := ContextWithStepIndex(ctx, i)
+		trace, err := run(stepCtx, newStepTrace(i, false, step), step)
+		traces = append(traces, trace)
+		stepResult = trace.Output
 		if err != nil {
 			//If it fails, check for failure steps
 			if step.FailureStep != nil {
 				step.FailureStep.Params[LastStepResultKey] = stepResult
-				_, err = s.runStep(ctx, *step.FailureStep)
+				trace, err = run(stepCtx, newStepTrace(i, true, *step.FailureStep), *step.FailureStep)
+				traces = append(traces, trace)
 				if err == nil {
 					//The failure step run successfully, we finish the execution with a handled failure status
 					exec.Status = entities.HandledFailureExecutionStatus
@@ -70,11 +164,38 @@ func (s service) ExecuteTask(ctx context.Context, taskID int, scheduleID int, id
 		}
 	}
 
-	//Save execution on DB
-	exec, err = s.storage.SaveExecution(ctx, exec)
+	//The steps get the real results, the traces are saved and returned so they only keep the redacted ones
+	redactor := secrets.RedactorFromContext(ctx)
+	for i := range traces {
+		traces[i] = redactTrace(redactor, traces[i])
+	}
+	return traces
+}
+
+// runTracedStep runs the step for real
+func (s service) runTracedStep(ctx context.Context, trace entities.StepTrace, step entities.Step) (entities.StepTrace, error) {
+	result, err := s.runStep(ctx, step)
+	trace.Mode, trace.Output = entities.RunStepTraceMode, result
 	if err != nil {
-		return entities.Execution{}, fmt.Errorf("saving execution: %w", err)
+		trace.Error = err.Error()
 	}
+	return trace, err
+}
 
-	return exec, nil
+// redactTrace redacts the output, error and params of the trace, the params are a copy of the step ones
+func redactTrace(redactor *secrets.Redactor, trace entities.StepTrace) entities.StepTrace {
+	trace.Output, trace.Error = redactor.Redact(trace.Output), redactor.Redact(trace.Error)
+	for key, value := range trace.Params {
+		trace.Params[key] = redactor.Redact(value)
+	}
+	return trace
+}
+
+// newStepTrace copies the params so the trace keeps what the step was sent
+func newStepTrace(index int, failureStep bool, step entities.Step) entities.StepTrace {
+	params := make(map[string]string, len(step.Params))
+	for key, value := range step.Params {
+		params[key] = value
+	}
+	return entities.StepTrace{Step: index, FailureStep: failureStep, Type: step.Type, Params: params}
 }
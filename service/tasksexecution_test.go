@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/tasker/entities"
+	"github.com/tasker/service/secrets"
 )
 
 //TODO: add test to check that last step result is being setted
@@ -71,11 +72,15 @@ func Test_service_ExecuteTask_StepExecution_Success(t *testing.T) {
 	mockStorage.On("GetTask", mock.Anything, 1).Return(task, nil)
 
 	expectedExecution := entities.Execution{
+		WorkspaceID:      entities.DefaultWorkspaceID,
 		Status:           entities.SuccessExecutionStatus,
 		ScheduledTask:    1,
 		TaskID:           1,
 		IdempotencyToken: "idemp-token",
 		ExecutedTime:     time.Time{},
+		Steps: []entities.StepTrace{
+			{Step: 0, Type: "test", Mode: entities.RunStepTraceMode, Params: map[string]string{}, Output: "step-result", ParamsHash: stepParamsHash(task.Steps[0])},
+		},
 	}
 	//SHOULD FAIL for MISSING ID IN EXPECTED
 	mockStorage.On("SaveExecution", mock.Anything, expectedExecution).Return(expectedExecution, nil)
@@ -83,7 +88,7 @@ func Test_service_ExecuteTask_StepExecution_Success(t *testing.T) {
 	mockStepRunner := MockStepRunner{}
 	var expectedParams map[string]string
 	mockStepRunner.On("RunStep", mock.Anything, expectedParams).Return("step-result", nil)
-	emptyStepRunners["test"] = mockStepRunner
+	emptyStepRunners["test"] = &mockStepRunner
 
 	srv := NewService(&mockStorage, emptyStepRunners)
 
@@ -111,18 +116,22 @@ func Test_service_ExecuteTask_StepExecution_Failure_NoFailureStep(t *testing.T)
 	mockStorage.On("GetTask", mock.Anything, 1).Return(task, nil)
 
 	expectedExecution := entities.Execution{
+		WorkspaceID:      entities.DefaultWorkspaceID,
 		Status:           entities.FailureExecutionStatus,
 		ScheduledTask:    1,
 		TaskID:           1,
 		IdempotencyToken: "idemp-token",
 		ExecutedTime:     time.Time{},
+		Steps: []entities.StepTrace{
+			{Step: 0, Type: "test", Mode: entities.RunStepTraceMode, Params: map[string]string{}, Output: "", Error: "mocked runstep error", ParamsHash: stepParamsHash(task.Steps[0])},
+		},
 	}
 	mockStorage.On("SaveExecution", mock.Anything, expectedExecution).Return(expectedExecution, nil)
 
 	mockStepRunner := MockStepRunner{}
 	var expectedParams map[string]string
 	mockStepRunner.On("RunStep", mock.Anything, expectedParams).Return("", errors.New("mocked runstep error"))
-	emptyStepRunners["test"] = mockStepRunner
+	emptyStepRunners["test"] = &mockStepRunner
 
 	srv := NewService(&mockStorage, emptyStepRunners)
 
@@ -155,11 +164,16 @@ func Test_service_ExecuteTask_StepExecution_FailureStepExecutionError(t *testing
 	mockStorage.On("GetTask", mock.Anything, 1).Return(task, nil)
 
 	expectedExecution := entities.Execution{
+		WorkspaceID:      entities.DefaultWorkspaceID,
 		Status:           entities.FailureExecutionStatus,
 		ScheduledTask:    1,
 		TaskID:           1,
 		IdempotencyToken: "idemp-token",
 		ExecutedTime:     time.Time{},
+		Steps: []entities.StepTrace{
+			{Step: 0, Type: "test", Mode: entities.RunStepTraceMode, Params: map[string]string{}, Output: "", Error: "mocked runstep error", ParamsHash: stepParamsHash(task.Steps[0])},
+			{Step: 0, FailureStep: true, Type: "test", Mode: entities.RunStepTraceMode, Params: map[string]string{"last_step_result": ""}, Error: "mocked failure step runstep error", ParamsHash: stepParamsHash(*task.Steps[0].FailureStep)},
+		},
 	}
 	mockStorage.On("SaveExecution", mock.Anything, expectedExecution).Return(expectedExecution, nil)
 
@@ -170,7 +184,7 @@ func Test_service_ExecuteTask_StepExecution_FailureStepExecutionError(t *testing
 	}
 	mockStepRunner.On("RunStep", mock.Anything, expectedParams1).Return("", errors.New("mocked runstep error"))
 	mockStepRunner.On("RunStep", mock.Anything, expectedParams2).Return("", errors.New("mocked failure step runstep error"))
-	emptyStepRunners["test"] = mockStepRunner
+	emptyStepRunners["test"] = &mockStepRunner
 
 	srv := NewService(&mockStorage, emptyStepRunners)
 
@@ -203,11 +217,16 @@ func Test_service_ExecuteTask_StepExecution_FailureStepExecution_Success(t *test
 	mockStorage.On("GetTask", mock.Anything, 1).Return(task, nil)
 
 	expectedExecution := entities.Execution{
+		WorkspaceID:      entities.DefaultWorkspaceID,
 		Status:           entities.HandledFailureExecutionStatus,
 		ScheduledTask:    1,
 		TaskID:           1,
 		IdempotencyToken: "idemp-token",
 		ExecutedTime:     time.Time{},
+		Steps: []entities.StepTrace{
+			{Step: 0, Type: "test", Mode: entities.RunStepTraceMode, Params: map[string]string{}, Output: "", Error: "mocked runstep error", ParamsHash: stepParamsHash(task.Steps[0])},
+			{Step: 0, FailureStep: true, Type: "test", Mode: entities.RunStepTraceMode, Params: map[string]string{"last_step_result": ""}, ParamsHash: stepParamsHash(*task.Steps[0].FailureStep)},
+		},
 	}
 	mockStorage.On("SaveExecution", mock.Anything, expectedExecution).Return(expectedExecution, nil)
 
@@ -218,7 +237,7 @@ func Test_service_ExecuteTask_StepExecution_FailureStepExecution_Success(t *test
 	}
 	mockStepRunner.On("RunStep", mock.Anything, expectedParams1).Return("", errors.New("mocked runstep error"))
 	mockStepRunner.On("RunStep", mock.Anything, expectedParams2).Return("", nil)
-	emptyStepRunners["test"] = mockStepRunner
+	emptyStepRunners["test"] = &mockStepRunner
 
 	srv := NewService(&mockStorage, emptyStepRunners)
 
@@ -246,18 +265,22 @@ func Test_service_ExecuteTask_StepExecution_FailureSavingExecution(t *testing.T)
 	mockStorage.On("GetTask", mock.Anything, 1).Return(task, nil)
 
 	expectedExecution := entities.Execution{
+		WorkspaceID:      entities.DefaultWorkspaceID,
 		Status:           entities.SuccessExecutionStatus,
 		ScheduledTask:    1,
 		TaskID:           1,
 		IdempotencyToken: "idemp-token",
 		ExecutedTime:     time.Time{},
+		Steps: []entities.StepTrace{
+			{Step: 0, Type: "test", Mode: entities.RunStepTraceMode, Params: map[string]string{}, Output: "step-result", ParamsHash: stepParamsHash(task.Steps[0])},
+		},
 	}
 	mockStorage.On("SaveExecution", mock.Anything, expectedExecution).Return(entities.Execution{}, errors.New("mocked save exec error"))
 
 	mockStepRunner := MockStepRunner{}
 	var expectedParams map[string]string
 	mockStepRunner.On("RunStep", mock.Anything, expectedParams).Return("step-result", nil)
-	emptyStepRunners["test"] = mockStepRunner
+	emptyStepRunners["test"] = &mockStepRunner
 
 	srv := NewService(&mockStorage, emptyStepRunners)
 
@@ -268,3 +291,47 @@ func Test_service_ExecuteTask_StepExecution_FailureSavingExecution(t *testing.T)
 	mockStorage.AssertExpectations(t)
 	mockStepRunner.AssertExpectations(t)
 }
+
+func Test_service_ExecuteTask_RedactsSavedTraces(t *testing.T) {
+	cipher, err := secrets.NewAESGCM(testSecretsKey)
+	assert.NoError(t, err)
+	encrypted, err := cipher.Encrypt([]byte("s3cr3t"))
+	assert.NoError(t, err)
+
+	mockStorage := MockStorage{}
+	mockStorage.On("GetExecutionIdempotency", mock.Anything, "idemp-token").Return(entities.Execution{}, nil)
+	mockStorage.On("GetSecret", mock.Anything, "api_key").Return(encrypted, nil)
+	task := entities.Task{ID: 1, Steps: []entities.Step{
+		{ID: 1, Type: "test", Params: map[string]string{"value": "secret://api_key"}},
+		{ID: 2, Type: "test", Params: map[string]string{}},
+	}}
+	mockStorage.On("GetTask", mock.Anything, 1).Return(task, nil)
+	expectedExecution := entities.Execution{
+		WorkspaceID:      entities.DefaultWorkspaceID,
+		Status:           entities.FailureExecutionStatus,
+		ScheduledTask:    1,
+		TaskID:           1,
+		IdempotencyToken: "idemp-token",
+		ExecutedTime:     time.Time{},
+		Steps: []entities.StepTrace{
+			{Step: 0, Type: "test", Mode: entities.RunStepTraceMode, Params: map[string]string{"value": "secret://api_key"}, Output: "wrote [REDACTED]", ParamsHash: stepParamsHash(task.Steps[0])},
+			{Step: 1, Type: "test", Mode: entities.RunStepTraceMode, Params: map[string]string{LastStepResultKey: "wrote [REDACTED]"}, Error: "rejected [REDACTED]", ParamsHash: stepParamsHash(task.Steps[1])},
+		},
+	}
+	mockStorage.On("SaveExecution", mock.Anything, expectedExecution).Return(expectedExecution, nil)
+
+	//The second step gets the real output of the first one
+	mockStepRunner := MockStepRunner{}
+	mockStepRunner.On("RunStep", mock.Anything, map[string]string{"value": "s3cr3t"}).Return("wrote s3cr3t", nil)
+	mockStepRunner.On("RunStep", mock.Anything, map[string]string{LastStepResultKey: "wrote s3cr3t"}).Return("", errors.New("rejected s3cr3t"))
+	emptyStepRunners["test"] = &mockStepRunner
+
+	srv := NewService(&mockStorage, emptyStepRunners, WithCipher(cipher))
+
+	execution, err := srv.ExecuteTask(context.Background(), 1, 1, "idemp-token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedExecution, execution)
+	mockStorage.AssertExpectations(t)
+	mockStepRunner.AssertExpectations(t)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/entities"
+	"github.com/tasker/tracing"
+)
+
+type recordingExporter struct {
+	spans []tracing.SpanData
+}
+
+func (r *recordingExporter) ExportSpans(ctx context.Context, spans []tracing.SpanData) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func Test_service_ExecuteTask_Spans(t *testing.T) {
+	mockStorage := MockStorage{}
+	mockStorage.On("GetExecutionIdempotency", mock.Anything, "idemp-token").Return(entities.Execution{}, nil)
+	mockStorage.On("GetTask", mock.Anything, 1).Return(entities.Task{ID: 1, Steps: []entities.Step{{ID: 5, Type: "test"}}}, nil)
+	mockStorage.On("SaveExecution", mock.Anything, mock.Anything).Return(entities.Execution{ID: 9, Status: entities.FailureExecutionStatus}, nil)
+
+	mockStepRunner := MockStepRunner{}
+	var stepCtx context.Context
+	mockStepRunner.On("RunStep", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stepCtx = args.Get(0).(context.Context)
+	}).Return("", errors.New("mocked runstep error"))
+	stepRunners := map[entities.StepType]StepRunner{}
+	for stepType, stepRunner := range emptyStepRunners {
+		stepRunners[stepType] = stepRunner
+	}
+	stepRunners["test"] = &mockStepRunner
+
+	exporter := &recordingExporter{}
+	tracer := tracing.NewTracer(exporter, 0)
+	srv := NewService(&mockStorage, stepRunners, WithTracer(tracer))
+
+	incoming, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(t, err)
+	ctx := tracing.ContextWithRemoteSpanContext(context.Background(), incoming)
+
+	_, err = srv.ExecuteTask(ctx, 1, 2, "idemp-token")
+	assert.NoError(t, err)
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	if assert.Len(t, exporter.spans, 2) {
+		step, exec := exporter.spans[0], exporter.spans[1]
+		assert.Equal(t, "execute task", exec.Name)
+		assert.Equal(t, incoming.TraceID, exec.TraceID)
+		assert.Equal(t, incoming.SpanID, exec.ParentSpanID)
+		assert.Equal(t, 9, exec.Attributes["execution.id"])
+		assert.True(t, exec.Failed)
+
+		assert.Equal(t, "run step", step.Name)
+		assert.Equal(t, exec.SpanID, step.ParentSpanID)
+		assert.Equal(t, "test", step.Attributes["step.type"])
+		assert.Equal(t, "mocked runstep error", step.ErrorMessage)
+		assert.Equal(t, step.SpanID, tracing.SpanContextFromContext(stepCtx).SpanID, "the step runner gets the step span")
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tasker/entities"
+)
+
+type workspaceKey struct{}
+
+// ContextWithWorkspace sets the workspace every storage read and write of the context is done on
+func ContextWithWorkspace(ctx context.Context, workspaceID int) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+// WorkspaceFromContext returns the workspace of the context, found is false when none was set and the default workspace
+// is returned
+func WorkspaceFromContext(ctx context.Context) (int, bool) {
+	workspaceID, found := ctx.Value(workspaceKey{}).(int)
+	if !found {
+		return entities.DefaultWorkspaceID, false
+	}
+	return workspaceID, true
+}
+
+func (s service) CreateWorkspace(ctx context.Context, workspace entities.Workspace) (entities.Workspace, error) {
+	workspace.CreatedAt = time.Now().UTC()
+	workspace, err := s.storage.SaveWorkspace(ctx, workspace)
+	if err != nil {
+		return entities.Workspace{}, fmt.Errorf("saving workspace: %w", err)
+	}
+	s.audit(ctx, entities.AuditEvent{Action: entities.CreateAuditAction, EntityType: entities.WorkspaceAuditEntity, EntityID: strconv.Itoa(workspace.ID)}, nil, workspace)
+
+	return workspace, nil
+}
+
+func (s service) GetWorkspaces(ctx context.Context) ([]entities.Workspace, error) {
+	workspaces, err := s.storage.GetWorkspaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting workspaces: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+func (s service) GetWorkspaceByName(ctx context.Context, name string) (entities.Workspace, error) {
+	workspace, err := s.storage.GetWorkspaceByName(ctx, name)
+	if err != nil {
+		return entities.Workspace{}, fmt.Errorf("getting workspace: %w", err)
+	}
+
+	return workspace, nil
+}
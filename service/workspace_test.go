@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tasker/entities"
+)
+
+func TestWorkspaceFromContext(t *testing.T) {
+	workspaceID, found := WorkspaceFromContext(context.Background())
+	assert.False(t, found)
+	assert.Equal(t, entities.DefaultWorkspaceID, workspaceID)
+
+	workspaceID, found = WorkspaceFromContext(ContextWithWorkspace(context.Background(), 3))
+	assert.True(t, found)
+	assert.Equal(t, 3, workspaceID)
+}
+
+func Test_service_CreateWorkspace_Audited(t *testing.T) {
+	auditLog := &recordingAuditLog{}
+	mockStorage := MockStorage{}
+	srv := NewService(&mockStorage, emptyStepRunners, WithAuditLog(auditLog))
+
+	mockStorage.On("SaveWorkspace", mock.Anything, mock.MatchedBy(func(w entities.Workspace) bool {
+		return w.Name == "acme" && !w.CreatedAt.IsZero()
+	})).Return(entities.Workspace{ID: 2, Name: "acme"}, nil)
+
+	workspace, err := srv.CreateWorkspace(context.Background(), entities.Workspace{Name: "acme"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, workspace.ID)
+	if assert.Len(t, auditLog.events, 1) {
+		assert.Equal(t, entities.WorkspaceAuditEntity, auditLog.events[0].EntityType)
+		assert.Equal(t, "2", auditLog.events[0].EntityID)
+	}
+	mockStorage.AssertExpectations(t)
+}
+
+func Test_service_ExecuteTask_OnTheContextWorkspace(t *testing.T) {
+	mockStorage := MockStorage{}
+	inWorkspace := mock.MatchedBy(func(ctx context.Context) bool {
+		workspaceID, _ := WorkspaceFromContext(ctx)
+		return workspaceID == 2
+	})
+	mockStorage.On("GetExecutionIdempotency", inWorkspace, "idemp-token").Return(entities.Execution{}, nil)
+	mockStorage.On("GetTask", inWorkspace, 1).Return(entities.Task{ID: 1, WorkspaceID: 2, Steps: []entities.Step{{ID: 1, Type: "test"}}}, nil)
+	mockStorage.On("SaveExecution", inWorkspace, mock.MatchedBy(func(exec entities.Execution) bool {
+		return exec.WorkspaceID == 2
+	})).Return(entities.Execution{ID: 5, WorkspaceID: 2}, nil)
+
+	mockStepRunner := MockStepRunner{}
+	mockStepRunner.On("RunStep", mock.MatchedBy(func(ctx context.Context) bool {
+		exec, _ := ExecutionInfoFromContext(ctx)
+		return exec.WorkspaceID == 2
+	}), mock.Anything).Return("step-result", nil)
+	emptyStepRunners["test"] = &mockStepRunner
+	srv := NewService(&mockStorage, emptyStepRunners)
+
+	execution, err := srv.ExecuteTask(ContextWithWorkspace(context.Background(), 2), 1, 0, "idemp-token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, execution.WorkspaceID)
+	mockStorage.AssertExpectations(t)
+	mockStepRunner.AssertExpectations(t)
+}
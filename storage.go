@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tasker/repo/executionDB"
+)
+
+const storageUsage = "usage: tasker storage move-legacy-keys"
+
+// runStorage runs the storage subcommand on the execution storage, not scoped to any workspace
+func runStorage(repo executionDB.Repository, args []string) error {
+	if len(args) == 0 || args[0] != "move-legacy-keys" {
+		return fmt.Errorf(storageUsage)
+	}
+
+	moved, err := executionDB.MoveLegacyKeys(context.Background(), repo)
+	fmt.Printf("moved %d keys under workspace:1:\n", moved)
+	return err
+}
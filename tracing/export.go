@@ -0,0 +1,185 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP/JSON status codes
+const (
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+)
+
+type otlpExporter struct {
+	client      *http.Client
+	url         string
+	serviceName string
+}
+
+// NewOTLPExporter exports the spans to a collector with OTLP/HTTP using the JSON encoding, endpoint is the collector
+// base URL, e.g. http://localhost:4318
+func NewOTLPExporter(endpoint, serviceName string, timeout time.Duration) Exporter {
+	return otlpExporter{
+		client:      &http.Client{Timeout: timeout},
+		url:         strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		serviceName: serviceName,
+	}
+}
+
+func (e otlpExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(otlpRequest(e.serviceName, spans))
+	if err != nil {
+		return fmt.Errorf("encoding spans: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("preparing export request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("exporting spans to %s: %w", e.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("exporting spans to %s failed with code %d: %s", e.url, resp.StatusCode, msg)
+	}
+	return nil
+}
+
+func otlpRequest(serviceName string, spans []SpanData) map[string]any {
+	otlpSpans := make([]map[string]any, len(spans))
+	for i, span := range spans {
+		status := map[string]any{"code": otlpStatusOK}
+		if span.Failed {
+			status = map[string]any{"code": otlpStatusError, "message": span.ErrorMessage}
+		}
+		otlpSpan := map[string]any{
+			"traceId":           span.TraceID.String(),
+			"spanId":            span.SpanID.String(),
+			"name":              span.Name,
+			"kind":              span.Kind,
+			"startTimeUnixNano": strconv.FormatInt(span.Start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.End.UnixNano(), 10),
+			"attributes":        otlpAttributes(span.Attributes),
+			"status":            status,
+		}
+		if span.ParentSpanID.IsValid() {
+			otlpSpan["parentSpanId"] = span.ParentSpanID.String()
+		}
+		otlpSpans[i] = otlpSpan
+	}
+
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": serviceName}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "github.com/tasker/tracing"},
+				"spans": otlpSpans,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attributes map[string]any) []map[string]any {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]map[string]any, len(keys))
+	for i, key := range keys {
+		var value map[string]any
+		switch v := attributes[key].(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		result[i] = map[string]any{"key": key, "value": value}
+	}
+	return result
+}
+
+type fileExporter struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileExporter appends the spans to the file as JSON lines, meant for local development and tests
+func NewFileExporter(path string) Exporter {
+	return &fileExporter{path: path}
+}
+
+// FileSpan is a span as written by the file exporter
+type FileSpan struct {
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Name         string         `json:"name"`
+	Kind         SpanKind       `json:"kind"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Error        string         `json:"error,omitempty"`
+}
+
+func (e *fileExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, span := range spans {
+		fileSpan := FileSpan{
+			TraceID:    span.TraceID.String(),
+			SpanID:     span.SpanID.String(),
+			Name:       span.Name,
+			Kind:       span.Kind,
+			Start:      span.Start,
+			End:        span.End,
+			Attributes: span.Attributes,
+			Error:      span.ErrorMessage,
+		}
+		if span.ParentSpanID.IsValid() {
+			fileSpan.ParentSpanID = span.ParentSpanID.String()
+		}
+		if err := encoder.Encode(fileSpan); err != nil {
+			return fmt.Errorf("encoding span: %w", err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	file, err := os.OpenFile(e.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening spans file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("writing spans file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader carries the trace context as defined by W3C Trace Context
+const TraceparentHeader = "traceparent"
+
+const sampledFlag = 0x01
+
+// Traceparent formats the span context as a version 00 traceparent header value
+func (sc SpanContext) Traceparent() string {
+	flags := 0
+	if sc.Sampled {
+		flags = sampledFlag
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a traceparent header value, accepting future versions as long as they start with the
+// version 00 fields
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("invalid traceparent version %q", version)
+	}
+
+	var sc SpanContext
+	if err := decodeHex(traceID, sc.TraceID[:]); err != nil || !sc.TraceID.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent trace id %q", traceID)
+	}
+	if err := decodeHex(spanID, sc.SpanID[:]); err != nil || !sc.SpanID.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent parent id %q", spanID)
+	}
+	var flagsByte [1]byte
+	if err := decodeHex(flags, flagsByte[:]); err != nil {
+		return SpanContext{}, fmt.Errorf("invalid traceparent flags %q", flags)
+	}
+	sc.Sampled = flagsByte[0]&sampledFlag != 0
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex of exactly the destination length
+func decodeHex(value string, dst []byte) error {
+	if len(value) != hex.EncodedLen(len(dst)) || strings.ToLower(value) != value {
+		return fmt.Errorf("invalid length or case")
+	}
+	_, err := hex.Decode(dst, []byte(value))
+	return err
+}
+
+// Inject sets the traceparent header of the current span context, if any
+func Inject(ctx context.Context, header http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		header.Set(TraceparentHeader, sc.Traceparent())
+	}
+}
+
+// Extract sets the span context of a valid incoming traceparent header as the remote parent on the context, invalid
+// headers are ignored and start a new trace
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
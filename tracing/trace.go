@@ -0,0 +1,186 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+type SpanID [8]byte
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// SpanContext identifies a span across services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type SpanKind int
+
+// The span kinds, numbered as on OTLP
+const (
+	InternalSpanKind SpanKind = 1
+	ServerSpanKind   SpanKind = 2
+	ClientSpanKind   SpanKind = 3
+)
+
+// Span is an operation of a trace, it's exported when ended if its trace is sampled
+type Span struct {
+	tracer       *Tracer
+	mu           sync.Mutex
+	name         string
+	kind         SpanKind
+	spanContext  SpanContext
+	parentSpanID SpanID
+	start        time.Time
+	end          time.Time
+	attributes   map[string]any
+	errMsg       string
+	failed       bool
+	ended        bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.spanContext
+}
+
+// SetAttribute sets a string, int, int64, float64 or bool attribute, any other type is set as its string
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// SetError marks the span as failed, nil errors are ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.errMsg = err.Error()
+}
+
+// End finishes the span, only the first call has effect
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	data := s.data()
+	s.mu.Unlock()
+
+	if s.spanContext.Sampled {
+		s.tracer.enqueue(data)
+	}
+}
+
+func (s *Span) data() SpanData {
+	attributes := make(map[string]any, len(s.attributes))
+	for k, v := range s.attributes {
+		attributes[k] = v
+	}
+	return SpanData{
+		Name:         s.name,
+		Kind:         s.kind,
+		TraceID:      s.spanContext.TraceID,
+		SpanID:       s.spanContext.SpanID,
+		ParentSpanID: s.parentSpanID,
+		Start:        s.start,
+		End:          s.end,
+		Attributes:   attributes,
+		Failed:       s.failed,
+		ErrorMessage: s.errMsg,
+	}
+}
+
+// SpanData is an ended span, as given to the exporters
+type SpanData struct {
+	Name         string
+	Kind         SpanKind
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]any
+	Failed       bool
+	ErrorMessage string
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// ContextWithSpan sets the span as the parent of the spans started with the context
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext sets the span of another service as the parent of the spans started with the context
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the current span context, local or remote
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.spanContext
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	maxQueuedSpans       = 2048
+	defaultFlushInterval = 5 * time.Second
+)
+
+// Exporter sends the ended spans to a tracing backend
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+}
+
+// Tracer starts spans and exports them in batches every flush interval. A tracer without exporter still creates the
+// span contexts, so the trace context is propagated even when tracing is disabled
+type Tracer struct {
+	exporter Exporter
+	mu       sync.Mutex
+	queue    []SpanData
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewTracer creates a tracer exporting to exporter, nil disables the export. A non-positive flushInterval uses the
+// default of 5 seconds
+func NewTracer(exporter Exporter, flushInterval time.Duration) *Tracer {
+	t := &Tracer{exporter: exporter, stop: make(chan struct{}), done: make(chan struct{})}
+	if exporter == nil {
+		close(t.done)
+		return t
+	}
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	go func() {
+		defer close(t.done)
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.flush(context.Background())
+			case <-t.stop:
+				return
+			}
+		}
+	}()
+	return t
+}
+
+// Start starts a span, child of the span or remote span context on ctx, and returns the context holding it
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: map[string]any{},
+	}
+
+	if parent := SpanContextFromContext(ctx); parent.IsValid() {
+		span.spanContext = SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Sampled: parent.Sampled}
+		span.parentSpanID = parent.SpanID
+	} else {
+		span.spanContext = SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) enqueue(span SpanData) {
+	if t.exporter == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queue) >= maxQueuedSpans {
+		//The exporter can't keep up, drop the span rather than growing forever
+		return
+	}
+	t.queue = append(t.queue, span)
+}
+
+func (t *Tracer) flush(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.queue
+	t.queue = nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+	if err := t.exporter.ExportSpans(ctx, spans); err != nil {
+		slog.ErrorContext(ctx, "exporting spans", "spans", len(spans), "error", err)
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops the periodic export and exports the queued spans
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.exporter == nil {
+		return nil
+	}
+	select {
+	case <-t.stop:
+	default:
+		close(t.stop)
+	}
+	<-t.done
+	return t.flush(ctx)
+}
@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := map[string]struct {
+		value   string
+		want    SpanContext
+		wantErr bool
+	}{
+		"sampled": {
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want: SpanContext{
+				TraceID: TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+				SpanID:  SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+				Sampled: true,
+			},
+		},
+		"future version with extra fields": {
+			value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra",
+			want: SpanContext{
+				TraceID: TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+				SpanID:  SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			},
+		},
+		"empty":            {value: "", wantErr: true},
+		"invalid version":  {value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		"zero trace id":    {value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		"zero span id":     {value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		"uppercase":        {value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		"version 00 extra": {value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: true},
+		"short parent id":  {value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01", wantErr: true},
+		"invalid flags":    {value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz", wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sc, err := ParseTraceparent(test.value)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, sc)
+		})
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	tracer := NewTracer(nil, 0)
+	incoming := http.Header{}
+	incoming.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx := Extract(context.Background(), incoming)
+	ctx, span := tracer.Start(ctx, "child", InternalSpanKind)
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID.String())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext().SpanID.String()+"-01", outgoing.Get(TraceparentHeader))
+
+	//Invalid incoming headers start a new trace
+	incoming.Set(TraceparentHeader, "garbage")
+	_, span = tracer.Start(Extract(context.Background(), incoming), "root", InternalSpanKind)
+	assert.True(t, span.SpanContext().IsValid())
+	assert.NotEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID.String())
+}
+
+func readFileSpans(t *testing.T, path string) []FileSpan {
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var spans []FileSpan
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span FileSpan
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &span))
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+func TestTracer_FileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	tracer := NewTracer(NewFileExporter(path), time.Hour)
+
+	ctx, parent := tracer.Start(context.Background(), "parent", InternalSpanKind)
+	_, child := tracer.Start(ctx, "child", ClientSpanKind)
+	child.SetAttribute("http.status_code", 500)
+	child.SetError(errors.New("boom"))
+	child.End()
+	parent.End()
+	parent.End()
+
+	//Spans of unsampled traces are not exported
+	unsampled := ContextWithRemoteSpanContext(context.Background(), SpanContext{TraceID: newTraceID(), SpanID: newSpanID()})
+	_, span := tracer.Start(unsampled, "unsampled", InternalSpanKind)
+	span.End()
+
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	spans := readFileSpans(t, path)
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "child", spans[0].Name)
+		assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+		assert.Equal(t, spans[1].TraceID, spans[0].TraceID)
+		assert.Equal(t, "boom", spans[0].Error)
+		assert.Equal(t, float64(500), spans[0].Attributes["http.status_code"])
+		assert.Empty(t, spans[1].ParentSpanID)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(NewOTLPExporter(server.URL+"/", "tasker-test", time.Second), time.Hour)
+	_, span := tracer.Start(context.Background(), "execute task", InternalSpanKind)
+	span.SetAttribute("task.id", 7)
+	span.SetError(errors.New("failed"))
+	span.End()
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	resourceSpans := received["resourceSpans"].([]any)[0].(map[string]any)
+	resourceAttributes := resourceSpans["resource"].(map[string]any)["attributes"].([]any)
+	assert.Equal(t, map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "tasker-test"}}, resourceAttributes[0])
+
+	otlpSpan := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	assert.Equal(t, span.SpanContext().TraceID.String(), otlpSpan["traceId"])
+	assert.Equal(t, "execute task", otlpSpan["name"])
+	assert.Equal(t, []any{map[string]any{"key": "task.id", "value": map[string]any{"intValue": "7"}}}, otlpSpan["attributes"])
+	assert.Equal(t, map[string]any{"code": float64(2), "message": "failed"}, otlpSpan["status"])
+	assert.NotContains(t, otlpSpan, "parentSpanId")
+}
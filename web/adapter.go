@@ -10,18 +10,33 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/tasker/entities"
 	httpErr "github.com/tasker/http"
+	"github.com/tasker/tracing"
 )
 
 type Service interface {
 	CreateTask(ctx context.Context, task entities.Task) (entities.Task, error)
 	GetTask(ctx context.Context, taskID int) (entities.Task, error)
+	GetTasks(ctx context.Context, filter entities.TaskFilter) (entities.TaskPage, error)
+	ExportBundle(ctx context.Context) (entities.Bundle, error)
+	ImportBundle(ctx context.Context, bundle entities.Bundle, dryRun bool) (entities.ImportResult, error)
 	ExecuteTask(ctx context.Context, taskID int, scheduleID int, idempToken string) (entities.Execution, error)
+	DryRunTask(ctx context.Context, taskID int, dryRun entities.DryRun) (entities.DryRunResult, error)
+	ResumeExecution(ctx context.Context, executionID int, idempToken string) (entities.Execution, error)
 	CreateSchedule(ctx context.Context, sch entities.ScheduledTask) (entities.ScheduledTask, error)
 	ExecuteScheduledTasks(ctx context.Context) error
+	CreateSecret(ctx context.Context, secret entities.Secret) (entities.Secret, error)
+	GetAuditEvents(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error)
+	CreateAPIKey(ctx context.Context, key entities.APIKey) (entities.APIKey, string, error)
+	GetAPIKeys(ctx context.Context) ([]entities.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int) error
+	Authenticate(ctx context.Context, secret string) (entities.APIKey, error)
+	Readiness(ctx context.Context) entities.Readiness
 }
 
 type adapter struct {
 	service Service
+	// authEnabled requires API keys, see Authenticate
+	authEnabled bool
 }
 
 func (a adapter) CreateTask(w http.ResponseWriter, r *http.Request) {
@@ -29,31 +44,31 @@ func (a adapter) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 	receivedTask := entities.Task{}
 	if err := decode(r, &receivedTask); err != nil {
-		httpErr.JSONHandleError(w, httpErr.WrapError(err, httpErr.ErrBadRequest))
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid request body")))
 		return
 	}
 
 	if err := receivedTask.IsValid(); err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	task, err := a.service.CreateTask(ctx, receivedTask)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	taskJSON, err := json.Marshal(task)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	_, err = w.Write([]byte(fmt.Sprintf(`{"msg": "task saved successfully", "task": %s}`, taskJSON)))
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 }
@@ -63,26 +78,26 @@ func (a adapter) GetTask(w http.ResponseWriter, r *http.Request) {
 
 	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
 	if err != nil {
-		httpErr.JSONHandleError(w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid task ID")))
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid task ID")))
 		return
 	}
 
 	task, err := a.service.GetTask(ctx, taskID)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	taskJSON, err := json.Marshal(task)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write(taskJSON)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 }
@@ -92,13 +107,13 @@ func (a adapter) ExecuteTask(w http.ResponseWriter, r *http.Request) {
 
 	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
 	if err != nil {
-		httpErr.JSONHandleError(w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid task ID")))
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid task ID")))
 		return
 	}
 
 	schID, err := strconv.Atoi(chi.URLParam(r, "scheduleID"))
 	if err != nil {
-		httpErr.JSONHandleError(w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid schedule ID")))
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid schedule ID")))
 		return
 	}
 
@@ -106,30 +121,75 @@ func (a adapter) ExecuteTask(w http.ResponseWriter, r *http.Request) {
 		Token string `json:"idempotency_token"`
 	}{}
 	if err := decode(r, &idempotencyTokenMsg); err != nil {
-		httpErr.JSONHandleError(w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid idempotency token")))
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid idempotency token")))
 		return
 	}
 	if idempotencyTokenMsg.Token == "" {
-		httpErr.JSONHandleError(w, httpErr.ErrBadRequest.WithMessage("invalid idempotency token"))
+		httpErr.JSONHandleError(ctx, w, httpErr.ErrBadRequest.WithMessage("invalid idempotency token"))
 		return
 	}
 
+	//Join the trace of the caller when it sends a traceparent header
+	ctx = tracing.Extract(ctx, r.Header)
 	execution, err := a.service.ExecuteTask(ctx, taskID, schID, idempotencyTokenMsg.Token)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	execJSON, err := json.Marshal(execution)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write(execJSON)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
+
+// ResumeExecution reruns the failed execution of the path from its failed step, reusing the outputs of the steps before it
+func (a adapter) ResumeExecution(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	executionID, err := strconv.Atoi(chi.URLParam(r, "executionID"))
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid execution ID")))
+		return
+	}
+
+	idempotencyTokenMsg := struct {
+		Token string `json:"idempotency_token"`
+	}{}
+	if err := decode(r, &idempotencyTokenMsg); err != nil {
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid idempotency token")))
+		return
+	}
+	if idempotencyTokenMsg.Token == "" {
+		httpErr.JSONHandleError(ctx, w, httpErr.ErrBadRequest.WithMessage("invalid idempotency token"))
+		return
+	}
+
+	ctx = tracing.Extract(ctx, r.Header)
+	execution, err := a.service.ResumeExecution(ctx, executionID, idempotencyTokenMsg.Token)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	execJSON, err := json.Marshal(execution)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(execJSON)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 }
@@ -139,7 +199,7 @@ func (a adapter) CreateSchedule(w http.ResponseWriter, r *http.Request) {
 
 	receivedSchedule := ScheduledTask{}
 	if err := decode(r, &receivedSchedule); err != nil {
-		httpErr.JSONHandleError(w, httpErr.WrapError(err, httpErr.ErrBadRequest))
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid request body")))
 		return
 	}
 	sch := entities.ScheduledTask{
@@ -151,26 +211,26 @@ func (a adapter) CreateSchedule(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := sch.IsValid(); err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	sch, err := a.service.CreateSchedule(ctx, sch)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	schJSON, err := json.Marshal(sch)
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	_, err = w.Write([]byte(fmt.Sprintf(`{"msg": "schedule saved successfully", "schedule": %s}`, schJSON)))
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 }
@@ -179,14 +239,48 @@ func (a adapter) ExecuteScheduledTasks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	if err := a.service.ExecuteScheduledTasks(ctx); err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, err := w.Write([]byte("scheduled tasks execution finished successfully"))
 	if err != nil {
-		httpErr.JSONHandleError(w, err)
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
+
+func (a adapter) CreateSecret(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	receivedSecret := entities.Secret{}
+	if err := decode(r, &receivedSecret); err != nil {
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid request body")))
+		return
+	}
+
+	if err := receivedSecret.IsValid(); err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	secret, err := a.service.CreateSecret(ctx, receivedSecret)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	secretJSON, err := json.Marshal(secret)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, err = w.Write([]byte(fmt.Sprintf(`{"msg": "secret saved successfully", "secret": %s}`, secretJSON)))
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
 		return
 	}
 }
@@ -197,6 +291,8 @@ func decode(r *http.Request, val any) error {
 	return decoder.Decode(val)
 }
 
-func NewAdapter(srv Service) *adapter {
-	return &adapter{service: srv}
+// NewAdapter creates the API handlers, authEnabled requires an API key with the route scope on the routes using
+// Authenticate and RequireScope
+func NewAdapter(srv Service, authEnabled bool) *adapter {
+	return &adapter{service: srv, authEnabled: authEnabled}
 }
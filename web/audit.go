@@ -0,0 +1,79 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tasker/entities"
+	httpErr "github.com/tasker/http"
+	"github.com/tasker/service"
+)
+
+const (
+	// ActorHeader names who makes the request, the changes it makes are audited under that name
+	ActorHeader = "X-Actor"
+
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// Actor sets the actor of the request changes from the X-Actor header
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get(ActorHeader); actor != "" {
+			r = r.WithContext(service.ContextWithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetAuditEvents replies with the audit events, newest first, filtered by the entity, entity_id, actor, from (RFC 3339)
+// and limit query params
+func (a adapter) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	filter := entities.AuditFilter{
+		EntityType: query.Get("entity"),
+		EntityID:   query.Get("entity_id"),
+		Actor:      query.Get("actor"),
+		Limit:      defaultAuditLimit,
+	}
+	if from := query.Get("from"); from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("from must be an RFC 3339 date")))
+			return
+		}
+		filter.From = fromTime
+	}
+	if limit := query.Get("limit"); limit != "" {
+		limitNumber, err := strconv.Atoi(limit)
+		if err != nil || limitNumber < 1 || limitNumber > maxAuditLimit {
+			httpErr.JSONHandleError(ctx, w, httpErr.ErrBadRequest.WithMessage("limit must be a number between 1 and 1000"))
+			return
+		}
+		filter.Limit = limitNumber
+	}
+
+	events, err := a.service.GetAuditEvents(ctx, filter)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(eventsJSON)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
@@ -0,0 +1,160 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/tasker/entities"
+	httpErr "github.com/tasker/http"
+	"github.com/tasker/service"
+)
+
+// APIKeyHeader carries the API key, the Authorization header with the Bearer scheme can be used instead
+const APIKeyHeader = "X-API-Key"
+
+// Authenticate rejects the requests without a valid API key and sets the key and its workspace on the context of the
+// others. It does nothing when the authentication is disabled, every request uses the default workspace then
+func (a adapter) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.authEnabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+
+		key, err := a.service.Authenticate(ctx, apiKeyFromRequest(r))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			httpErr.JSONHandleError(ctx, w, err)
+			return
+		}
+
+		ctx = service.ContextWithAPIKey(ctx, key)
+		next.ServeHTTP(w, r.WithContext(service.ContextWithWorkspace(ctx, key.WorkspaceID)))
+	})
+}
+
+// RequireScope rejects the requests whose API key doesn't have the scope, it must run after Authenticate
+func (a adapter) RequireScope(scope entities.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !a.authEnabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+
+			key, found := service.APIKeyFromContext(ctx)
+			if !found {
+				httpErr.JSONHandleError(ctx, w, httpErr.ErrUnauthorized.WithMessage("missing api key"))
+				return
+			}
+			if !key.HasScope(scope) {
+				httpErr.JSONHandleError(ctx, w, httpErr.ErrForbidden.WithMessage(fmt.Sprintf("api key lacks the %s scope", scope)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	scheme, key, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
+
+func (a adapter) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	receivedKey := APIKey{}
+	if err := decode(r, &receivedKey); err != nil {
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid request body")))
+		return
+	}
+	key := entities.APIKey{Name: receivedKey.Name, Scopes: receivedKey.Scopes}
+
+	if err := key.IsValid(); err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	key, secret, err := a.service.CreateAPIKey(ctx, key)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+	secretJSON, err := json.Marshal(secret)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, err = w.Write([]byte(fmt.Sprintf(`{"msg": "api key created successfully, it can't be retrieved again", "api_key": %s, "key": %s}`, keyJSON, secretJSON)))
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
+
+func (a adapter) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	keys, err := a.service.GetAPIKeys(ctx)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	keysJSON, err := json.Marshal(keys)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(keysJSON)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
+
+func (a adapter) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	keyID, err := strconv.Atoi(chi.URLParam(r, "keyID"))
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid api key ID")))
+		return
+	}
+
+	if err := a.service.RevokeAPIKey(ctx, keyID); err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte(`{"msg": "api key revoked successfully"}`))
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/tasker/entities"
+	httpErr "github.com/tasker/http"
+)
+
+const (
+	// YAMLContentType is the media type of the YAML bundles
+	YAMLContentType = "application/yaml"
+
+	// maxBundleSize bounds the imported bundles, they are read whole to be parsed
+	maxBundleSize = 5 << 20
+)
+
+// ExportBundle replies with the bundle of every task of the workspace and its schedules, on the format query param:
+// yaml, the default, or json
+func (a adapter) ExportBundle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	format, contentType := entities.YAMLBundleFormat, YAMLContentType
+	switch r.URL.Query().Get("format") {
+	case "", entities.YAMLBundleFormat:
+	case entities.JSONBundleFormat:
+		format, contentType = entities.JSONBundleFormat, "application/json"
+	default:
+		httpErr.JSONHandleError(ctx, w, httpErr.ErrBadRequest.WithMessage("format must be yaml or json"))
+		return
+	}
+
+	bundle, err := a.service.ExportBundle(ctx)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	bundleData, err := bundle.Marshal(format)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(bundleData)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
+
+// ImportBundle creates or updates the tasks and schedules of the YAML or JSON bundle of the body, with the dry_run query
+// param the changes are only described
+func (a adapter) ImportBundle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dryRun := false
+	if dryRunParam := r.URL.Query().Get("dry_run"); dryRunParam != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(dryRunParam); err != nil {
+			httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("dry_run must be true or false")))
+			return
+		}
+	}
+
+	bundleData, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBundleSize))
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid request body")))
+		return
+	}
+	bundle, err := entities.ParseBundle(bundleData)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	result, err := a.service.ImportBundle(ctx, bundle, dryRun)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(resultJSON)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
@@ -1,5 +1,7 @@
 package web
 
+import "github.com/tasker/entities"
+
 type ScheduledTask struct {
 	Name    string `json:"name"`
 	Cron    string `json:"cron"`
@@ -7,3 +9,8 @@ type ScheduledTask struct {
 	TaskID  int    `json:"task_id"`
 	Enabled bool   `json:"enabled"`
 }
+
+type APIKey struct {
+	Name   string           `json:"name"`
+	Scopes []entities.Scope `json:"scopes"`
+}
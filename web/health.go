@@ -0,0 +1,43 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	httpErr "github.com/tasker/http"
+)
+
+// Healthz replies while the process is alive, it doesn't check any dependency
+func (a adapter) Healthz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write([]byte(`{"status": "ok"}`))
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
+
+// Readyz replies with each dependency status and latency and the scheduler state, with 503 when not ready
+func (a adapter) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	readiness := a.service.Readiness(ctx)
+	readinessJSON, err := json.Marshal(readiness)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	w.WriteHeader(status)
+	_, err = w.Write(readinessJSON)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
@@ -0,0 +1,148 @@
+package web
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/tasker/entities"
+	httpErr "github.com/tasker/http"
+	"github.com/tasker/tracing"
+)
+
+const (
+	defaultTaskLimit = 50
+	maxTaskLimit     = 200
+
+	// cursorPrefix versions the cursors, they are opaque to the clients
+	cursorPrefix = "id:"
+)
+
+// TaskPage is a page of the task listing, NextCursor is empty on the last page
+type TaskPage struct {
+	Tasks      []entities.TaskSummary `json:"tasks"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// GetTasks replies with the task summaries by ID, filtered by the name (contained), step_type and scheduled query params
+// and paginated by the cursor and limit ones
+func (a adapter) GetTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	filter := entities.TaskFilter{
+		Name:     query.Get("name"),
+		StepType: entities.StepType(query.Get("step_type")),
+		Limit:    defaultTaskLimit,
+	}
+	if scheduled := query.Get("scheduled"); scheduled != "" {
+		isScheduled, err := strconv.ParseBool(scheduled)
+		if err != nil {
+			httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("scheduled must be true or false")))
+			return
+		}
+		filter.Scheduled = &isScheduled
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		afterID, err := decodeCursor(cursor)
+		if err != nil {
+			httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid cursor")))
+			return
+		}
+		filter.AfterID = afterID
+	}
+	if limit := query.Get("limit"); limit != "" {
+		limitNumber, err := strconv.Atoi(limit)
+		if err != nil || limitNumber < 1 || limitNumber > maxTaskLimit {
+			httpErr.JSONHandleError(ctx, w, httpErr.ErrBadRequest.WithMessage("limit must be a number between 1 and 200"))
+			return
+		}
+		filter.Limit = limitNumber
+	}
+
+	page, err := a.service.GetTasks(ctx, filter)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	response := TaskPage{Tasks: page.Tasks}
+	if page.NextAfterID > 0 {
+		response.NextCursor = encodeCursor(page.NextAfterID)
+	}
+	pageJSON, err := json.Marshal(response)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(pageJSON)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
+
+// DryRunTask replies with the trace of an execution of the saved task of the path, or of the unsaved task of the body on
+// /task/dry-run, that persists nothing and only runs the steps the body allows
+func (a adapter) DryRunTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID := 0
+	if taskIDParam := chi.URLParam(r, "taskID"); taskIDParam != "" {
+		var err error
+		if taskID, err = strconv.Atoi(taskIDParam); err != nil {
+			httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid task ID")))
+			return
+		}
+	}
+
+	//The body is optional for the saved tasks, without it every step is skipped
+	dryRun := entities.DryRun{}
+	if err := decode(r, &dryRun); err != nil && !errors.Is(err, io.EOF) {
+		httpErr.JSONHandleError(ctx, w, httpErr.WrapError(err, httpErr.ErrBadRequest.WithMessage("invalid request body")))
+		return
+	}
+
+	ctx = tracing.Extract(ctx, r.Header)
+	result, err := a.service.DryRunTask(ctx, taskID, dryRun)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(resultJSON)
+	if err != nil {
+		httpErr.JSONHandleError(ctx, w, err)
+		return
+	}
+}
+
+func encodeCursor(afterID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(afterID)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, found := strings.CutPrefix(string(decoded), cursorPrefix)
+	if !found {
+		return 0, errors.New("unknown cursor version")
+	}
+	return strconv.Atoi(id)
+}